@@ -0,0 +1,46 @@
+package notify
+
+import "time"
+
+// Clock abstracts time so schedulers and delivery windows can be tested
+// without waiting on the wall clock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock backed by the time package.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (systemClock) NewTimer(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTimer(d)
+	return t.C, func() { t.Stop() }
+}
+
+// TimerClock is a Clock whose timers can be stopped before they fire. Fake
+// clocks implement it so tests can tell timers the code under test is
+// waiting on from ones it abandoned.
+type TimerClock interface {
+	Clock
+	// NewTimer is like After; stop releases the timer if it has not fired.
+	NewTimer(d time.Duration) (c <-chan time.Time, stop func())
+}
+
+// NewTimer returns a channel that receives the time once d has elapsed on
+// c, and a function that releases the timer. Loops that may stop waiting
+// for another reason, such as a wake-up or cancellation, call stop once
+// they do. For clocks that are not TimerClocks, stop does nothing.
+func NewTimer(c Clock, d time.Duration) (<-chan time.Time, func()) {
+	if tc, ok := c.(TimerClock); ok {
+		return tc.NewTimer(d)
+	}
+	return c.After(d), func() {}
+}
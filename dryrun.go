@@ -0,0 +1,200 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Renderer is implemented by providers that can build the HTTP requests for
+// a payload without sending them. Every provider in this module implements it.
+type Renderer interface {
+	// Render returns the requests Send would make for payload, in order.
+	Render(ctx context.Context, payload interface{}) ([]*http.Request, error)
+}
+
+// DryRun is a Notifier that renders payloads and writes the requests to an
+// io.Writer instead of sending them.
+type DryRun struct {
+	r  Renderer
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewDryRun creates a DryRun that dumps the requests rendered by r to w.
+func NewDryRun(r Renderer, w io.Writer) *DryRun {
+	return &DryRun{r: r, w: w}
+}
+
+// Send renders payload and writes the requests with DumpRequests.
+func (d *DryRun) Send(ctx context.Context, payload interface{}) error {
+	reqs, err := d.r.Render(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return DumpRequests(d.w, reqs)
+}
+
+// DumpRequests writes reqs in a stable, human readable form: the request
+// line, the headers sorted by name, and the body. Credentials are redacted
+// from the Authorization header and from URLs: Telegram bot tokens, Discord
+// and Teams webhook paths and secret query parameters such as sig.
+// JSON bodies are indented and multipart bodies are listed part by part.
+// The request bodies are consumed.
+func DumpRequests(w io.Writer, reqs []*http.Request) error {
+	var buf bytes.Buffer
+	for i, req := range reqs {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if err := dumpRequest(&buf, req); err != nil {
+			return fmt.Errorf("failed to dump request %d: %w", i, err)
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func dumpRequest(buf *bytes.Buffer, req *http.Request) error {
+	fmt.Fprintf(buf, "%s %s\n", req.Method, redactURL(req.URL))
+
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	multipartBody := strings.HasPrefix(mediaType, "multipart/")
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range req.Header[name] {
+			if name == "Content-Type" && multipartBody {
+				// The boundary is random; leave it out to keep dumps stable.
+				v = mediaType
+			}
+			fmt.Fprintf(buf, "%s: %s\n", name, redactHeader(name, v))
+		}
+	}
+
+	if req.Body == nil {
+		return nil
+	}
+	defer req.Body.Close()
+
+	if multipartBody {
+		return dumpMultipart(buf, multipart.NewReader(req.Body, params["boundary"]))
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	buf.WriteString("\n")
+	dumpBody(buf, mediaType, body)
+	return nil
+}
+
+func dumpMultipart(buf *bytes.Buffer, r *multipart.Reader) error {
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(buf, "\n--- %s", part.FormName())
+		if part.FileName() != "" {
+			fmt.Fprintf(buf, " (file %q)", part.FileName())
+		}
+		mediaType := part.Header.Get("Content-Type")
+		if mediaType != "" {
+			fmt.Fprintf(buf, " %s", mediaType)
+		}
+		buf.WriteString("\n")
+		if mediaType == "" && json.Valid(body) {
+			mediaType = "application/json"
+		}
+		dumpBody(buf, mediaType, body)
+	}
+}
+
+// dumpBody writes body, indenting JSON.
+func dumpBody(buf *bytes.Buffer, mediaType string, body []byte) {
+	if strings.HasSuffix(mediaType, "json") && json.Indent(buf, body, "", "  ") == nil {
+		buf.WriteString("\n")
+		return
+	}
+	buf.Write(body)
+	if len(body) > 0 && body[len(body)-1] != '\n' {
+		buf.WriteString("\n")
+	}
+}
+
+var (
+	// telegramTokenPath matches the bot token of a Bot API request path.
+	telegramTokenPath = regexp.MustCompile(`^(/(?:file/)?bot)[^/]+`)
+	// discordWebhookPath matches the ID and token of a Discord webhook.
+	discordWebhookPath = regexp.MustCompile(`^(/api(?:/v\d+)?/webhooks)/[^/]+(?:/[^/]+)?`)
+	// officeWebhookPath matches the IDs and signature of a Teams incoming webhook.
+	officeWebhookPath = regexp.MustCompile(`^(/webhook(?:b2)?)/.*`)
+	// secretQueryParams are query parameters that carry credentials, such
+	// as the signature of a Power Automate workflow URL.
+	secretQueryParams = []string{"sig", "token", "access_token", "key", "code"}
+)
+
+// redactURL returns u with the secrets the providers put in URLs replaced
+// by REDACTED.
+func redactURL(u *url.URL) string {
+	r := *u
+	host := strings.ToLower(r.Hostname())
+	switch {
+	case host == "api.telegram.org":
+		r.Path = telegramTokenPath.ReplaceAllString(r.Path, "${1}REDACTED")
+	case host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com"):
+		r.Path = discordWebhookPath.ReplaceAllString(r.Path, "${1}/REDACTED")
+	case strings.HasSuffix(host, ".webhook.office.com") || host == "outlook.office.com":
+		r.Path = officeWebhookPath.ReplaceAllString(r.Path, "${1}/REDACTED")
+	}
+	r.RawPath = ""
+
+	if r.RawQuery != "" {
+		q := r.Query()
+		for _, name := range secretQueryParams {
+			if q.Has(name) {
+				q.Set(name, "REDACTED")
+			}
+		}
+		r.RawQuery = q.Encode()
+	}
+	return r.String()
+}
+
+// redactHeader hides credentials while keeping the authorization scheme.
+func redactHeader(name, value string) string {
+	if !strings.EqualFold(name, "Authorization") {
+		return value
+	}
+	if scheme, _, ok := strings.Cut(value, " "); ok {
+		return scheme + " REDACTED"
+	}
+	return "REDACTED"
+}
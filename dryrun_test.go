@@ -0,0 +1,72 @@
+package notify_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/thanpawatpiti/notify"
+	"github.com/thanpawatpiti/notify/providers/discord"
+	"github.com/thanpawatpiti/notify/providers/line"
+	"github.com/thanpawatpiti/notify/providers/msteams"
+	"github.com/thanpawatpiti/notify/providers/telegram"
+)
+
+func TestDryRun(t *testing.T) {
+	var out strings.Builder
+	n := notify.NewDryRun(line.New("secret-token", "U123"), &out)
+
+	if err := n.Send(context.Background(), "hello"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"POST https://api.line.me/v2/bot/message/push\n",
+		"Authorization: Bearer REDACTED\n",
+		`"text": "hello"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected dump to contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "secret-token") {
+		t.Errorf("expected token to be redacted, got:\n%s", got)
+	}
+}
+
+func TestDryRunRedactsURLs(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       notify.Renderer
+		payload interface{}
+		secrets []string
+		want    string
+	}{
+		{"telegram", telegram.New("123456:AAE-secret", "42"), telegram.Document{Document: telegram.FileURL("https://example.com/a.pdf")},
+			[]string{"123456:AAE-secret"}, "POST https://api.telegram.org/botREDACTED/sendDocument\n"},
+		{"discord", discord.New("https://discord.com/api/webhooks/987654/wh-secret"), "hello",
+			[]string{"987654", "wh-secret"}, "POST https://discord.com/api/webhooks/REDACTED\n"},
+		{"teams", msteams.New("https://contoso.webhook.office.com/webhookb2/guid@tenant/IncomingWebhook/abc/def/sig-secret"), "hello",
+			[]string{"guid@tenant", "sig-secret"}, "POST https://contoso.webhook.office.com/webhookb2/REDACTED\n"},
+		{"teams workflow", msteams.New("https://prod-1.westus.logic.azure.com/workflows/1/triggers/manual/paths/invoke?api-version=2016-06-01&sig=sig-secret"), "hello",
+			[]string{"sig-secret"}, "sig=REDACTED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := notify.NewDryRun(tt.r, &out).Send(context.Background(), tt.payload); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			got := out.String()
+			for _, secret := range tt.secrets {
+				if strings.Contains(got, secret) {
+					t.Errorf("expected %q to be redacted, got:\n%s", secret, got)
+				}
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("expected dump to contain %q, got:\n%s", tt.want, got)
+			}
+		})
+	}
+}
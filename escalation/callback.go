@@ -0,0 +1,126 @@
+package escalation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// callbackPrefix marks acknowledgement callback data.
+const callbackPrefix = "ack:"
+
+// CallbackData returns the callback data for an acknowledgement button,
+// e.g. a telegram.InlineKeyboardButton's CallbackData.
+func CallbackData(id string) string {
+	return callbackPrefix + id
+}
+
+// ParseCallbackData extracts the incident ID from data produced by CallbackData.
+func ParseCallbackData(data string) (string, bool) {
+	if !strings.HasPrefix(data, callbackPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(data, callbackPrefix), true
+}
+
+// HandleCallbackData acknowledges the incident referenced by data. It reports
+// false when data is not acknowledgement callback data.
+func (e *Engine) HandleCallbackData(data, by string) (bool, error) {
+	id, ok := ParseCallbackData(data)
+	if !ok {
+		return false, nil
+	}
+	return true, e.Ack(id, by)
+}
+
+// AckURL returns a link to the confirmation page of HTTPHandler mounted at
+// baseURL, for use in an msteams.ActionOpenUrl.
+func AckURL(baseURL, id string) string {
+	sep := "?"
+	if strings.Contains(baseURL, "?") {
+		sep = "&"
+	}
+	return baseURL + sep + "incident=" + url.QueryEscape(id)
+}
+
+// ackRequest is the JSON body accepted by HTTPHandler, as sent by an Adaptive
+// Card Action.Submit or Action.Http.
+type ackRequest struct {
+	Incident string `json:"incident"`
+	By       string `json:"by"`
+}
+
+// confirmPage asks for confirmation before acknowledging, so that link
+// previews and mail scanners that follow an AckURL do not acknowledge.
+var confirmPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Acknowledge incident</title></head>
+<body><form method="post">
+<input type="hidden" name="incident" value="{{.Incident}}">
+<input type="hidden" name="by" value="{{.By}}">
+<p>Acknowledge incident {{.Incident}}?</p>
+<button type="submit">Acknowledge</button>
+</form></body></html>
+`))
+
+// HTTPHandler returns a handler that acknowledges incidents. A GET of an
+// AckURL shows a confirmation page whose button acknowledges with a POST;
+// GET requests never acknowledge. POST requests read the incident ID from
+// the "incident" query or form field, or a JSON body
+// {"incident": "...", "by": "..."}. Incident IDs are unguessable, so the
+// link itself authorises the acknowledgement.
+func (e *Engine) HTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := ackRequest{
+			Incident: r.URL.Query().Get("incident"),
+			By:       r.URL.Query().Get("by"),
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			if req.Incident == "" {
+				http.Error(w, "missing incident", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			confirmPage.Execute(w, req)
+			return
+		case http.MethodPost:
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, 1<<16)
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+		} else if err := r.ParseForm(); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		} else if req.Incident == "" {
+			req.Incident, req.By = r.PostForm.Get("incident"), r.PostForm.Get("by")
+		}
+		if req.Incident == "" {
+			http.Error(w, "missing incident", http.StatusBadRequest)
+			return
+		}
+
+		switch err := e.Ack(req.Incident, req.By); {
+		case errors.Is(err, ErrNotFound):
+			http.Error(w, "incident not found", http.StatusNotFound)
+		case errors.Is(err, ErrNotActive):
+			http.Error(w, "incident is no longer active", http.StatusConflict)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			fmt.Fprintln(w, "acknowledged")
+		}
+	})
+}
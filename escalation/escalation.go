@@ -0,0 +1,325 @@
+// Package escalation notifies a chain of notify.Notifier destinations until
+// someone acknowledges the incident.
+//
+// A Policy lists the steps, for example "Telegram on-call, then the Teams
+// channel after 10 minutes, then LINE the manager". Acknowledgements arrive
+// through Engine.Ack, Telegram callback queries (see CallbackData and the
+// telegramack package) or an HTTP endpoint that Teams cards can link to
+// (see AckURL and HTTPHandler).
+package escalation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/thanpawatpiti/notify"
+	"github.com/thanpawatpiti/notify/internal/randid"
+)
+
+var (
+	// ErrNotFound is returned for unknown incident IDs.
+	ErrNotFound = errors.New("incident not found")
+	// ErrNotActive is returned when acknowledging or cancelling an incident
+	// that is no longer escalating.
+	ErrNotActive = errors.New("incident is not active")
+)
+
+// Step is one stage of an escalation policy.
+type Step struct {
+	// Notifier receives the notification for this step.
+	Notifier notify.Notifier
+	// Payload overrides the incident payload for this step when set.
+	Payload interface{}
+	// Delay is how long to wait for an acknowledgement after each
+	// notification before repeating the step or escalating.
+	Delay time.Duration
+	// Repeat is the number of additional notifications sent by this step
+	// before escalating to the next one.
+	Repeat int
+}
+
+// Policy is an ordered list of escalation steps.
+type Policy struct {
+	// Name identifies the policy in Status.
+	Name  string
+	Steps []Step
+	// Repeat is the number of times the whole policy restarts from the first
+	// step after the last step times out.
+	Repeat int
+}
+
+// PayloadFunc builds the payload for each notification. Pass one to Trigger
+// to embed the incident ID, e.g. in acknowledgement buttons.
+type PayloadFunc func(s Status) interface{}
+
+// State is the lifecycle state of an incident.
+type State string
+
+const (
+	// StateActive means the incident is still escalating.
+	StateActive State = "active"
+	// StateAcknowledged means someone acknowledged the incident.
+	StateAcknowledged State = "acknowledged"
+	// StateExhausted means every step ran without an acknowledgement.
+	StateExhausted State = "exhausted"
+	// StateCancelled means the incident was cancelled.
+	StateCancelled State = "cancelled"
+)
+
+// Status is a snapshot of an incident.
+type Status struct {
+	ID     string
+	Policy string
+	State  State
+	// Step is the index of the current (or last) policy step.
+	Step int
+	// Round is the number of times the policy has restarted.
+	Round int
+	// Notifications is the number of notifications sent so far.
+	Notifications int
+	TriggeredAt   time.Time
+	// NotifiedAt is when the latest notification was sent.
+	NotifiedAt     time.Time
+	AcknowledgedAt time.Time
+	AcknowledgedBy string
+	// LastError is the most recent delivery error, if any.
+	LastError error
+	// FinishedAt is when the incident stopped escalating.
+	FinishedAt time.Time
+}
+
+// defaultRetention is how long finished incidents are kept by default.
+const defaultRetention = 24 * time.Hour
+
+// Engine runs escalation policies.
+type Engine struct {
+	clock     notify.Clock
+	onError   func(s Status, err error)
+	retention time.Duration
+
+	mu        sync.Mutex
+	incidents map[string]*incident
+	wg        sync.WaitGroup
+}
+
+type incident struct {
+	status Status
+	policy Policy
+	done   chan struct{}
+}
+
+// Option configures an Engine.
+type Option func(*Engine)
+
+// WithClock configures the clock used for step delays. Defaults to notify.SystemClock.
+func WithClock(c notify.Clock) Option {
+	return func(e *Engine) {
+		e.clock = c
+	}
+}
+
+// WithErrorHandler configures a callback for failed notifications.
+// Escalation continues after a failure.
+func WithErrorHandler(f func(s Status, err error)) Option {
+	return func(e *Engine) {
+		e.onError = f
+	}
+}
+
+// WithRetention configures how long acknowledged, cancelled and exhausted
+// incidents remain available to Status and Incidents. Older ones are
+// removed and report ErrNotFound. Defaults to 24 hours.
+func WithRetention(d time.Duration) Option {
+	return func(e *Engine) {
+		e.retention = d
+	}
+}
+
+// New creates a new escalation Engine.
+func New(opts ...Option) *Engine {
+	e := &Engine{
+		clock:     notify.SystemClock,
+		onError:   func(Status, error) {},
+		retention: defaultRetention,
+		incidents: make(map[string]*incident),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// Trigger starts escalating payload with policy and returns the incident ID.
+// payload may be a PayloadFunc. Notifications are sent in the background
+// with ctx's values but without its cancellation.
+func (e *Engine) Trigger(ctx context.Context, policy Policy, payload interface{}) (string, error) {
+	if len(policy.Steps) == 0 {
+		return "", fmt.Errorf("policy %q has no steps", policy.Name)
+	}
+	for i, step := range policy.Steps {
+		if step.Notifier == nil {
+			return "", fmt.Errorf("policy %q step %d has no notifier", policy.Name, i)
+		}
+	}
+
+	inc := &incident{
+		status: Status{
+			ID:          randid.New(),
+			Policy:      policy.Name,
+			State:       StateActive,
+			TriggeredAt: e.clock.Now(),
+		},
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+
+	e.mu.Lock()
+	e.prune()
+	e.incidents[inc.status.ID] = inc
+	e.mu.Unlock()
+
+	e.wg.Add(1)
+	go e.run(context.WithoutCancel(ctx), inc, payload)
+
+	return inc.status.ID, nil
+}
+
+// Ack acknowledges an active incident and stops its escalation.
+func (e *Engine) Ack(id, by string) error {
+	return e.finish(id, StateAcknowledged, by)
+}
+
+// Cancel stops an active incident without acknowledging it.
+func (e *Engine) Cancel(id string) error {
+	return e.finish(id, StateCancelled, "")
+}
+
+// Status returns the current status of an incident.
+func (e *Engine) Status(id string) (Status, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.prune()
+	inc, ok := e.incidents[id]
+	if !ok {
+		return Status{}, ErrNotFound
+	}
+	return inc.status, nil
+}
+
+// Incidents returns the status of every incident, most recent first.
+func (e *Engine) Incidents() []Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.prune()
+	list := make([]Status, 0, len(e.incidents))
+	for _, inc := range e.incidents {
+		list = append(list, inc.status)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].TriggeredAt.After(list[j].TriggeredAt) })
+	return list
+}
+
+// Wait blocks until every incident has stopped escalating.
+func (e *Engine) Wait() {
+	e.wg.Wait()
+}
+
+func (e *Engine) finish(id string, state State, by string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.prune()
+	inc, ok := e.incidents[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if inc.status.State != StateActive {
+		return ErrNotActive
+	}
+	inc.status.State = state
+	inc.status.FinishedAt = e.clock.Now()
+	if state == StateAcknowledged {
+		inc.status.AcknowledgedAt = inc.status.FinishedAt
+		inc.status.AcknowledgedBy = by
+	}
+	close(inc.done)
+	return nil
+}
+
+// prune removes incidents that finished longer than the retention period
+// ago. e.mu must be held.
+func (e *Engine) prune() {
+	now := e.clock.Now()
+	for id, inc := range e.incidents {
+		if inc.status.State != StateActive && now.Sub(inc.status.FinishedAt) >= e.retention {
+			delete(e.incidents, id)
+		}
+	}
+}
+
+func (e *Engine) run(ctx context.Context, inc *incident, payload interface{}) {
+	defer e.wg.Done()
+
+	for round := 0; round <= inc.policy.Repeat; round++ {
+		for i, step := range inc.policy.Steps {
+			for attempt := 0; attempt <= step.Repeat; attempt++ {
+				status, active := e.advance(inc, round, i)
+				if !active {
+					return
+				}
+
+				p := payload
+				if step.Payload != nil {
+					p = step.Payload
+				}
+				if f, ok := p.(PayloadFunc); ok {
+					p = f(status)
+				}
+
+				if err := step.Notifier.Send(ctx, p); err != nil {
+					err = fmt.Errorf("step %d: %w", i, err)
+					e.mu.Lock()
+					inc.status.LastError = err
+					status = inc.status
+					e.mu.Unlock()
+					e.onError(status, err)
+				}
+
+				timer, stop := notify.NewTimer(e.clock, step.Delay)
+				select {
+				case <-inc.done:
+					stop()
+					return
+				case <-timer:
+				}
+			}
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if inc.status.State == StateActive {
+		inc.status.State = StateExhausted
+		inc.status.FinishedAt = e.clock.Now()
+		close(inc.done)
+	}
+}
+
+// advance records the next notification and reports whether the incident is still active.
+func (e *Engine) advance(inc *incident, round, step int) (Status, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if inc.status.State != StateActive {
+		return inc.status, false
+	}
+	inc.status.Round = round
+	inc.status.Step = step
+	inc.status.Notifications++
+	inc.status.NotifiedAt = e.clock.Now()
+	return inc.status, true
+}
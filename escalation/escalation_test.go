@@ -0,0 +1,152 @@
+package escalation
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thanpawatpiti/notify/notifytest"
+)
+
+func TestEscalation(t *testing.T) {
+	clock := notifytest.NewClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	telegram, teams, line := notifytest.NewRecorder(), notifytest.NewRecorder(), notifytest.NewRecorder()
+
+	e := New(WithClock(clock))
+	policy := Policy{
+		Name: "incident",
+		Steps: []Step{
+			{Notifier: telegram, Delay: 10 * time.Minute, Repeat: 1},
+			{Notifier: teams, Delay: 10 * time.Minute},
+			{Notifier: line},
+		},
+	}
+
+	id, err := e.Trigger(context.Background(), policy, PayloadFunc(func(s Status) interface{} {
+		return CallbackData(s.ID)
+	}))
+	if err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+
+	if !clock.BlockUntil(1, time.Second) {
+		t.Fatal("timed out waiting for a pending timer")
+	}
+	if telegram.Len() != 1 || teams.Len() != 0 {
+		t.Fatalf("expected first step only, got telegram=%d teams=%d", telegram.Len(), teams.Len())
+	}
+	clock.Advance(10 * time.Minute)
+	if !clock.BlockUntil(1, time.Second) {
+		t.Fatal("timed out waiting for a pending timer")
+	}
+	if telegram.Len() != 2 {
+		t.Fatalf("expected step to repeat, got telegram=%d", telegram.Len())
+	}
+	clock.Advance(10 * time.Minute)
+	if !clock.BlockUntil(1, time.Second) {
+		t.Fatal("timed out waiting for a pending timer")
+	}
+	if teams.Len() != 1 {
+		t.Fatalf("expected escalation to teams, got %d", teams.Len())
+	}
+
+	s, _ := e.Status(id)
+	if s.State != StateActive || s.Step != 1 || s.Notifications != 3 {
+		t.Errorf("unexpected status %+v", s)
+	}
+
+	// Following the link only shows a confirmation page.
+	srv := httptest.NewServer(e.HTTPHandler())
+	defer srv.Close()
+	resp, err := http.Get(AckURL(srv.URL, id) + "&by=alice")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("confirm request failed: %v %v", err, resp)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(page), `method="post"`) || !strings.Contains(string(page), id) {
+		t.Errorf("unexpected confirmation page %s", page)
+	}
+	if s, _ := e.Status(id); s.State != StateActive {
+		t.Fatalf("GET acknowledged the incident: %+v", s)
+	}
+
+	resp, err = http.PostForm(srv.URL, url.Values{"incident": {id}, "by": {"alice"}})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("ack request failed: %v %v", err, resp)
+	}
+	e.Wait()
+
+	s, _ = e.Status(id)
+	if s.State != StateAcknowledged || s.AcknowledgedBy != "alice" {
+		t.Errorf("unexpected status after ack %+v", s)
+	}
+	line.AssertNothingSent(t)
+	if telegram.Last() != CallbackData(id) {
+		t.Errorf("expected payload func to receive incident ID, got %v", telegram.Last())
+	}
+	if err := e.Ack(id, "bob"); err != ErrNotActive {
+		t.Errorf("expected ErrNotActive, got %v", err)
+	}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	e := New()
+	policy := Policy{Steps: []Step{{Notifier: notifytest.NewRecorder(), Delay: time.Hour}}}
+	id, _ := e.Trigger(context.Background(), policy, "db down")
+	h := e.HTTPHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, AckURL("/ack", id), nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+
+	// Action.Http posts JSON.
+	req := httptest.NewRequest(http.MethodPost, "/ack", strings.NewReader(`{"incident":"`+id+`","by":"bob"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, AckURL("/ack", id), nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a second ack, got %d", rec.Code)
+	}
+	e.Wait()
+}
+
+func TestRetention(t *testing.T) {
+	clock := notifytest.NewClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	e := New(WithClock(clock), WithRetention(time.Hour))
+	policy := Policy{Steps: []Step{{Notifier: notifytest.NewRecorder(), Delay: time.Minute}}}
+
+	acked, _ := e.Trigger(context.Background(), policy, "db down")
+	active, _ := e.Trigger(context.Background(), Policy{Steps: []Step{{Notifier: notifytest.NewRecorder(), Delay: 24 * time.Hour}}}, "disk full")
+	if err := e.Ack(acked, "alice"); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if s, err := e.Status(acked); err != nil || !s.FinishedAt.Equal(clock.Now()) {
+		t.Errorf("unexpected status %+v (%v)", s, err)
+	}
+
+	clock.Advance(time.Hour)
+	if _, err := e.Status(acked); err != ErrNotFound {
+		t.Errorf("expected acknowledged incident to be removed, got %v", err)
+	}
+	if _, err := e.Status(active); err != nil {
+		t.Errorf("expected active incident to be kept, got %v", err)
+	}
+	if n := len(e.Incidents()); n != 1 {
+		t.Errorf("expected 1 incident, got %d", n)
+	}
+	e.Cancel(active)
+	e.Wait()
+}
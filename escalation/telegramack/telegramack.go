@@ -0,0 +1,58 @@
+// Package telegramack acknowledges escalation incidents from Telegram
+// buttons created with escalation.CallbackData. It is kept apart from the
+// escalation package so that only applications using Telegram depend on
+// the Telegram provider.
+package telegramack
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/thanpawatpiti/notify/escalation"
+	"github.com/thanpawatpiti/notify/providers/telegram"
+)
+
+// Register makes r acknowledge incidents of e when a button created with
+// escalation.CallbackData is pressed, for bots that also handle other
+// updates with a telegram.Router, through a telegram.Poller or
+// telegram.Webhook.
+func Register(e *escalation.Engine, r *telegram.Router) {
+	r.Callback(escalation.CallbackData(""), func(ctx context.Context, q *telegram.CallbackQuery) error {
+		return callback(ctx, e, q)
+	})
+}
+
+// Handler returns a Telegram webhook handler that acknowledges incidents
+// of e when a button created with escalation.CallbackData is pressed and
+// ignores other updates. secretToken is the SecretToken of the
+// telegram.WebhookConfig; requests without it are rejected. The callback
+// query is answered through p in the webhook response.
+func Handler(e *escalation.Engine, p *telegram.Provider, secretToken string) http.Handler {
+	r := telegram.NewRouter()
+	Register(e, r)
+	return telegram.NewWebhook(secretToken, r, telegram.WithWebhookProvider(p))
+}
+
+// callback acknowledges the incident of an acknowledgement button.
+func callback(ctx context.Context, e *escalation.Engine, q *telegram.CallbackQuery) error {
+	by := q.From.Username
+	if by != "" {
+		by = "@" + by
+	} else if q.From.FirstName != "" {
+		by = q.From.FirstName
+	} else {
+		by = strconv.FormatInt(q.From.ID, 10)
+	}
+
+	text := "Acknowledged"
+	if _, err := e.HandleCallbackData(q.Data, by); err != nil {
+		text = "Already handled"
+	}
+	err := q.AnswerInline(ctx, telegram.CallbackAnswer{Text: text})
+	if errors.Is(err, telegram.ErrInlineReplyUnavailable) {
+		return q.Answer(ctx, text)
+	}
+	return err
+}
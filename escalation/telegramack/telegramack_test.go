@@ -0,0 +1,44 @@
+package telegramack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thanpawatpiti/notify/escalation"
+	"github.com/thanpawatpiti/notify/notifytest"
+	"github.com/thanpawatpiti/notify/providers/telegram"
+)
+
+func TestHandler(t *testing.T) {
+	e := escalation.New()
+	id, _ := e.Trigger(context.Background(), escalation.Policy{Steps: []escalation.Step{{Notifier: notifytest.NewRecorder(), Delay: time.Hour}}}, "db down")
+	h := Handler(e, telegram.New("test-token", "test-chat"), "webhook-secret")
+	body := `{"update_id":1,"callback_query":{"id":"q1","data":"` + escalation.CallbackData(id) + `","from":{"id":42,"username":"oncall"},"chat_instance":"1"}}`
+
+	// Requests without the secret token are rejected.
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+	if s, _ := e.Status(id); s.State != escalation.StateActive {
+		t.Fatalf("forged update acknowledged the incident: %+v", s)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "webhook-secret")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if !strings.Contains(rec.Body.String(), `"method":"answerCallbackQuery"`) || !strings.Contains(rec.Body.String(), "Acknowledged") {
+		t.Errorf("expected answerCallbackQuery response, got %s", rec.Body.String())
+	}
+	e.Wait()
+	if s, _ := e.Status(id); s.State != escalation.StateAcknowledged || s.AcknowledgedBy != "@oncall" {
+		t.Errorf("unexpected status %+v", s)
+	}
+}
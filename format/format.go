@@ -0,0 +1,121 @@
+// Package format converts message bodies between the markup languages
+// understood by the supported providers.
+//
+// Content is parsed from notify.FormatPlain, notify.FormatMarkdown or
+// notify.FormatHTML into a small Document tree, which can then be rendered
+// for a specific platform (Telegram MarkdownV2/HTML, Discord, Adaptive Card
+// TextBlock or plain text).
+package format
+
+import (
+	"github.com/thanpawatpiti/notify"
+)
+
+// Kind identifies the type of a Node.
+type Kind int
+
+const (
+	// KindText is literal text stored in Node.Text.
+	KindText Kind = iota
+	// KindBold is bold text. Its content is in Node.Children.
+	KindBold
+	// KindItalic is italic text. Its content is in Node.Children.
+	KindItalic
+	// KindCode is inline code stored in Node.Text.
+	KindCode
+	// KindLink is a hyperlink to Node.URL. Its label is in Node.Children.
+	KindLink
+	// KindParagraph is a block of inline nodes.
+	KindParagraph
+	// KindPre is a preformatted code block stored in Node.Text.
+	KindPre
+	// KindList is a list block. Its items are KindListItem nodes.
+	KindList
+	// KindListItem is a list entry made of inline nodes.
+	KindListItem
+)
+
+// Node is an element of a parsed Document.
+type Node struct {
+	Kind     Kind
+	Text     string // KindText, KindCode, KindPre
+	URL      string // KindLink
+	Language string // KindPre
+	Ordered  bool   // KindList
+	Children []*Node
+}
+
+// Document is a parsed message body made of block nodes
+// (KindParagraph, KindPre and KindList).
+type Document struct {
+	Blocks []*Node
+}
+
+// Parse parses src according to f. The zero Format is treated as Markdown.
+func Parse(src string, f notify.Format) *Document {
+	switch f {
+	case notify.FormatPlain:
+		return ParsePlain(src)
+	case notify.FormatHTML:
+		return ParseHTML(src)
+	default:
+		return ParseMarkdown(src)
+	}
+}
+
+// ParsePlain wraps src in a single paragraph without interpreting any markup.
+func ParsePlain(src string) *Document {
+	if src == "" {
+		return &Document{}
+	}
+	return &Document{Blocks: []*Node{paragraph(textNode(src))}}
+}
+
+// WithTitle returns a copy of d with title prepended as a bold line.
+func (d *Document) WithTitle(title string) *Document {
+	if title == "" {
+		return d
+	}
+	return d.PrependLine(&Node{Kind: KindBold, Children: []*Node{textNode(title)}})
+}
+
+// PrependLine returns a copy of d with the inline nodes added as a new first line.
+func (d *Document) PrependLine(nodes ...*Node) *Document {
+	if len(nodes) == 0 {
+		return d
+	}
+	blocks := make([]*Node, 0, len(d.Blocks)+1)
+	if len(d.Blocks) > 0 && d.Blocks[0].Kind == KindParagraph {
+		first := paragraph(append(append([]*Node(nil), nodes...), textNode("\n"))...)
+		first.Children = append(first.Children, d.Blocks[0].Children...)
+		blocks = append(blocks, first)
+		blocks = append(blocks, d.Blocks[1:]...)
+	} else {
+		blocks = append(blocks, paragraph(nodes...))
+		blocks = append(blocks, d.Blocks...)
+	}
+	return &Document{Blocks: blocks}
+}
+
+// Text returns a text node.
+func Text(s string) *Node {
+	return textNode(s)
+}
+
+// Link returns a link node labelled with label.
+func Link(label, url string) *Node {
+	return &Node{Kind: KindLink, URL: url, Children: []*Node{textNode(label)}}
+}
+
+// Convert parses src according to f and renders it with r.
+func Convert(src string, f notify.Format, r Renderer) string {
+	return r.Render(Parse(src, f))
+}
+
+func textNode(s string) *Node {
+	return &Node{Kind: KindText, Text: s}
+}
+
+func paragraph(children ...*Node) *Node {
+	return &Node{Kind: KindParagraph, Children: children}
+}
@@ -0,0 +1,121 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/thanpawatpiti/notify"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		format   notify.Format
+		renderer Renderer
+		want     string
+	}{
+		{
+			name:     "telegram markdownv2 escapes stray characters",
+			src:      "disk_usage at 95.5% (host-1)!",
+			renderer: TelegramMarkdownV2,
+			want:     `disk\_usage at 95\.5% \(host\-1\)\!`,
+		},
+		{
+			name:     "telegram markdownv2 formatting",
+			src:      "**Deploy** *done* `v1.2` [logs](https://example.com/a_(b))",
+			renderer: TelegramMarkdownV2,
+			want:     "*Deploy* _done_ `v1.2` [logs](https://example.com/a_(b\\))",
+		},
+		{
+			name:     "telegram html",
+			src:      "**a < b** & [x](https://e.com/?a=1&b=2)",
+			renderer: TelegramHTML,
+			want:     `<b>a &lt; b</b> &amp; <a href="https://e.com/?a=1&amp;b=2">x</a>`,
+		},
+		{
+			name:     "pre block with language",
+			src:      "Error:\n```go\npanic(\"x\")\n```",
+			renderer: TelegramHTML,
+			want:     "Error:\n\n<pre><code class=\"language-go\">panic(\"x\")</code></pre>",
+		},
+		{
+			name:     "lists",
+			src:      "- one\n- two\n\n1. first\n2. second",
+			renderer: TelegramMarkdownV2,
+			want:     "• one\n• two\n\n1\\. first\n2\\. second",
+		},
+		{
+			name:     "nested emphasis",
+			src:      "*a **b** c*",
+			renderer: Discord,
+			want:     "*a **b** c*",
+		},
+		{
+			name:     "intraword underscores stay literal",
+			src:      "file_name_here",
+			renderer: Plain,
+			want:     "file_name_here",
+		},
+		{
+			name:     "plain strips markup",
+			src:      "**Bold** and [link](https://example.com)",
+			renderer: Plain,
+			want:     "Bold and link (https://example.com)",
+		},
+		{
+			name:     "adaptive card",
+			src:      "**Bold** _it_ `code`",
+			renderer: AdaptiveCard,
+			want:     "**Bold** _it_ code",
+		},
+		{
+			name:     "plain format is escaped",
+			src:      "**not bold**",
+			format:   notify.FormatPlain,
+			renderer: Discord,
+			want:     `\*\*not bold\*\*`,
+		},
+		{
+			name:     "discord code block cannot be closed by its content",
+			src:      "<pre>before\n```\n**not bold**</pre>",
+			format:   notify.FormatHTML,
+			renderer: Discord,
+			want:     "```\nbefore\n`\u200b`\u200b`\u200b\n**not bold**\n```",
+		},
+		{
+			name:     "line starts are escaped after inline nodes",
+			src:      "<b>Alert</b><br># 1<br>**x**- y",
+			format:   notify.FormatHTML,
+			renderer: Discord,
+			want:     "**Alert**\n\\# 1\n\\*\\*x\\*\\*- y",
+		},
+		{
+			name:     "html input",
+			src:      "<b>Alert</b><br>CPU &gt; 90%<ul><li>web-1</li><li><i>web-2</i></li></ul>",
+			format:   notify.FormatHTML,
+			renderer: Discord,
+			want:     "**Alert**\nCPU > 90%\n\n- web-1\n- *web-2*",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Convert(tt.src, tt.format, tt.renderer)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTitle(t *testing.T) {
+	doc := ParseMarkdown("body").WithTitle("Title")
+	if got, want := TelegramMarkdownV2.Render(doc), "*Title*\nbody"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	doc = ParseMarkdown("- item").WithTitle("Title")
+	if got, want := Plain.Render(doc), "Title\n\n- item"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,182 @@
+package format
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var hrefPattern = regexp.MustCompile(`(?i)\bhref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+var classLanguagePattern = regexp.MustCompile(`(?i)\bclass\s*=\s*["']?language-([\w+#.-]+)`)
+
+// ParseHTML parses the HTML subset b/strong, i/em, code, pre, a, ul/ol/li,
+// br and p. Unknown tags are dropped while their text is kept, and
+// newlines in text are preserved as line breaks.
+func ParseHTML(src string) *Document {
+	p := &htmlParser{doc: &Document{}}
+	for len(src) > 0 {
+		lt := strings.IndexByte(src, '<')
+		if lt < 0 {
+			p.text(html.UnescapeString(src))
+			break
+		}
+		if lt > 0 {
+			p.text(html.UnescapeString(src[:lt]))
+		}
+		gt := strings.IndexByte(src[lt:], '>')
+		if gt < 0 {
+			p.text(html.UnescapeString(src[lt:]))
+			break
+		}
+		p.tag(src[lt+1 : lt+gt])
+		src = src[lt+gt+1:]
+	}
+	p.endBlock()
+	return p.doc
+}
+
+type htmlParser struct {
+	doc   *Document
+	list  *Node   // open list block, if any
+	stack []*Node // open block followed by open inline nodes
+}
+
+func (p *htmlParser) text(s string) {
+	if s == "" {
+		return
+	}
+	top := p.top()
+	if top == nil {
+		if strings.TrimSpace(s) == "" {
+			return
+		}
+		if p.list != nil {
+			// Stray text between list items is dropped, like browsers do.
+			return
+		}
+		top = paragraph()
+		p.stack = []*Node{top}
+	}
+	switch top.Kind {
+	case KindCode, KindPre:
+		top.Text += s
+	default:
+		top.Children = append(top.Children, textNode(s))
+	}
+}
+
+func (p *htmlParser) tag(raw string) {
+	closing := strings.HasPrefix(raw, "/")
+	raw = strings.TrimPrefix(raw, "/")
+	fields := strings.Fields(raw)
+	if len(fields) == 0 {
+		return
+	}
+	name := strings.ToLower(strings.TrimRight(fields[0], "/"))
+
+	switch name {
+	case "b", "strong":
+		p.inline(closing, KindBold, "")
+	case "i", "em":
+		p.inline(closing, KindItalic, "")
+	case "a":
+		href := ""
+		if m := hrefPattern.FindStringSubmatch(raw); m != nil {
+			href = html.UnescapeString(m[1] + m[2] + m[3])
+		}
+		p.inline(closing, KindLink, href)
+	case "code":
+		if top := p.top(); top != nil && top.Kind == KindPre {
+			if m := classLanguagePattern.FindStringSubmatch(raw); m != nil && !closing {
+				top.Language = m[1]
+			}
+			return
+		}
+		p.inline(closing, KindCode, "")
+	case "br":
+		p.text("\n")
+	case "p", "div":
+		p.endBlock()
+	case "pre":
+		p.endBlock()
+		if !closing {
+			p.stack = []*Node{{Kind: KindPre}}
+		}
+	case "ul", "ol":
+		p.endBlock()
+		p.endList()
+		if !closing {
+			p.list = &Node{Kind: KindList, Ordered: name == "ol"}
+		}
+	case "li":
+		p.stack = nil
+		if closing || p.list == nil {
+			return
+		}
+		item := &Node{Kind: KindListItem}
+		p.list.Children = append(p.list.Children, item)
+		p.stack = []*Node{item}
+	}
+}
+
+func (p *htmlParser) inline(closing bool, kind Kind, url string) {
+	if closing {
+		for i := len(p.stack) - 1; i > 0; i-- {
+			if p.stack[i].Kind == kind {
+				p.stack = p.stack[:i]
+				return
+			}
+		}
+		return
+	}
+	top := p.top()
+	if top == nil {
+		top = paragraph()
+		p.stack = []*Node{top}
+	}
+	if top.Kind == KindCode || top.Kind == KindPre {
+		return
+	}
+	n := &Node{Kind: kind, URL: url}
+	top.Children = append(top.Children, n)
+	p.stack = append(p.stack, n)
+}
+
+func (p *htmlParser) top() *Node {
+	if len(p.stack) == 0 {
+		return nil
+	}
+	return p.stack[len(p.stack)-1]
+}
+
+// endBlock closes the open paragraph or pre block.
+func (p *htmlParser) endBlock() {
+	if len(p.stack) == 0 {
+		p.endList()
+		return
+	}
+	block := p.stack[0]
+	p.stack = nil
+	switch block.Kind {
+	case KindParagraph:
+		if len(block.Children) > 0 {
+			p.doc.Blocks = append(p.doc.Blocks, block)
+		}
+	case KindPre:
+		block.Text = strings.Trim(block.Text, "\n")
+		p.doc.Blocks = append(p.doc.Blocks, block)
+	case KindListItem:
+		// List items stay attached to their list.
+		return
+	}
+	p.endList()
+}
+
+func (p *htmlParser) endList() {
+	if p.list != nil {
+		if len(p.list.Children) > 0 {
+			p.doc.Blocks = append(p.doc.Blocks, p.list)
+		}
+		p.list = nil
+	}
+}
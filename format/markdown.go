@@ -0,0 +1,276 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	listItemPattern = regexp.MustCompile(`^\s{0,3}([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	headingPattern  = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+)
+
+// ParseMarkdown parses the common Markdown subset: **bold**, *italic* or
+// _italic_, `code`, fenced ``` blocks, [links](url) and - / 1. lists.
+// Headings are kept as bold paragraphs. Unmatched delimiters are treated as
+// literal text, so stray underscores or asterisks never fail to parse.
+func ParseMarkdown(src string) *Document {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	doc := &Document{}
+
+	var para []string
+	var list *Node
+
+	flushPara := func() {
+		if len(para) > 0 {
+			doc.Blocks = append(doc.Blocks, paragraph(parseInline(strings.Join(para, "\n"))...))
+			para = nil
+		}
+	}
+	flushList := func() {
+		if list != nil {
+			doc.Blocks = append(doc.Blocks, list)
+			list = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flushPara()
+			flushList()
+			lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			doc.Blocks = append(doc.Blocks, &Node{Kind: KindPre, Text: strings.Join(code, "\n"), Language: lang})
+		case trimmed == "":
+			flushPara()
+			flushList()
+		case listItemPattern.MatchString(line):
+			flushPara()
+			m := listItemPattern.FindStringSubmatch(line)
+			ordered := m[1][0] >= '0' && m[1][0] <= '9'
+			if list != nil && list.Ordered != ordered {
+				flushList()
+			}
+			if list == nil {
+				list = &Node{Kind: KindList, Ordered: ordered}
+			}
+			list.Children = append(list.Children, &Node{Kind: KindListItem, Children: parseInline(m[2])})
+		case headingPattern.MatchString(line):
+			flushPara()
+			flushList()
+			m := headingPattern.FindStringSubmatch(line)
+			doc.Blocks = append(doc.Blocks, paragraph(&Node{Kind: KindBold, Children: parseInline(m[1])}))
+		default:
+			if list != nil && len(list.Children) > 0 && line != trimmed {
+				// Indented continuation of the previous list item.
+				item := list.Children[len(list.Children)-1]
+				item.Children = append(item.Children, textNode("\n"))
+				item.Children = append(item.Children, parseInline(trimmed)...)
+				continue
+			}
+			flushList()
+			para = append(para, line)
+		}
+	}
+	flushPara()
+	flushList()
+
+	return doc
+}
+
+// parseInline parses emphasis, code spans and links within a block.
+func parseInline(s string) []*Node {
+	var nodes []*Node
+	var buf strings.Builder
+
+	flush := func() {
+		if buf.Len() > 0 {
+			nodes = append(nodes, textNode(buf.String()))
+			buf.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch c {
+		case '\\':
+			if i+1 < len(s) && isASCIIPunct(s[i+1]) {
+				buf.WriteByte(s[i+1])
+				i += 2
+				continue
+			}
+		case '`':
+			n := runLength(s, i, '`')
+			delim := s[i : i+n]
+			if end := strings.Index(s[i+n:], delim); end >= 0 {
+				flush()
+				code := s[i+n : i+n+end]
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				nodes = append(nodes, &Node{Kind: KindCode, Text: code})
+				i += 2*n + end
+				continue
+			}
+			buf.WriteString(delim)
+			i += n
+			continue
+		case '*', '_':
+			n := runLength(s, i, c)
+			dl := 1
+			kind := KindItalic
+			if n >= 2 {
+				dl = 2
+				kind = KindBold
+			}
+			if canOpen(s, i, n, c) {
+				if end := findClose(s, i+dl, dl, c); end >= 0 {
+					flush()
+					nodes = append(nodes, &Node{Kind: kind, Children: parseInline(s[i+dl : end])})
+					i = end + dl
+					continue
+				}
+			}
+			buf.WriteString(s[i : i+n])
+			i += n
+			continue
+		case '[':
+			if label, url, consumed, ok := parseLink(s[i:]); ok {
+				flush()
+				nodes = append(nodes, &Node{Kind: KindLink, URL: url, Children: parseInline(label)})
+				i += consumed
+				continue
+			}
+		}
+		buf.WriteByte(c)
+		i++
+	}
+	flush()
+
+	return nodes
+}
+
+// canOpen reports whether the delimiter run of length n at i may open emphasis.
+func canOpen(s string, i, n int, c byte) bool {
+	next, _ := utf8.DecodeRuneInString(s[i+n:])
+	if i+n >= len(s) || unicode.IsSpace(next) {
+		return false
+	}
+	if c == '_' && i > 0 {
+		prev, _ := utf8.DecodeLastRuneInString(s[:i])
+		if unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+			return false
+		}
+	}
+	return true
+}
+
+// findClose returns the index of the closing delimiter (dl characters of c)
+// for emphasis opened before from, or -1 if there is none.
+func findClose(s string, from, dl int, c byte) int {
+	for j := from; j < len(s); {
+		switch s[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			n := runLength(s, j, '`')
+			if end := strings.Index(s[j+n:], s[j:j+n]); end >= 0 {
+				j += 2*n + end
+				continue
+			}
+			j += n
+			continue
+		case c:
+			n := runLength(s, j, c)
+			end := j + n
+			prev, _ := utf8.DecodeLastRuneInString(s[:j])
+			if j == 0 || unicode.IsSpace(prev) {
+				// A left-flanking run opens nested emphasis; skip past it.
+				if canOpen(s, j, n, c) {
+					ndl := min(n, 2)
+					if nested := findClose(s, j+ndl, ndl, c); nested >= 0 {
+						j = nested + ndl
+						continue
+					}
+				}
+				j = end
+				continue
+			}
+			if pos := end - dl; n >= dl && pos > from {
+				closes := true
+				if c == '_' && end < len(s) {
+					next, _ := utf8.DecodeRuneInString(s[end:])
+					closes = !unicode.IsLetter(next) && !unicode.IsDigit(next)
+				}
+				if closes {
+					return pos
+				}
+			}
+			j = end
+			continue
+		}
+		j++
+	}
+	return -1
+}
+
+// parseLink parses "[label](url)" at the start of s.
+func parseLink(s string) (label, url string, consumed int, ok bool) {
+	closeLabel := -1
+	for j := 1; j < len(s); j++ {
+		if s[j] == '\\' {
+			j++
+			continue
+		}
+		if s[j] == ']' {
+			closeLabel = j
+			break
+		}
+		if s[j] == '\n' && j+1 < len(s) && s[j+1] == '\n' {
+			return "", "", 0, false
+		}
+	}
+	if closeLabel < 0 || closeLabel+1 >= len(s) || s[closeLabel+1] != '(' {
+		return "", "", 0, false
+	}
+	depth := 0
+	for j := closeLabel + 2; j < len(s); j++ {
+		switch s[j] {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			url = strings.TrimSpace(s[closeLabel+2 : j])
+			if url == "" || strings.ContainsAny(url, " \n") {
+				return "", "", 0, false
+			}
+			return s[1:closeLabel], url, j + 1, true
+		}
+	}
+	return "", "", 0, false
+}
+
+func runLength(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
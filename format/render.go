@@ -0,0 +1,264 @@
+package format
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Renderer renders a Document into a provider-specific markup string.
+type Renderer interface {
+	Render(doc *Document) string
+}
+
+var (
+	// Plain renders text without markup. Links become "label (url)".
+	Plain Renderer = markup{
+		escape: identity,
+		bold:   identity,
+		italic: identity,
+		code:   identity,
+		pre:    func(text, _ string) string { return text },
+		link: func(label, url string) string {
+			if label == "" || label == url {
+				return url
+			}
+			return fmt.Sprintf("%s (%s)", label, url)
+		},
+		bullet: markdownBullet,
+	}
+
+	// Discord renders Discord-flavoured Markdown.
+	Discord Renderer = markup{
+		escape: escapeDiscord,
+		bold:   wrap("**"),
+		italic: wrap("*"),
+		code:   backtickCode,
+		pre: func(text, lang string) string {
+			return "```" + lang + "\n" + escapeFences(text) + "\n```"
+		},
+		link: func(label, url string) string {
+			return fmt.Sprintf("[%s](%s)", label, url)
+		},
+		bullet:     markdownBullet,
+		lineStarts: escapeLineStarts,
+	}
+
+	// AdaptiveCard renders the Markdown subset supported by Adaptive Card
+	// TextBlocks (bold, italic, links and lists). Code is rendered as text.
+	AdaptiveCard Renderer = markup{
+		escape: escapeAdaptiveCard,
+		bold:   wrap("**"),
+		italic: wrap("_"),
+		code:   escapeAdaptiveCard,
+		pre: func(text, _ string) string {
+			return escapeLineStarts(escapeAdaptiveCard(text))
+		},
+		link: func(label, url string) string {
+			return fmt.Sprintf("[%s](%s)", label, strings.ReplaceAll(url, ")", "%29"))
+		},
+		bullet:     markdownBullet,
+		lineStarts: escapeLineStarts,
+	}
+
+	// TelegramMarkdownV2 renders text for Telegram's "MarkdownV2" parse mode.
+	TelegramMarkdownV2 Renderer = markup{
+		escape: EscapeTelegramMarkdownV2,
+		bold:   wrap("*"),
+		italic: wrap("_"),
+		code: func(text string) string {
+			return "`" + EscapeTelegramCode(text) + "`"
+		},
+		pre: func(text, lang string) string {
+			return "```" + lang + "\n" + EscapeTelegramCode(text) + "\n```"
+		},
+		link: func(label, url string) string {
+			url = strings.NewReplacer(`\`, `\\`, `)`, `\)`).Replace(url)
+			return fmt.Sprintf("[%s](%s)", label, url)
+		},
+		bullet: func(i int, ordered bool) string {
+			if ordered {
+				return fmt.Sprintf("%d\\. ", i+1)
+			}
+			return "• "
+		},
+	}
+
+	// TelegramHTML renders text for Telegram's "HTML" parse mode.
+	TelegramHTML Renderer = markup{
+		escape: EscapeTelegramHTML,
+		bold:   tag("b"),
+		italic: tag("i"),
+		code: func(text string) string {
+			return "<code>" + EscapeTelegramHTML(text) + "</code>"
+		},
+		pre: func(text, lang string) string {
+			if lang == "" {
+				return "<pre>" + EscapeTelegramHTML(text) + "</pre>"
+			}
+			return fmt.Sprintf(`<pre><code class="language-%s">%s</code></pre>`, EscapeTelegramHTML(lang), EscapeTelegramHTML(text))
+		},
+		link: func(label, url string) string {
+			return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), label)
+		},
+		bullet: func(i int, ordered bool) string {
+			if ordered {
+				return fmt.Sprintf("%d. ", i+1)
+			}
+			return "• "
+		},
+	}
+)
+
+var (
+	telegramMarkdownV2Escaper = strings.NewReplacer(
+		`\`, `\\`, `_`, `\_`, `*`, `\*`, `[`, `\[`, `]`, `\]`, `(`, `\(`, `)`, `\)`,
+		`~`, `\~`, "`", "\\`", `>`, `\>`, `#`, `\#`, `+`, `\+`, `-`, `\-`, `=`, `\=`,
+		`|`, `\|`, `{`, `\{`, `}`, `\}`, `.`, `\.`, `!`, `\!`,
+	)
+	telegramCodeEscaper  = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+	telegramHTMLEscaper  = strings.NewReplacer(`&`, `&amp;`, `<`, `&lt;`, `>`, `&gt;`)
+	discordEscaper       = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `_`, `\_`, `~`, `\~`, "`", "\\`", `|`, `\|`, `[`, `\[`, `]`, `\]`)
+	adaptiveCardEscaper  = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`)
+	lineStartMarkdownSet = "#>-+"
+)
+
+// EscapeTelegramMarkdownV2 escapes every character reserved by Telegram's
+// MarkdownV2 parse mode.
+func EscapeTelegramMarkdownV2(s string) string {
+	return telegramMarkdownV2Escaper.Replace(s)
+}
+
+// EscapeTelegramHTML escapes the characters reserved by Telegram's HTML parse mode.
+func EscapeTelegramHTML(s string) string {
+	return telegramHTMLEscaper.Replace(s)
+}
+
+// EscapeTelegramCode escapes s for use inside Telegram MarkdownV2 `code`
+// and ```pre``` blocks.
+func EscapeTelegramCode(s string) string {
+	return telegramCodeEscaper.Replace(s)
+}
+
+func escapeDiscord(s string) string {
+	return discordEscaper.Replace(s)
+}
+
+func escapeAdaptiveCard(s string) string {
+	return adaptiveCardEscaper.Replace(s)
+}
+
+// escapeFences keeps text from closing a Discord code block by separating
+// its backticks with zero-width spaces.
+func escapeFences(text string) string {
+	if !strings.Contains(text, "```") {
+		return text
+	}
+	return strings.ReplaceAll(text, "`", "`\u200b")
+}
+
+// escapeLineStarts escapes characters that would start a heading, quote or
+// list when they appear at the beginning of a rendered line.
+func escapeLineStarts(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l != "" && strings.IndexByte(lineStartMarkdownSet, l[0]) >= 0 {
+			lines[i] = `\` + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func backtickCode(text string) string {
+	if strings.Contains(text, "`") {
+		return "`` " + text + " ``"
+	}
+	return "`" + text + "`"
+}
+
+func markdownBullet(i int, ordered bool) string {
+	if ordered {
+		return fmt.Sprintf("%d. ", i+1)
+	}
+	return "- "
+}
+
+func identity(s string) string { return s }
+
+func wrap(delim string) func(string) string {
+	return func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return delim + s + delim
+	}
+}
+
+func tag(name string) func(string) string {
+	return func(s string) string {
+		return "<" + name + ">" + s + "</" + name + ">"
+	}
+}
+
+// markup is a Renderer described by its per-node formatting functions.
+type markup struct {
+	escape func(string) string
+	bold   func(string) string
+	italic func(string) string
+	code   func(string) string
+	pre    func(text, lang string) string
+	link   func(label, url string) string
+	bullet func(i int, ordered bool) string
+	// lineStarts, if set, escapes the rendered lines of paragraphs and list
+	// items, where text that follows other nodes can start a line.
+	lineStarts func(string) string
+}
+
+func (m markup) Render(doc *Document) string {
+	if doc == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(doc.Blocks))
+	for _, b := range doc.Blocks {
+		switch b.Kind {
+		case KindParagraph:
+			parts = append(parts, m.lines(m.inline(b.Children)))
+		case KindPre:
+			parts = append(parts, m.pre(b.Text, b.Language))
+		case KindList:
+			items := make([]string, 0, len(b.Children))
+			for i, item := range b.Children {
+				items = append(items, m.bullet(i, b.Ordered)+m.lines(m.inline(item.Children)))
+			}
+			parts = append(parts, strings.Join(items, "\n"))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// lines applies m.lineStarts to rendered inline text.
+func (m markup) lines(s string) string {
+	if m.lineStarts == nil {
+		return s
+	}
+	return m.lineStarts(s)
+}
+
+func (m markup) inline(nodes []*Node) string {
+	var sb strings.Builder
+	for _, n := range nodes {
+		switch n.Kind {
+		case KindText:
+			sb.WriteString(m.escape(n.Text))
+		case KindBold:
+			sb.WriteString(m.bold(m.inline(n.Children)))
+		case KindItalic:
+			sb.WriteString(m.italic(m.inline(n.Children)))
+		case KindCode:
+			sb.WriteString(m.code(n.Text))
+		case KindLink:
+			sb.WriteString(m.link(m.inline(n.Children), n.URL))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,116 @@
+// Package multipart streams multipart/form-data request bodies for the
+// providers that upload files.
+package multipart
+
+import (
+	"fmt"
+	"io"
+	mimemultipart "mime/multipart"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/thanpawatpiti/notify"
+)
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// File is a file part of a multipart/form-data request.
+type File struct {
+	Field      string
+	Attachment notify.Attachment
+}
+
+// Releaser is implemented by attachment readers that hold a resource, such
+// as an open file, until they are read to the end. Body releases them once
+// their part is written, including when the request is abandoned early.
+type Releaser interface {
+	Release()
+}
+
+// Body streams fields and files as multipart/form-data and returns the
+// body with its Content-Type. File content is copied through a pipe once
+// the body is first read, so it is never held in memory as a whole and an
+// unsent request costs nothing.
+func Body(fields map[string]string, files []File) (io.ReadCloser, string) {
+	boundary := mimemultipart.NewWriter(io.Discard)
+	return &multipartReader{fields: fields, files: files, boundary: boundary.Boundary()}, boundary.FormDataContentType()
+}
+
+// multipartReader starts writing the multipart body on the first Read.
+type multipartReader struct {
+	fields   map[string]string
+	files    []File
+	boundary string
+
+	once sync.Once
+	pr   *io.PipeReader
+}
+
+func (r *multipartReader) start() {
+	r.once.Do(func() {
+		pr, pw := io.Pipe()
+		r.pr = pr
+		go func() {
+			mw := mimemultipart.NewWriter(pw)
+			if err := mw.SetBoundary(r.boundary); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			pw.CloseWithError(writeMultipart(mw, r.fields, r.files))
+		}()
+	})
+}
+
+func (r *multipartReader) Read(b []byte) (int, error) {
+	r.start()
+	return r.pr.Read(b)
+}
+
+// Close stops the writer goroutine if the body was only partly read.
+func (r *multipartReader) Close() error {
+	r.once.Do(func() {})
+	if r.pr != nil {
+		return r.pr.Close()
+	}
+	return nil
+}
+
+func writeMultipart(mw *mimemultipart.Writer, fields map[string]string, files []File) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := mw.WriteField(name, fields[name]); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(f.Field), quoteEscaper.Replace(f.Attachment.Name)))
+		h.Set("Content-Type", f.Attachment.MIMEType())
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		if err := copyFile(part, f.Attachment); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+func copyFile(w io.Writer, a notify.Attachment) error {
+	r := a.Open()
+	if rel, ok := r.(Releaser); ok {
+		defer rel.Release()
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		return fmt.Errorf("failed to read attachment %s: %w", a.Name, err)
+	}
+	return nil
+}
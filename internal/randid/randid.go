@@ -0,0 +1,19 @@
+// Package randid generates the random identifiers of scheduled jobs and
+// escalation incidents.
+package randid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// New returns a random 128-bit hex identifier. It is unguessable, so it can
+// double as a capability, e.g. in an acknowledgement link.
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("notify: failed to generate id: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
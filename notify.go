@@ -1,11 +1,25 @@
 package notify
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
+// ErrAttachmentsUnsupported is returned by providers that cannot upload files
+// and have no AttachmentUploader configured.
+var ErrAttachmentsUnsupported = errors.New("attachments are not supported by this provider")
+
+// ErrMentionNotFound is returned by a MentionResolver that has no mapping for a mention.
+var ErrMentionNotFound = errors.New("mention not found")
+
 // Notifier is the interface that all notification providers must implement.
 type Notifier interface {
 	// Send sends a payload to the provider.
@@ -27,14 +41,248 @@ type CommonMessage struct {
 	ImageURL string
 	// Color is the color of the embed/message (Hex string e.g. "#FF0000").
 	Color string
+	// Format describes the markup used in Content. Providers convert it to
+	// the closest formatting their platform supports.
+	// The zero value is treated as FormatMarkdown.
+	Format Format
+	// Attachments are files sent along with the message.
+	Attachments []Attachment
+	// Mentions are users or roles to ping. They are mapped to platform IDs
+	// with the provider's MentionResolver.
+	Mentions []Mention
+	// Severity is the importance of the message. Decorators such as
+	// QuietHours use it to decide whether the message may be delayed.
+	Severity Severity
+	// Labels describe what the message is about, e.g. {"service": "payments"}.
+	// Providers can route messages by label.
+	Labels map[string]string
+}
+
+// Severity describes how important a message is.
+type Severity int
+
+const (
+	// SeverityInfo is the default severity.
+	SeverityInfo Severity = iota
+	// SeverityWarning is for messages that need attention soon.
+	SeverityWarning
+	// SeverityCritical is for messages that must be delivered immediately.
+	SeverityCritical
+)
+
+// String returns the lower-case name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+type severityKey struct{}
+
+// ContextWithSeverity returns a copy of ctx carrying s. Use it to set the
+// severity of payloads other than CommonMessage.
+func ContextWithSeverity(ctx context.Context, s Severity) context.Context {
+	return context.WithValue(ctx, severityKey{}, s)
+}
+
+// SeverityOf returns the severity of payload: the higher of the value set
+// with ContextWithSeverity and CommonMessage.Severity.
+func SeverityOf(ctx context.Context, payload interface{}) Severity {
+	s, _ := ctx.Value(severityKey{}).(Severity)
+	if m, ok := payload.(CommonMessage); ok && m.Severity > s {
+		s = m.Severity
+	}
+	return s
+}
+
+type labelsKey struct{}
+
+// ContextWithLabels returns a copy of ctx carrying labels. Use it to label
+// payloads other than CommonMessage.
+func ContextWithLabels(ctx context.Context, labels map[string]string) context.Context {
+	return context.WithValue(ctx, labelsKey{}, labels)
+}
+
+// LabelsOf returns the labels of payload: those set with ContextWithLabels
+// merged with CommonMessage.Labels, which take precedence.
+func LabelsOf(ctx context.Context, payload interface{}) map[string]string {
+	labels, _ := ctx.Value(labelsKey{}).(map[string]string)
+	m, ok := payload.(CommonMessage)
+	if !ok || len(m.Labels) == 0 {
+		return labels
+	}
+	if len(labels) == 0 {
+		return m.Labels
+	}
+	merged := make(map[string]string, len(labels)+len(m.Labels))
+	for k, v := range labels {
+		merged[k] = v
+	}
+	for k, v := range m.Labels {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Attachment is a file sent along with a message.
+// Content is streamed from Reader when it is set, otherwise Data is used.
+// A Reader can only be consumed once, so a message carrying one should not be sent twice.
+type Attachment struct {
+	// Name is the file name shown to recipients (e.g. "report.csv").
+	Name string
+	// ContentType is the MIME type. It is guessed from Name when empty.
+	ContentType string
+	// Reader streams the file content.
+	Reader io.Reader
+	// Data holds the file content in memory. It is ignored when Reader is set.
+	Data []byte
+}
+
+// Open returns a reader for the attachment content.
+func (a Attachment) Open() io.Reader {
+	if a.Reader != nil {
+		return a.Reader
+	}
+	return bytes.NewReader(a.Data)
+}
+
+// MIMEType returns ContentType, or a type guessed from the file extension.
+func (a Attachment) MIMEType() string {
+	if a.ContentType != "" {
+		return a.ContentType
+	}
+	if t := mime.TypeByExtension(filepath.Ext(a.Name)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// IsImage reports whether the attachment is an image.
+func (a Attachment) IsImage() bool {
+	return strings.HasPrefix(a.MIMEType(), "image/")
+}
+
+// AttachmentUploader hosts attachments for providers that can only reference
+// files by URL (LINE, MS Teams).
+type AttachmentUploader interface {
+	// Upload stores the attachment and returns a public HTTPS URL for it.
+	Upload(ctx context.Context, a Attachment) (string, error)
 }
 
+// AttachmentUploaderFunc adapts a function to the AttachmentUploader interface.
+type AttachmentUploaderFunc func(ctx context.Context, a Attachment) (string, error)
+
+// Upload calls f(ctx, a).
+func (f AttachmentUploaderFunc) Upload(ctx context.Context, a Attachment) (string, error) {
+	return f(ctx, a)
+}
+
+// Format identifies the markup language of a message body.
+type Format string
+
+const (
+	// FormatPlain is literal text without any markup.
+	FormatPlain Format = "plain"
+	// FormatMarkdown is a common Markdown subset: bold, italic, code, pre, links and lists.
+	FormatMarkdown Format = "markdown"
+	// FormatHTML is the equivalent HTML subset (b, i, code, pre, a, ul/ol/li, br, p).
+	FormatHTML Format = "html"
+)
+
 // Message is an alias for CommonMessage for backward compatibility (optional, but good for transition).
 type Message = CommonMessage
 
+// MentionKind distinguishes user mentions from role (group) mentions.
+type MentionKind string
+
+const (
+	// MentionUser mentions a single person. It is the default kind.
+	MentionUser MentionKind = "user"
+	// MentionRole mentions a role, tag or everyone in the chat, depending on the platform.
+	MentionRole MentionKind = "role"
+)
+
+// Mention refers to a logical user or role, such as "oncall" or "alice".
+type Mention struct {
+	// Name is the logical identifier passed to the MentionResolver.
+	Name string
+	// Kind is the type of mention. The zero value is treated as MentionUser.
+	Kind MentionKind
+}
+
+// ResolvedMention is a Mention mapped to an identity on a specific platform.
+type ResolvedMention struct {
+	// ID is the platform identifier (Discord snowflake, Teams UPN or AAD ID,
+	// LINE user ID, Telegram numeric user ID or @username).
+	ID string
+	// DisplayName is the text shown for the mention where the platform needs one.
+	DisplayName string
+	// Kind is copied from the Mention.
+	Kind MentionKind
+}
+
+// MentionResolver maps logical mentions to platform identities.
+// provider is the provider's Name constant (e.g. "discord", "telegram").
+type MentionResolver interface {
+	ResolveMention(ctx context.Context, provider string, m Mention) (ResolvedMention, error)
+}
+
+// MentionDirectory is a static MentionResolver keyed by provider name and
+// then by logical mention name.
+type MentionDirectory map[string]map[string]ResolvedMention
+
+// ResolveMention implements MentionResolver.
+func (d MentionDirectory) ResolveMention(ctx context.Context, provider string, m Mention) (ResolvedMention, error) {
+	r, ok := d[provider][m.Name]
+	if !ok {
+		return ResolvedMention{}, fmt.Errorf("%s mention %q: %w", provider, m.Name, ErrMentionNotFound)
+	}
+	if r.DisplayName == "" {
+		r.DisplayName = m.Name
+	}
+	r.Kind = m.Kind
+	return r, nil
+}
+
+// ResolveMentions resolves ms for provider with r. When r is nil each
+// mention's Name is used as the platform ID.
+func ResolveMentions(ctx context.Context, r MentionResolver, provider string, ms []Mention) ([]ResolvedMention, error) {
+	resolved := make([]ResolvedMention, 0, len(ms))
+	for _, m := range ms {
+		if m.Kind == "" {
+			m.Kind = MentionUser
+		}
+		if r == nil {
+			resolved = append(resolved, ResolvedMention{ID: m.Name, DisplayName: m.Name, Kind: m.Kind})
+			continue
+		}
+		rm, err := r.ResolveMention(ctx, provider, m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve mention %q: %w", m.Name, err)
+		}
+		rm.Kind = m.Kind
+		resolved = append(resolved, rm)
+	}
+	return resolved, nil
+}
+
 // Options holds common configuration for providers.
 type Options struct {
 	HTTPClient *http.Client
+	// AttachmentUploader hosts attachments for providers without file upload support.
+	AttachmentUploader AttachmentUploader
+	// MentionResolver maps CommonMessage mentions to platform identities.
+	MentionResolver MentionResolver
+
+	// provider holds the provider-specific options added with ProviderOption.
+	provider []interface{}
 }
 
 // Option is a function that configures Options.
@@ -47,6 +295,40 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithAttachmentUploader configures the hook used to host attachments for
+// providers that can only send files by URL.
+func WithAttachmentUploader(u AttachmentUploader) Option {
+	return func(o *Options) {
+		o.AttachmentUploader = u
+	}
+}
+
+// WithMentionResolver configures how CommonMessage mentions are mapped to platform IDs.
+func WithMentionResolver(r MentionResolver) Option {
+	return func(o *Options) {
+		o.MentionResolver = r
+	}
+}
+
+// ProviderOption returns an Option that sets a provider-specific setting in
+// the provider's own options struct T. Providers build their options on it
+// and read them back with ApplyProviderOptions; other providers ignore it.
+func ProviderOption[T any](set func(*T)) Option {
+	return func(o *Options) {
+		o.provider = append(o.provider, set)
+	}
+}
+
+// ApplyProviderOptions applies the ProviderOption settings of type T in o
+// to t, in the order they were given.
+func ApplyProviderOptions[T any](o *Options, t *T) {
+	for _, set := range o.provider {
+		if set, ok := set.(func(*T)); ok {
+			set(t)
+		}
+	}
+}
+
 // WithTimeout configures a default timeout for the HTTP client if one isn't already set.
 func WithTimeout(d time.Duration) Option {
 	return func(o *Options) {
@@ -0,0 +1,58 @@
+package notifytest
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+var discordWebhookPath = regexp.MustCompile(`^/api/(v\d+/)?webhooks/\d+/[\w-]+$`)
+
+// NewDiscordServer starts a fake Discord webhook endpoint. Executing a
+// webhook returns 204 No Content, or the created message when ?wait=true.
+// Errors use Discord's {"message":...,"code":...} body, and rate limits
+// carry retry_after in the body as well as the Retry-After header.
+func NewDiscordServer(t testing.TB) *Server {
+	var mu sync.Mutex
+	seq := 0
+
+	return newServer(t, platform{
+		success: func(w http.ResponseWriter, r Request) {
+			if !discordWebhookPath.MatchString(r.Path) {
+				writeJSON(w, http.StatusNotFound, map[string]interface{}{"message": "Unknown Webhook", "code": 10015})
+				return
+			}
+			if r.Query.Get("wait") != "true" {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			mu.Lock()
+			seq++
+			id := seq
+			mu.Unlock()
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"id":         fmt.Sprintf("%d", 1000000000000000000+id),
+				"channel_id": "1",
+				"type":       0,
+			})
+		},
+		errorBody: func(status int, description string) string {
+			return mustJSON(map[string]interface{}{"message": description, "code": 50035})
+		},
+		rateLimitBody: func(retryAfter time.Duration) string {
+			return mustJSON(map[string]interface{}{
+				"message":     "You are being rate limited.",
+				"retry_after": retryAfter.Seconds(),
+				"global":      false,
+			})
+		},
+	})
+}
+
+// DiscordWebhookURL returns a webhook URL served by s.
+func (s *Server) DiscordWebhookURL() string {
+	return s.URL + "/api/webhooks/123456789/test-token"
+}
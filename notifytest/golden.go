@@ -0,0 +1,51 @@
+package notifytest
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thanpawatpiti/notify"
+)
+
+// updateEnv is the environment variable that makes Golden rewrite golden
+// files. It is not a flag, since importers often define -update themselves.
+const updateEnv = "NOTIFYTEST_UPDATE"
+
+// Golden compares got with testdata/<name>.golden. Run the tests with
+// NOTIFYTEST_UPDATE=1 to write the current output instead.
+func Golden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+
+	if os.Getenv(updateEnv) == "1" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create testdata: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with NOTIFYTEST_UPDATE=1 to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch (run with NOTIFYTEST_UPDATE=1 to accept)\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
+
+// GoldenRequests dumps reqs with notify.DumpRequests and compares the result
+// with testdata/<name>.golden.
+func GoldenRequests(t testing.TB, name string, reqs []*http.Request) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := notify.DumpRequests(&buf, reqs); err != nil {
+		t.Fatalf("failed to dump requests: %v", err)
+	}
+	Golden(t, name, buf.Bytes())
+}
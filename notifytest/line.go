@@ -0,0 +1,115 @@
+package notifytest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// NewLINEServer starts a fake LINE Messaging API. Requests without a Bearer
+// token are rejected with 401. Push and reply endpoints reply with
+// sentMessages, narrowcasts are accepted with 202 and report as succeeded,
+// the quota is a limited 300 messages with none used, created rich menus
+// get an ID, and every reply carries an X-Line-Request-Id header. A repeated
+// X-Line-Retry-Key is rejected with 409 and the X-Line-Accepted-Request-Id
+// of the first request. Errors use the {"message":...,"details":[...]} body
+// of the real API.
+func NewLINEServer(t testing.TB) *Server {
+	var mu sync.Mutex
+	seq := 0
+	next := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		seq++
+		return seq
+	}
+	accepted := make(map[string]string)
+
+	return newServer(t, platform{
+		success: func(w http.ResponseWriter, r Request) {
+			requestID := fmt.Sprintf("req-%d", next())
+			w.Header().Set("X-Line-Request-Id", requestID)
+			if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+				writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+					"message": "Authentication failed. Confirm that the access token in the authorization header is valid.",
+				})
+				return
+			}
+			if key := r.Header.Get("X-Line-Retry-Key"); key != "" {
+				mu.Lock()
+				first, ok := accepted[key]
+				if !ok {
+					accepted[key] = requestID
+				}
+				mu.Unlock()
+				if ok {
+					w.Header().Set("X-Line-Accepted-Request-Id", first)
+					writeJSON(w, http.StatusConflict, map[string]interface{}{
+						"message": "The retry key is already accepted",
+					})
+					return
+				}
+			}
+			switch r.Path {
+			case "/v2/bot/message/multicast", "/v2/bot/message/broadcast":
+				writeJSON(w, http.StatusOK, map[string]interface{}{})
+				return
+			case "/v2/bot/message/narrowcast":
+				writeJSON(w, http.StatusAccepted, map[string]interface{}{})
+				return
+			case "/v2/bot/richmenu":
+				writeJSON(w, http.StatusOK, map[string]interface{}{"richMenuId": fmt.Sprintf("richmenu-%d", next())})
+				return
+			case "/v2/bot/richmenu/bulk/link", "/v2/bot/richmenu/bulk/unlink":
+				writeJSON(w, http.StatusAccepted, map[string]interface{}{})
+				return
+			case "/v2/bot/message/quota":
+				writeJSON(w, http.StatusOK, map[string]interface{}{"type": "limited", "value": 300})
+				return
+			case "/v2/bot/message/quota/consumption":
+				writeJSON(w, http.StatusOK, map[string]interface{}{"totalUsage": 0})
+				return
+			case "/v2/bot/message/delivery/push", "/v2/bot/message/delivery/multicast",
+				"/v2/bot/message/delivery/broadcast", "/v2/bot/message/delivery/reply":
+				writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ready", "success": 0})
+				return
+			case "/v2/bot/message/progress/narrowcast":
+				writeJSON(w, http.StatusOK, map[string]interface{}{
+					"phase":         "succeeded",
+					"successCount":  1,
+					"failureCount":  0,
+					"targetCount":   1,
+					"acceptedTime":  time.Now().UTC().Format(time.RFC3339),
+					"completedTime": time.Now().UTC().Format(time.RFC3339),
+				})
+				return
+			}
+			if strings.HasPrefix(r.Path, "/v2/bot/message/") && r.Method == http.MethodPost {
+				var body struct {
+					Messages []interface{} `json:"messages"`
+				}
+				r.JSON(&body)
+				sent := make([]map[string]string, 0, len(body.Messages))
+				for range body.Messages {
+					id := next()
+					sent = append(sent, map[string]string{
+						"id":         fmt.Sprintf("%d", 500000000000000+id),
+						"quoteToken": fmt.Sprintf("quote-%d", id),
+					})
+				}
+				writeJSON(w, http.StatusOK, map[string]interface{}{"sentMessages": sent})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{})
+		},
+		errorBody: func(status int, description string) string {
+			return mustJSON(map[string]interface{}{"message": description, "details": []interface{}{}})
+		},
+		rateLimitBody: func(time.Duration) string {
+			return mustJSON(map[string]interface{}{"message": "The API rate limit has been exceeded. Try again later."})
+		},
+	})
+}
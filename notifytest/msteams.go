@@ -0,0 +1,33 @@
+package notifytest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// NewTeamsServer starts a fake Microsoft Teams incoming webhook. Successful
+// posts return 200 with the body "1"; errors are plain text like the real
+// connector.
+func NewTeamsServer(t testing.TB) *Server {
+	return newServer(t, platform{
+		success: func(w http.ResponseWriter, r Request) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			io.WriteString(w, "1")
+		},
+		errorBody: func(status int, description string) string {
+			return description
+		},
+		rateLimitBody: func(retryAfter time.Duration) string {
+			return fmt.Sprintf("Microsoft Teams endpoint returned HTTP error 429 with ContextId tcid=0,server=msgapi-canary-eus2-0,cv=notifytest. Retry after %s.", retryAfter)
+		},
+	})
+}
+
+// TeamsWebhookURL returns an incoming webhook URL served by s.
+func (s *Server) TeamsWebhookURL() string {
+	return s.URL + "/webhookb2/notifytest@notifytest/IncomingWebhook/test/test"
+}
@@ -0,0 +1,93 @@
+package notifytest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thanpawatpiti/notify"
+	"github.com/thanpawatpiti/notify/notifytest"
+)
+
+func TestRecorder(t *testing.T) {
+	rec := notifytest.NewRecorder()
+	go rec.Send(context.Background(), notify.CommonMessage{Title: "Deploy", Content: "v1.2.0 is live"})
+	if !rec.Wait(1, time.Second) {
+		t.Fatal("expected a recorded notification")
+	}
+	rec.AssertCount(t, 1)
+	rec.AssertContains(t, "v1.2.0")
+
+	boom := errors.New("boom")
+	rec.FailWith(boom)
+	if err := rec.Send(context.Background(), "x"); !errors.Is(err, boom) {
+		t.Errorf("expected FailWith error, got %v", err)
+	}
+	rec.AssertSent(t, "x")
+}
+
+func TestClock(t *testing.T) {
+	clock := notifytest.NewClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	ch := clock.After(time.Minute)
+	if clock.Timers() != 1 {
+		t.Fatalf("expected 1 pending timer, got %d", clock.Timers())
+	}
+	clock.Advance(59 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("timer fired early")
+	default:
+	}
+	clock.Advance(time.Second)
+	if got := <-ch; !got.Equal(time.Date(2025, 1, 1, 0, 1, 0, 0, time.UTC)) {
+		t.Errorf("unexpected fire time %v", got)
+	}
+
+	// Stopped timers no longer count as pending.
+	_, stop := notify.NewTimer(clock, time.Minute)
+	if !clock.BlockUntil(1, time.Second) {
+		t.Fatal("expected a pending timer")
+	}
+	stop()
+	if clock.BlockUntil(1, 10*time.Millisecond) {
+		t.Errorf("expected stopped timer to be dropped, got %d pending", clock.Timers())
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	srv := notifytest.NewTelegramServer(t)
+	srv.Fail(http.StatusBadRequest, "Bad Request: chat not found")
+	srv.RateLimit(3 * time.Second)
+
+	client := srv.Client()
+	post := func() (*http.Response, string) {
+		resp, err := client.Post("https://api.telegram.org/bottoken/sendMessage", "application/json", strings.NewReader(`{"chat_id":"1","text":"hi"}`))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := post()
+	if resp.StatusCode != http.StatusBadRequest || !strings.Contains(body, `"description":"Bad Request: chat not found"`) {
+		t.Errorf("unexpected error reply %d %s", resp.StatusCode, body)
+	}
+	resp, body = post()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "3" || !strings.Contains(body, `"retry_after":3`) {
+		t.Errorf("unexpected rate limit reply %d %s", resp.StatusCode, body)
+	}
+	resp, body = post()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `"message_id":1`) {
+		t.Errorf("unexpected success reply %d %s", resp.StatusCode, body)
+	}
+
+	if req := srv.LastRequest(t); req.Host != "api.telegram.org" || req.Path != "/bottoken/sendMessage" {
+		t.Errorf("unexpected captured request %s%s", req.Host, req.Path)
+	}
+}
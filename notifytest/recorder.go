@@ -0,0 +1,148 @@
+// Package notifytest provides helpers for testing code that sends
+// notifications: a recording notify.Notifier, a manually advanced
+// notify.Clock, and httptest-based fake servers for every provider API.
+package notifytest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thanpawatpiti/notify"
+)
+
+// Recorder is a thread-safe notify.Notifier that records every payload it
+// is asked to send.
+type Recorder struct {
+	mu       sync.Mutex
+	payloads []interface{}
+	err      error
+	signal   chan struct{}
+}
+
+// NewRecorder creates an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{signal: make(chan struct{})}
+}
+
+// Send records payload and returns the error set with FailWith.
+// Failed sends are recorded too.
+func (r *Recorder) Send(ctx context.Context, payload interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.payloads = append(r.payloads, payload)
+	close(r.signal)
+	r.signal = make(chan struct{})
+	return r.err
+}
+
+// FailWith makes subsequent sends return err. Pass nil to succeed again.
+func (r *Recorder) FailWith(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+// Payloads returns a copy of the recorded payloads in send order.
+func (r *Recorder) Payloads() []interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]interface{}(nil), r.payloads...)
+}
+
+// Len returns the number of recorded payloads.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.payloads)
+}
+
+// Last returns the most recent payload, or nil if nothing was sent.
+func (r *Recorder) Last() interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.payloads) == 0 {
+		return nil
+	}
+	return r.payloads[len(r.payloads)-1]
+}
+
+// Reset forgets all recorded payloads.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.payloads = nil
+}
+
+// Wait blocks until at least n payloads were recorded or timeout elapses,
+// and reports whether the count was reached.
+func (r *Recorder) Wait(n int, timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		r.mu.Lock()
+		count, signal := len(r.payloads), r.signal
+		r.mu.Unlock()
+		if count >= n {
+			return true
+		}
+		select {
+		case <-signal:
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+// AssertCount fails t unless exactly n payloads were recorded.
+func (r *Recorder) AssertCount(t testing.TB, n int) {
+	t.Helper()
+	if got := r.Len(); got != n {
+		t.Errorf("expected %d notifications, got %d: %v", n, got, r.Payloads())
+	}
+}
+
+// AssertNothingSent fails t if any payload was recorded.
+func (r *Recorder) AssertNothingSent(t testing.TB) {
+	t.Helper()
+	r.AssertCount(t, 0)
+}
+
+// AssertSent fails t unless a payload deeply equal to want was recorded.
+func (r *Recorder) AssertSent(t testing.TB, want interface{}) {
+	t.Helper()
+	for _, p := range r.Payloads() {
+		if reflect.DeepEqual(p, want) {
+			return
+		}
+	}
+	t.Errorf("expected notification %#v, got %v", want, r.Payloads())
+}
+
+// AssertContains fails t unless the text of a recorded payload contains
+// substr (see Text).
+func (r *Recorder) AssertContains(t testing.TB, substr string) {
+	t.Helper()
+	for _, p := range r.Payloads() {
+		if strings.Contains(Text(p), substr) {
+			return
+		}
+	}
+	t.Errorf("expected a notification containing %q, got %v", substr, r.Payloads())
+}
+
+// Text returns the human readable text of a payload: the string itself,
+// a CommonMessage's title and content, or the %v formatting of anything else.
+func Text(payload interface{}) string {
+	switch v := payload.(type) {
+	case string:
+		return v
+	case notify.CommonMessage:
+		return strings.TrimSpace(v.Title + "\n" + v.Content)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
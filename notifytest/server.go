@@ -0,0 +1,202 @@
+package notifytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Request is an HTTP request captured by a fake Server.
+type Request struct {
+	Method string
+	// Host is the host the client addressed, e.g. "api.telegram.org".
+	Host   string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// JSON decodes the request body into v.
+func (r Request) JSON(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Multipart parses a multipart/form-data request body.
+func (r Request) Multipart() (*multipart.Form, error) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	return multipart.NewReader(bytes.NewReader(r.Body), params["boundary"]).ReadForm(32 << 20)
+}
+
+// response is a canned reply queued with Respond, Fail or RateLimit.
+type response struct {
+	status int
+	header http.Header
+	body   string
+}
+
+// platform describes how a fake Server formats its replies.
+type platform struct {
+	// success writes the default reply to a captured request.
+	success func(w http.ResponseWriter, r Request)
+	// errorBody formats an error the way the real API does.
+	errorBody func(status int, description string) string
+	// rateLimitBody formats a 429 reply.
+	rateLimitBody func(retryAfter time.Duration) string
+}
+
+// Server is a fake provider API. It records every request and replies like
+// the real service, or with queued errors. Use Client to route a provider's
+// requests to it regardless of the API host the provider targets.
+type Server struct {
+	*httptest.Server
+	platform platform
+
+	mu        sync.Mutex
+	requests  []Request
+	responses []response
+}
+
+func newServer(t testing.TB, p platform) *Server {
+	s := &Server{platform: p}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	if t != nil {
+		t.Cleanup(s.Close)
+	}
+	return s
+}
+
+// Client returns an HTTP client that sends every request to this server,
+// whatever its original host. Pass it with notify.WithHTTPClient.
+func (s *Server) Client() *http.Client {
+	target, _ := url.Parse(s.URL)
+	return &http.Client{
+		Transport: &rewriteTransport{target: target, base: s.Server.Client().Transport},
+	}
+}
+
+// Requests returns the captured requests in arrival order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// LastRequest returns the most recent request. It fails t if there is none.
+func (s *Server) LastRequest(t testing.TB) Request {
+	t.Helper()
+	reqs := s.Requests()
+	if len(reqs) == 0 {
+		t.Fatalf("no requests received")
+	}
+	return reqs[len(reqs)-1]
+}
+
+// Reset forgets captured requests and queued responses.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+	s.responses = nil
+}
+
+// Respond queues a raw reply for the next request.
+func (s *Server) Respond(status int, body string) {
+	s.enqueue(response{status: status, body: body})
+}
+
+// Fail queues an error reply for the next request, formatted like the real API.
+func (s *Server) Fail(status int, description string) {
+	s.enqueue(response{status: status, body: s.platform.errorBody(status, description)})
+}
+
+// RateLimit queues a 429 reply with a Retry-After header for the next request.
+func (s *Server) RateLimit(retryAfter time.Duration) {
+	h := http.Header{}
+	h.Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+	s.enqueue(response{status: http.StatusTooManyRequests, header: h, body: s.platform.rateLimitBody(retryAfter)})
+}
+
+func (s *Server) enqueue(r response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses = append(s.responses, r)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := Request{
+		Method: r.Method,
+		Host:   r.Host,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	var queued *response
+	if len(s.responses) > 0 {
+		queued = &s.responses[0]
+		s.responses = s.responses[1:]
+	}
+	s.mu.Unlock()
+
+	if queued == nil {
+		s.platform.success(w, req)
+		return
+	}
+	for k, v := range queued.header {
+		w.Header()[k] = v
+	}
+	if strings.HasPrefix(strings.TrimSpace(queued.body), "{") {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(queued.status)
+	io.WriteString(w, queued.body)
+}
+
+// rewriteTransport redirects requests to target, keeping the original Host header.
+type rewriteTransport struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	if r.Host == "" {
+		r.Host = req.URL.Host
+	}
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	return t.base.RoundTrip(r)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func mustJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("notifytest: %v", err))
+	}
+	return string(b)
+}
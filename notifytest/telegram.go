@@ -0,0 +1,239 @@
+package notifytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var telegramPath = regexp.MustCompile(`^/bot[^/]+/(\w+)$`)
+
+// telegramMedia maps send methods to the file field of the returned message.
+var telegramMedia = map[string]string{
+	"sendPhoto":     "photo",
+	"sendDocument":  "document",
+	"sendVideo":     "video",
+	"sendAudio":     "audio",
+	"sendAnimation": "animation",
+	"sendVoice":     "voice",
+}
+
+// NewTelegramServer starts a fake Telegram Bot API. Methods named send*
+// return a message object with increasing message IDs, with a file_id of
+// "file-<message ID>" for media. sendMediaGroup returns one message per
+// album item, createForumTopic returns the topic with a new thread ID,
+// getWebhookInfo reports the URL of the last setWebhook call and every
+// other method returns true. sendPoll messages carry a poll with the ID
+// "poll-<message ID>", returned closed by stopPoll; sendDice rolls 1 to 6
+// in turn and sendLocation and sendVenue echo their coordinates.
+// Errors use the {"ok":false,"error_code":...,"description":...} envelope.
+func NewTelegramServer(t testing.TB) *Server {
+	var mu sync.Mutex
+	nextID := 0
+	webhookURL := ""
+	polls := make(map[string]map[string]interface{})
+
+	return newServer(t, platform{
+		success: func(w http.ResponseWriter, r Request) {
+			m := telegramPath.FindStringSubmatch(r.Path)
+			if m == nil {
+				writeJSON(w, http.StatusNotFound, telegramError(http.StatusNotFound, "Not Found"))
+				return
+			}
+			method := m[1]
+
+			chatID := telegramField(r, "chat_id")
+			message := func() map[string]interface{} {
+				mu.Lock()
+				nextID++
+				id := nextID
+				mu.Unlock()
+				msg := map[string]interface{}{
+					"message_id": id,
+					"date":       time.Now().Unix(),
+					"chat":       map[string]interface{}{"id": telegramChatID(chatID), "type": "private"},
+				}
+				if text := telegramField(r, "text"); text != "" {
+					msg["text"] = text
+				}
+				return msg
+			}
+			file := func(id int) map[string]interface{} {
+				return map[string]interface{}{"file_id": fmt.Sprintf("file-%d", id), "file_unique_id": fmt.Sprintf("unique-%d", id)}
+			}
+			attach := func(msg map[string]interface{}, kind string) map[string]interface{} {
+				id := msg["message_id"].(int)
+				if kind == "photo" {
+					msg["photo"] = []interface{}{file(id)}
+				} else {
+					msg[kind] = file(id)
+				}
+				return msg
+			}
+
+			var result interface{} = true
+			switch {
+			case method == "sendMediaGroup":
+				var media []struct {
+					Type string `json:"type"`
+				}
+				json.Unmarshal([]byte(telegramField(r, "media")), &media)
+				messages := make([]interface{}, 0, len(media))
+				for _, m := range media {
+					msg := attach(message(), m.Type)
+					msg["media_group_id"] = "album-1"
+					messages = append(messages, msg)
+				}
+				result = messages
+			case telegramMedia[method] != "":
+				result = attach(message(), telegramMedia[method])
+			case method == "sendChatAction":
+			case method == "sendPoll":
+				msg := message()
+				var options []struct {
+					Text string `json:"text"`
+				}
+				json.Unmarshal([]byte(telegramField(r, "options")), &options)
+				pollOptions := make([]interface{}, 0, len(options))
+				for _, o := range options {
+					pollOptions = append(pollOptions, map[string]interface{}{"text": o.Text, "voter_count": 0})
+				}
+				pollType := telegramField(r, "type")
+				if pollType == "" {
+					pollType = "regular"
+				}
+				poll := map[string]interface{}{
+					"id":                      fmt.Sprintf("poll-%d", msg["message_id"]),
+					"question":                telegramField(r, "question"),
+					"options":                 pollOptions,
+					"total_voter_count":       0,
+					"is_closed":               telegramField(r, "is_closed") == "true",
+					"is_anonymous":            telegramField(r, "is_anonymous") != "false",
+					"type":                    pollType,
+					"allows_multiple_answers": telegramField(r, "allows_multiple_answers") == "true",
+				}
+				mu.Lock()
+				polls[strconv.Itoa(msg["message_id"].(int))] = poll
+				mu.Unlock()
+				msg["poll"] = poll
+				result = msg
+			case method == "stopPoll":
+				mu.Lock()
+				poll, ok := polls[telegramField(r, "message_id")]
+				if ok {
+					poll["is_closed"] = true
+				}
+				mu.Unlock()
+				if !ok {
+					writeJSON(w, http.StatusBadRequest, telegramError(http.StatusBadRequest, "Bad Request: message to stop poll not found"))
+					return
+				}
+				result = poll
+			case method == "sendDice":
+				msg := message()
+				emoji := telegramField(r, "emoji")
+				if emoji == "" {
+					emoji = "🎲"
+				}
+				msg["dice"] = map[string]interface{}{"emoji": emoji, "value": 1 + (msg["message_id"].(int)-1)%6}
+				result = msg
+			case method == "sendLocation" || method == "sendVenue":
+				msg := message()
+				lat, _ := strconv.ParseFloat(telegramField(r, "latitude"), 64)
+				long, _ := strconv.ParseFloat(telegramField(r, "longitude"), 64)
+				msg["location"] = map[string]interface{}{"latitude": lat, "longitude": long}
+				result = msg
+			case strings.HasPrefix(method, "send"):
+				result = message()
+			case method == "createForumTopic":
+				mu.Lock()
+				nextID++
+				id := nextID
+				mu.Unlock()
+				color, _ := strconv.Atoi(telegramField(r, "icon_color"))
+				if color == 0 {
+					color = 0x6FB9F0
+				}
+				result = map[string]interface{}{"message_thread_id": id, "name": telegramField(r, "name"), "icon_color": color}
+			case method == "setWebhook":
+				mu.Lock()
+				webhookURL = telegramField(r, "url")
+				mu.Unlock()
+			case method == "deleteWebhook":
+				mu.Lock()
+				webhookURL = ""
+				mu.Unlock()
+			case method == "getWebhookInfo":
+				mu.Lock()
+				result = map[string]interface{}{"url": webhookURL, "has_custom_certificate": false, "pending_update_count": 0}
+				mu.Unlock()
+			case method == "getUpdates":
+				result = []interface{}{}
+			case method == "getMe":
+				result = map[string]interface{}{"id": 1, "is_bot": true, "first_name": "notifytest", "username": "notifytest_bot"}
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"ok": true, "result": result})
+		},
+		errorBody: func(status int, description string) string {
+			return mustJSON(telegramError(status, description))
+		},
+		rateLimitBody: func(retryAfter time.Duration) string {
+			secs := int((retryAfter + time.Second - 1) / time.Second)
+			return mustJSON(map[string]interface{}{
+				"ok":          false,
+				"error_code":  http.StatusTooManyRequests,
+				"description": "Too Many Requests: retry after " + strconv.Itoa(secs),
+				"parameters":  map[string]interface{}{"retry_after": secs},
+			})
+		},
+	})
+}
+
+func telegramError(status int, description string) map[string]interface{} {
+	return map[string]interface{}{"ok": false, "error_code": status, "description": description}
+}
+
+// telegramField reads a field from a JSON or multipart request body.
+// JSON arrays and objects are returned encoded.
+func telegramField(r Request, name string) string {
+	if form, err := r.Multipart(); err == nil {
+		if v := form.Value[name]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	var body map[string]interface{}
+	if err := r.JSON(&body); err != nil {
+		return ""
+	}
+	switch v := body[name].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return ""
+	}
+	b, _ := json.Marshal(body[name])
+	return string(b)
+}
+
+// telegramChatID returns the numeric ID of a chat, like the real API.
+// Usernames and other non-numeric IDs get a stable supergroup-style ID.
+func telegramChatID(id string) int64 {
+	if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+		return n
+	}
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return -1000000000000 - int64(h.Sum32())
+}
@@ -5,13 +5,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/thanpawatpiti/notify"
+	"github.com/thanpawatpiti/notify/format"
+	"github.com/thanpawatpiti/notify/internal/multipart"
 )
 
+// Name identifies this provider to a notify.MentionResolver.
+const Name = "discord"
+
 // Provider implements the Notifier interface for Discord.
 type Provider struct {
 	webhookURL string
@@ -40,9 +46,31 @@ func New(webhookURL string, opts ...notify.Option) *Provider {
 // - notify.CommonMessage: Generic rich message (Text + Image).
 // - discord.WebhookPayload: Full webhook payload.
 // - discord.Embed: Single embed.
+//
+// Attachments (CommonMessage.Attachments or WebhookPayload.Files) are uploaded
+// as files[n] parts of a multipart request next to payload_json.
+// CommonMessage mentions are written to the message content and whitelisted
+// in allowed_mentions, so only the resolved users and roles are pinged.
 func (p *Provider) Send(ctx context.Context, payload interface{}) error {
+	reqs, err := p.Render(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	for _, req := range reqs {
+		if err := p.do(req); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Render builds the webhook request Send would make for payload without
+// sending it.
+func (p *Provider) Render(ctx context.Context, payload interface{}) ([]*http.Request, error) {
 	if p.webhookURL == "" {
-		return fmt.Errorf("discord webhook url is missing")
+		return nil, fmt.Errorf("discord webhook url is missing")
 	}
 
 	var wp WebhookPayload
@@ -52,7 +80,7 @@ func (p *Provider) Send(ctx context.Context, payload interface{}) error {
 		wp.Content = v
 	case notify.CommonMessage:
 		embed := Embed{
-			Description: v.Content,
+			Description: format.Convert(v.Content, v.Format, format.Discord),
 		}
 		if v.Title != "" {
 			embed.Title = v.Title
@@ -66,26 +94,49 @@ func (p *Provider) Send(ctx context.Context, payload interface{}) error {
 			}
 		}
 		wp.Embeds = []Embed{embed}
+		wp.Files = v.Attachments
+		if len(v.Mentions) > 0 {
+			mentions, err := notify.ResolveMentions(ctx, p.opts.MentionResolver, Name, v.Mentions)
+			if err != nil {
+				return nil, err
+			}
+			wp.Content, wp.AllowedMentions = renderMentions(mentions)
+		}
 	case WebhookPayload:
 		wp = v
 	case Embed:
 		wp.Embeds = []Embed{v}
 	default:
-		return fmt.Errorf("unsupported payload type: %T", v)
+		return nil, fmt.Errorf("unsupported payload type: %T", v)
 	}
 
 	body, err := json.Marshal(wp)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.webhookURL, bytes.NewReader(body))
+	var reqBody io.Reader = bytes.NewReader(body)
+	contentType := "application/json"
+	if len(wp.Files) > 0 {
+		files := make([]multipart.File, len(wp.Files))
+		for i, a := range wp.Files {
+			files[i] = multipart.File{Field: fmt.Sprintf("files[%d]", i), Attachment: a}
+		}
+		reqBody, contentType = multipart.Body(map[string]string{"payload_json": string(body)}, files)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.webhookURL, reqBody)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
+
+	return []*http.Request{req}, nil
+}
 
+// do sends req and checks the response status.
+func (p *Provider) do(req *http.Request) error {
 	resp, err := p.opts.HTTPClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
@@ -107,3 +158,20 @@ func parseColor(colorStr string) (int, error) {
 	}
 	return int(val), nil
 }
+
+// renderMentions returns the mention markup for mentions and the matching
+// allowed_mentions object.
+func renderMentions(mentions []notify.ResolvedMention) (string, *AllowedMentions) {
+	allowed := &AllowedMentions{Parse: []string{}}
+	parts := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		if m.Kind == notify.MentionRole {
+			parts = append(parts, "<@&"+m.ID+">")
+			allowed.Roles = append(allowed.Roles, m.ID)
+		} else {
+			parts = append(parts, "<@"+m.ID+">")
+			allowed.Users = append(allowed.Users, m.ID)
+		}
+	}
+	return strings.Join(parts, " "), allowed
+}
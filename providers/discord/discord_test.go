@@ -2,11 +2,16 @@ package discord
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/thanpawatpiti/notify"
+	"github.com/thanpawatpiti/notify/notifytest"
 )
 
 func TestSend(t *testing.T) {
@@ -33,3 +38,111 @@ func TestSend(t *testing.T) {
 		t.Errorf("Embed: expected no error, got %v", err)
 	}
 }
+
+func TestSendAttachments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("expected multipart request, got %v", err)
+		}
+		if !strings.Contains(r.FormValue("payload_json"), `"description":"see logs"`) {
+			t.Errorf("unexpected payload_json: %s", r.FormValue("payload_json"))
+		}
+		f, h, err := r.FormFile("files[0]")
+		if err != nil {
+			t.Fatalf("expected files[0], got %v", err)
+		}
+		defer f.Close()
+		data, _ := io.ReadAll(f)
+		if h.Filename != "app.log" || string(data) != "line 1\nline 2" {
+			t.Errorf("unexpected file %s: %q", h.Filename, data)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	p := New(server.URL)
+
+	err := p.Send(context.Background(), notify.CommonMessage{
+		Content: "see logs",
+		Attachments: []notify.Attachment{
+			{Name: "app.log", Reader: strings.NewReader("line 1\nline 2")},
+		},
+	})
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestSendMentions(t *testing.T) {
+	var wp WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&wp)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	resolver := notify.MentionDirectory{
+		Name: {
+			"oncall": {ID: "111"},
+			"sre":    {ID: "222"},
+		},
+	}
+	p := New(server.URL, notify.WithMentionResolver(resolver))
+
+	err := p.Send(context.Background(), notify.CommonMessage{
+		Content:  "database is down",
+		Mentions: []notify.Mention{{Name: "oncall"}, {Name: "sre", Kind: notify.MentionRole}},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if wp.Content != "<@111> <@&222>" {
+		t.Errorf("unexpected content %q", wp.Content)
+	}
+	if wp.AllowedMentions == nil || len(wp.AllowedMentions.Users) != 1 || len(wp.AllowedMentions.Roles) != 1 {
+		t.Errorf("unexpected allowed_mentions %+v", wp.AllowedMentions)
+	}
+
+	err = p.Send(context.Background(), notify.CommonMessage{
+		Content:  "x",
+		Mentions: []notify.Mention{{Name: "unknown"}},
+	})
+	if !errors.Is(err, notify.ErrMentionNotFound) {
+		t.Errorf("expected ErrMentionNotFound, got %v", err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	p := New("https://discord.com/api/webhooks/123/token")
+
+	tests := []struct {
+		name    string
+		payload interface{}
+	}{
+		{"string", "Deploy finished for api_server"},
+		{"common_message", notify.CommonMessage{
+			Title:       "Nightly report",
+			Content:     "Backups **completed** in 3.5 minutes. See [logs](https://example.com/logs).",
+			Color:       "#00ff00",
+			Mentions:    []notify.Mention{{Name: "1234"}, {Name: "5678", Kind: notify.MentionRole}},
+			Attachments: []notify.Attachment{{Name: "report.csv", Data: []byte("a,b\n1,2\n")}},
+		}},
+		{"webhook_payload", WebhookPayload{
+			Username: "deploy-bot",
+			Embeds: []Embed{{
+				Title:  "Release v1.2.0",
+				Fields: []EmbedField{{Name: "Service", Value: "api", Inline: true}},
+			}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqs, err := p.Render(context.Background(), tt.payload)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			notifytest.GoldenRequests(t, tt.name, reqs)
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package discord
 
+import "github.com/thanpawatpiti/notify"
+
 // Embed represents a Discord Embed.
 type Embed struct {
 	Title       string         `json:"title,omitempty"`
@@ -68,4 +70,16 @@ type WebhookPayload struct {
 	AvatarURL string  `json:"avatar_url,omitempty"`
 	TTS       bool    `json:"tts,omitempty"`
 	Embeds    []Embed `json:"embeds,omitempty"`
+	// AllowedMentions controls which mentions in Content ping their target.
+	AllowedMentions *AllowedMentions `json:"allowed_mentions,omitempty"`
+	// Files are uploaded as multipart files[n] parts. Embeds can reference
+	// them with "attachment://<name>" URLs.
+	Files []notify.Attachment `json:"-"`
+}
+
+// AllowedMentions restricts which mentions in a message notify their targets.
+type AllowedMentions struct {
+	Parse []string `json:"parse"` // "roles", "users", "everyone"
+	Roles []string `json:"roles,omitempty"`
+	Users []string `json:"users,omitempty"`
 }
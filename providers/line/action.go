@@ -0,0 +1,98 @@
+package line
+
+// Action types.
+const (
+	ActionPostback       = "postback"
+	ActionMessage        = "message"
+	ActionURI            = "uri"
+	ActionDatetimePicker = "datetimepicker"
+	ActionCamera         = "camera"
+	ActionCameraRoll     = "cameraRoll"
+	ActionLocation       = "location"
+	ActionRichMenuSwitch = "richmenuswitch"
+	ActionClipboard      = "clipboard"
+)
+
+// Action represents an action of a button, template, quick reply or Flex
+// component. Type selects which of the other fields apply; the NewXxxAction
+// helpers set them for each action type.
+type Action struct {
+	Type  string `json:"type"`
+	Label string `json:"label,omitempty"`
+	// URI is the link opened by a uri action.
+	URI    string  `json:"uri,omitempty"`
+	AltURI *AltURI `json:"altUri,omitempty"`
+	// Data is returned in the postback event of postback, datetimepicker
+	// and richmenuswitch actions.
+	Data string `json:"data,omitempty"`
+	// Text is sent by the user for message actions.
+	Text string `json:"text,omitempty"`
+	// DisplayText is shown in the chat as the user's message for postback actions.
+	DisplayText string `json:"displayText,omitempty"`
+	// InputOption controls the rich menu or keyboard after a postback action:
+	// "closeRichMenu", "openRichMenu", "openKeyboard" or "openVoice".
+	InputOption string `json:"inputOption,omitempty"`
+	// FillInText prefills the keyboard when InputOption is "openKeyboard".
+	FillInText string `json:"fillInText,omitempty"`
+	// Mode is "date", "time" or "datetime" for datetimepicker actions.
+	Mode    string `json:"mode,omitempty"`
+	Initial string `json:"initial,omitempty"`
+	Max     string `json:"max,omitempty"`
+	Min     string `json:"min,omitempty"`
+	// RichMenuAliasID is the rich menu alias a richmenuswitch action switches to.
+	RichMenuAliasID string `json:"richMenuAliasId,omitempty"`
+	// ClipboardText is copied by a clipboard action.
+	ClipboardText string `json:"clipboardText,omitempty"`
+}
+
+// AltURI is the URI opened instead of Action.URI on LINE for macOS and Windows.
+type AltURI struct {
+	Desktop string `json:"desktop"`
+}
+
+// NewPostbackAction returns an action that sends a postback event with data.
+func NewPostbackAction(label, data string) Action {
+	return Action{Type: ActionPostback, Label: label, Data: data}
+}
+
+// NewMessageAction returns an action that sends text as the user's message.
+func NewMessageAction(label, text string) Action {
+	return Action{Type: ActionMessage, Label: label, Text: text}
+}
+
+// NewURIAction returns an action that opens uri.
+func NewURIAction(label, uri string) Action {
+	return Action{Type: ActionURI, Label: label, URI: uri}
+}
+
+// NewDatetimePickerAction returns an action that lets the user pick a date
+// and/or time; mode is "date", "time" or "datetime".
+func NewDatetimePickerAction(label, data, mode string) Action {
+	return Action{Type: ActionDatetimePicker, Label: label, Data: data, Mode: mode}
+}
+
+// NewCameraAction returns a quick reply action that opens the camera.
+func NewCameraAction(label string) Action {
+	return Action{Type: ActionCamera, Label: label}
+}
+
+// NewCameraRollAction returns a quick reply action that opens the camera roll.
+func NewCameraRollAction(label string) Action {
+	return Action{Type: ActionCameraRoll, Label: label}
+}
+
+// NewLocationAction returns a quick reply action that opens the location picker.
+func NewLocationAction(label string) Action {
+	return Action{Type: ActionLocation, Label: label}
+}
+
+// NewRichMenuSwitchAction returns a rich menu action that switches to the
+// rich menu with the given alias.
+func NewRichMenuSwitchAction(label, richMenuAliasID, data string) Action {
+	return Action{Type: ActionRichMenuSwitch, Label: label, RichMenuAliasID: richMenuAliasID, Data: data}
+}
+
+// NewClipboardAction returns an action that copies text to the clipboard.
+func NewClipboardAction(label, text string) Action {
+	return Action{Type: ActionClipboard, Label: label, ClipboardText: text}
+}
@@ -0,0 +1,467 @@
+package line
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EventType is the type of a webhook event.
+type EventType string
+
+const (
+	EventMessage           EventType = "message"
+	EventUnsend            EventType = "unsend"
+	EventFollow            EventType = "follow"
+	EventUnfollow          EventType = "unfollow"
+	EventJoin              EventType = "join"
+	EventLeave             EventType = "leave"
+	EventMemberJoined      EventType = "memberJoined"
+	EventMemberLeft        EventType = "memberLeft"
+	EventPostback          EventType = "postback"
+	EventVideoPlayComplete EventType = "videoPlayComplete"
+	EventBeacon            EventType = "beacon"
+	EventAccountLink       EventType = "accountLink"
+	EventThings            EventType = "things"
+	EventMembership        EventType = "membership"
+	EventModule            EventType = "module"
+	EventActivated         EventType = "activated"
+	EventDeactivated       EventType = "deactivated"
+	EventBotSuspended      EventType = "botSuspended"
+	EventBotResumed        EventType = "botResumed"
+)
+
+// Event is a webhook event: *MessageEvent, *UnsendEvent, *FollowEvent,
+// *UnfollowEvent, *JoinEvent, *LeaveEvent, *MemberJoinedEvent,
+// *MemberLeftEvent, *PostbackEvent, *VideoPlayCompleteEvent, *BeaconEvent,
+// *AccountLinkEvent, *ThingsEvent, *MembershipEvent, *ModuleEvent or
+// *UnknownEvent.
+type Event interface {
+	Base() *EventBase
+}
+
+// EventBase holds the properties common to all webhook events.
+type EventBase struct {
+	Type EventType
+	// Mode is "active", or "standby" when another channel handles the chat.
+	Mode      string
+	Timestamp time.Time
+	Source    Source
+	// WebhookEventID identifies the event across redeliveries.
+	WebhookEventID string
+	// IsRedelivery reports whether LINE is resending an event that was not
+	// acknowledged earlier.
+	IsRedelivery bool
+	// ReplyToken is set for events that can be answered with Reply.
+	ReplyToken ReplyToken
+
+	provider *Provider
+}
+
+// Base returns the common event properties.
+func (e *EventBase) Base() *EventBase { return e }
+
+// Reply answers the event with payload through the Provider configured with
+// WithWebhookProvider. payload can be any type accepted by Send.
+func (e *EventBase) Reply(ctx context.Context, payload interface{}) error {
+	if e.provider == nil {
+		return fmt.Errorf("line webhook has no provider to reply with")
+	}
+	if e.ReplyToken.Value == "" {
+		return fmt.Errorf("line %s event has no reply token", e.Type)
+	}
+	return e.provider.Reply(ctx, e.ReplyToken, payload)
+}
+
+// Source is the user, group or room an event came from.
+type Source struct {
+	Type    string `json:"type"` // "user", "group", "room"
+	UserID  string `json:"userId,omitempty"`
+	GroupID string `json:"groupId,omitempty"`
+	RoomID  string `json:"roomId,omitempty"`
+}
+
+// ID returns the group, room or user ID to use as a push target.
+func (s Source) ID() string {
+	switch {
+	case s.GroupID != "":
+		return s.GroupID
+	case s.RoomID != "":
+		return s.RoomID
+	default:
+		return s.UserID
+	}
+}
+
+// MessageEvent is sent when a user sends a message.
+type MessageEvent struct {
+	EventBase
+	// Message is a *TextMessageContent, *ImageMessageContent,
+	// *VideoMessageContent, *AudioMessageContent, *FileMessageContent,
+	// *LocationMessageContent, *StickerMessageContent or *UnknownMessageContent.
+	Message MessageContent
+}
+
+// UnsendEvent is sent when a user unsends a message.
+type UnsendEvent struct {
+	EventBase
+	MessageID string
+}
+
+// FollowEvent is sent when a user adds the account as a friend or unblocks it.
+type FollowEvent struct {
+	EventBase
+	IsUnblocked bool
+}
+
+// UnfollowEvent is sent when a user blocks the account.
+type UnfollowEvent struct {
+	EventBase
+}
+
+// JoinEvent is sent when the account joins a group or room.
+type JoinEvent struct {
+	EventBase
+}
+
+// LeaveEvent is sent when the account is removed from a group or room.
+type LeaveEvent struct {
+	EventBase
+}
+
+// MemberJoinedEvent is sent when users join a group or room the account is in.
+type MemberJoinedEvent struct {
+	EventBase
+	Members []Source
+}
+
+// MemberLeftEvent is sent when users leave a group or room the account is in.
+type MemberLeftEvent struct {
+	EventBase
+	Members []Source
+}
+
+// PostbackEvent is sent when a user triggers a postback action.
+type PostbackEvent struct {
+	EventBase
+	Data string
+	// Params holds the "date", "time" or "datetime" chosen in a datetime
+	// picker, or "newRichMenuAliasId" and "status" of a rich menu switch.
+	Params map[string]string
+}
+
+// VideoPlayCompleteEvent is sent when a user finishes watching a video
+// message that has a TrackingID.
+type VideoPlayCompleteEvent struct {
+	EventBase
+	TrackingID string
+}
+
+// BeaconEvent is sent when a user enters the range of a LINE Beacon.
+type BeaconEvent struct {
+	EventBase
+	HWID       string
+	BeaconType string // "enter", "banner", "stay"
+	DM         string
+}
+
+// AccountLinkEvent is sent when a user links their account with a provider service.
+type AccountLinkEvent struct {
+	EventBase
+	Result string // "ok", "failed"
+	Nonce  string
+}
+
+// ThingsEvent is sent by LINE Things devices.
+type ThingsEvent struct {
+	EventBase
+	DeviceID   string
+	ThingsType string // "link", "unlink", "scenarioResult"
+	// Result is the scenario result of "scenarioResult" events.
+	Result json.RawMessage
+}
+
+// MembershipEvent is sent when a user joins, leaves or renews a membership.
+type MembershipEvent struct {
+	EventBase
+	MembershipType string // "joined", "left", "renewed"
+	MembershipID   int64
+}
+
+// ModuleEvent is sent to module channels: module, activated, deactivated,
+// botSuspended and botResumed events. Raw holds the full event.
+type ModuleEvent struct {
+	EventBase
+	Raw json.RawMessage
+}
+
+// UnknownEvent is an event type this package does not know yet. Raw holds
+// the full event.
+type UnknownEvent struct {
+	EventBase
+	Raw json.RawMessage
+}
+
+// MessageContent is the content of a MessageEvent.
+type MessageContent interface {
+	isMessageContent()
+}
+
+// ContentProvider tells where the content of a media message is stored.
+// Content provided by "line" is downloaded with the message ID.
+type ContentProvider struct {
+	Type               string `json:"type"` // "line", "external"
+	OriginalContentURL string `json:"originalContentUrl,omitempty"`
+	PreviewImageURL    string `json:"previewImageUrl,omitempty"`
+}
+
+// TextMessageContent is a received text message.
+type TextMessageContent struct {
+	ID              string         `json:"id"`
+	Text            string         `json:"text"`
+	Emojis          []Emoji        `json:"emojis,omitempty"`
+	Mention         *MentionObject `json:"mention,omitempty"`
+	QuoteToken      string         `json:"quoteToken,omitempty"`
+	QuotedMessageID string         `json:"quotedMessageId,omitempty"`
+}
+
+func (m *TextMessageContent) isMessageContent() {}
+
+// Emoji is a LINE emoji inside a received text message.
+type Emoji struct {
+	Index     int    `json:"index"`
+	Length    int    `json:"length"`
+	ProductID string `json:"productId"`
+	EmojiID   string `json:"emojiId"`
+}
+
+// MentionObject lists the users mentioned in a received text message.
+type MentionObject struct {
+	Mentionees []Mentionee `json:"mentionees"`
+}
+
+// Mentionee is a mention inside a received text message.
+type Mentionee struct {
+	Index  int    `json:"index"`
+	Length int    `json:"length"`
+	Type   string `json:"type"` // "user", "all"
+	UserID string `json:"userId,omitempty"`
+	IsSelf bool   `json:"isSelf,omitempty"`
+}
+
+// ImageMessageContent is a received image.
+type ImageMessageContent struct {
+	ID              string          `json:"id"`
+	ContentProvider ContentProvider `json:"contentProvider"`
+	// ImageSet groups images sent together.
+	ImageSet   *ImageSet `json:"imageSet,omitempty"`
+	QuoteToken string    `json:"quoteToken,omitempty"`
+}
+
+func (m *ImageMessageContent) isMessageContent() {}
+
+// ImageSet identifies an image within a set of images sent at once.
+type ImageSet struct {
+	ID    string `json:"id"`
+	Index int    `json:"index"`
+	Total int    `json:"total"`
+}
+
+// VideoMessageContent is a received video. Duration is in milliseconds.
+type VideoMessageContent struct {
+	ID              string          `json:"id"`
+	Duration        int             `json:"duration"`
+	ContentProvider ContentProvider `json:"contentProvider"`
+	QuoteToken      string          `json:"quoteToken,omitempty"`
+}
+
+func (m *VideoMessageContent) isMessageContent() {}
+
+// AudioMessageContent is a received audio message. Duration is in milliseconds.
+type AudioMessageContent struct {
+	ID              string          `json:"id"`
+	Duration        int             `json:"duration"`
+	ContentProvider ContentProvider `json:"contentProvider"`
+}
+
+func (m *AudioMessageContent) isMessageContent() {}
+
+// FileMessageContent is a received file.
+type FileMessageContent struct {
+	ID       string `json:"id"`
+	FileName string `json:"fileName"`
+	FileSize int64  `json:"fileSize"`
+}
+
+func (m *FileMessageContent) isMessageContent() {}
+
+// LocationMessageContent is a received location.
+type LocationMessageContent struct {
+	ID        string  `json:"id"`
+	Title     string  `json:"title,omitempty"`
+	Address   string  `json:"address,omitempty"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+func (m *LocationMessageContent) isMessageContent() {}
+
+// StickerMessageContent is a received sticker.
+type StickerMessageContent struct {
+	ID                  string   `json:"id"`
+	PackageID           string   `json:"packageId"`
+	StickerID           string   `json:"stickerId"`
+	StickerResourceType string   `json:"stickerResourceType"`
+	Keywords            []string `json:"keywords,omitempty"`
+	// Text is the text entered with a message sticker.
+	Text            string `json:"text,omitempty"`
+	QuoteToken      string `json:"quoteToken,omitempty"`
+	QuotedMessageID string `json:"quotedMessageId,omitempty"`
+}
+
+func (m *StickerMessageContent) isMessageContent() {}
+
+// UnknownMessageContent is a message type this package does not know yet.
+type UnknownMessageContent struct {
+	ID   string
+	Type string
+	Raw  json.RawMessage
+}
+
+func (m *UnknownMessageContent) isMessageContent() {}
+
+// rawEvent is the wire format of a webhook event.
+type rawEvent struct {
+	Type            EventType `json:"type"`
+	Mode            string    `json:"mode"`
+	Timestamp       int64     `json:"timestamp"`
+	Source          Source    `json:"source"`
+	WebhookEventID  string    `json:"webhookEventId"`
+	DeliveryContext struct {
+		IsRedelivery bool `json:"isRedelivery"`
+	} `json:"deliveryContext"`
+	ReplyToken string `json:"replyToken"`
+
+	Message  json.RawMessage            `json:"message"`
+	Unsend   struct{ MessageID string } `json:"unsend"`
+	Follow   struct{ IsUnblocked bool } `json:"follow"`
+	Joined   struct{ Members []Source } `json:"joined"`
+	Left     struct{ Members []Source } `json:"left"`
+	Postback struct {
+		Data   string            `json:"data"`
+		Params map[string]string `json:"params"`
+	} `json:"postback"`
+	VideoPlayComplete struct{ TrackingID string } `json:"videoPlayComplete"`
+	Beacon            struct {
+		HWID string `json:"hwid"`
+		Type string `json:"type"`
+		DM   string `json:"dm"`
+	} `json:"beacon"`
+	Link struct {
+		Result string `json:"result"`
+		Nonce  string `json:"nonce"`
+	} `json:"link"`
+	Things struct {
+		DeviceID string          `json:"deviceId"`
+		Type     string          `json:"type"`
+		Result   json.RawMessage `json:"result"`
+	} `json:"things"`
+	Membership struct {
+		Type         string `json:"type"`
+		MembershipID int64  `json:"membershipId"`
+	} `json:"membership"`
+}
+
+// parseEvent decodes a single webhook event received at receivedAt.
+func parseEvent(data json.RawMessage, receivedAt time.Time) (Event, error) {
+	var raw rawEvent
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode line event: %w", err)
+	}
+
+	base := EventBase{
+		Type:           raw.Type,
+		Mode:           raw.Mode,
+		Timestamp:      time.UnixMilli(raw.Timestamp),
+		Source:         raw.Source,
+		WebhookEventID: raw.WebhookEventID,
+		IsRedelivery:   raw.DeliveryContext.IsRedelivery,
+	}
+	if raw.ReplyToken != "" {
+		base.ReplyToken = ReplyToken{Value: raw.ReplyToken, ReceivedAt: receivedAt}
+	}
+
+	switch raw.Type {
+	case EventMessage:
+		msg, err := parseMessageContent(raw.Message)
+		if err != nil {
+			return nil, err
+		}
+		return &MessageEvent{EventBase: base, Message: msg}, nil
+	case EventUnsend:
+		return &UnsendEvent{EventBase: base, MessageID: raw.Unsend.MessageID}, nil
+	case EventFollow:
+		return &FollowEvent{EventBase: base, IsUnblocked: raw.Follow.IsUnblocked}, nil
+	case EventUnfollow:
+		return &UnfollowEvent{EventBase: base}, nil
+	case EventJoin:
+		return &JoinEvent{EventBase: base}, nil
+	case EventLeave:
+		return &LeaveEvent{EventBase: base}, nil
+	case EventMemberJoined:
+		return &MemberJoinedEvent{EventBase: base, Members: raw.Joined.Members}, nil
+	case EventMemberLeft:
+		return &MemberLeftEvent{EventBase: base, Members: raw.Left.Members}, nil
+	case EventPostback:
+		return &PostbackEvent{EventBase: base, Data: raw.Postback.Data, Params: raw.Postback.Params}, nil
+	case EventVideoPlayComplete:
+		return &VideoPlayCompleteEvent{EventBase: base, TrackingID: raw.VideoPlayComplete.TrackingID}, nil
+	case EventBeacon:
+		return &BeaconEvent{EventBase: base, HWID: raw.Beacon.HWID, BeaconType: raw.Beacon.Type, DM: raw.Beacon.DM}, nil
+	case EventAccountLink:
+		return &AccountLinkEvent{EventBase: base, Result: raw.Link.Result, Nonce: raw.Link.Nonce}, nil
+	case EventThings:
+		return &ThingsEvent{EventBase: base, DeviceID: raw.Things.DeviceID, ThingsType: raw.Things.Type, Result: raw.Things.Result}, nil
+	case EventMembership:
+		return &MembershipEvent{EventBase: base, MembershipType: raw.Membership.Type, MembershipID: raw.Membership.MembershipID}, nil
+	case EventModule, EventActivated, EventDeactivated, EventBotSuspended, EventBotResumed:
+		return &ModuleEvent{EventBase: base, Raw: data}, nil
+	default:
+		return &UnknownEvent{EventBase: base, Raw: data}, nil
+	}
+}
+
+func parseMessageContent(data json.RawMessage) (MessageContent, error) {
+	var head struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("failed to decode line message: %w", err)
+	}
+
+	var msg MessageContent
+	switch head.Type {
+	case "text":
+		msg = &TextMessageContent{}
+	case "image":
+		msg = &ImageMessageContent{}
+	case "video":
+		msg = &VideoMessageContent{}
+	case "audio":
+		msg = &AudioMessageContent{}
+	case "file":
+		msg = &FileMessageContent{}
+	case "location":
+		msg = &LocationMessageContent{}
+	case "sticker":
+		msg = &StickerMessageContent{}
+	default:
+		return &UnknownMessageContent{ID: head.ID, Type: head.Type, Raw: data}, nil
+	}
+	if err := json.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("failed to decode line %s message: %w", head.Type, err)
+	}
+	return msg, nil
+}
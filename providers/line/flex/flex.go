@@ -0,0 +1,654 @@
+// Package flex builds LINE Flex Message containers without spelling out the
+// discriminator Type fields of the line package structs:
+//
+//	bubble, err := flex.Bubble().
+//		Hero(flex.Image("https://example.com/cover.png").Size("full").AspectMode("cover")).
+//		Body(flex.VBox(
+//			flex.Text("Deploy finished").Bold().Size("xl"),
+//			flex.Text("api v1.2.0").Color("#999999"),
+//		)).
+//		Footer(flex.VBox(flex.Button(line.NewURIAction("Open", "https://example.com")).Primary())).
+//		Build()
+//
+// Build validates the result with Validate.
+package flex
+
+import (
+	"github.com/thanpawatpiti/notify/providers/line"
+)
+
+// Component is a Flex component builder, or a raw line.FlexComponent wrapped with Raw.
+type Component interface {
+	flexComponent() line.FlexComponent
+}
+
+type raw struct {
+	c line.FlexComponent
+}
+
+func (r raw) flexComponent() line.FlexComponent { return r.c }
+
+// Raw wraps an existing line.FlexComponent so it can be used with the builders.
+func Raw(c line.FlexComponent) Component {
+	return raw{c}
+}
+
+func components(cs []Component) []line.FlexComponent {
+	out := make([]line.FlexComponent, 0, len(cs))
+	for _, c := range cs {
+		out = append(out, c.flexComponent())
+	}
+	return out
+}
+
+// BubbleBuilder builds a bubble container.
+type BubbleBuilder struct {
+	b line.BubbleContainer
+}
+
+// Bubble starts a bubble container.
+func Bubble() *BubbleBuilder {
+	return &BubbleBuilder{b: line.BubbleContainer{Type: "bubble"}}
+}
+
+// Size sets the bubble size: "nano", "micro", "deca", "hecto", "kilo", "mega" or "giga".
+func (b *BubbleBuilder) Size(size string) *BubbleBuilder {
+	b.b.Size = size
+	return b
+}
+
+// Direction sets the text direction, "ltr" or "rtl".
+func (b *BubbleBuilder) Direction(direction string) *BubbleBuilder {
+	b.b.Direction = direction
+	return b
+}
+
+// Header sets the header block.
+func (b *BubbleBuilder) Header(box *BoxBuilder) *BubbleBuilder {
+	b.b.Header = box.box()
+	return b
+}
+
+// Hero sets the hero block: a box, image or video.
+func (b *BubbleBuilder) Hero(c Component) *BubbleBuilder {
+	b.b.Hero = c.flexComponent()
+	return b
+}
+
+// Body sets the body block.
+func (b *BubbleBuilder) Body(box *BoxBuilder) *BubbleBuilder {
+	b.b.Body = box.box()
+	return b
+}
+
+// Footer sets the footer block.
+func (b *BubbleBuilder) Footer(box *BoxBuilder) *BubbleBuilder {
+	b.b.Footer = box.box()
+	return b
+}
+
+// Styles sets the block styles.
+func (b *BubbleBuilder) Styles(styles line.BubbleStyles) *BubbleBuilder {
+	b.b.Styles = &styles
+	return b
+}
+
+// Action sets the action run when the bubble is tapped.
+func (b *BubbleBuilder) Action(a line.Action) *BubbleBuilder {
+	b.b.Action = &a
+	return b
+}
+
+// Build validates and returns the bubble.
+func (b *BubbleBuilder) Build() (line.BubbleContainer, error) {
+	if err := Validate(b.b); err != nil {
+		return line.BubbleContainer{}, err
+	}
+	return b.b, nil
+}
+
+// Message builds the bubble into a Flex message.
+func (b *BubbleBuilder) Message(altText string) (line.FlexMessage, error) {
+	c, err := b.Build()
+	if err != nil {
+		return line.FlexMessage{}, err
+	}
+	return line.FlexMessage{AltText: altText, Contents: c}, nil
+}
+
+// CarouselBuilder builds a carousel container.
+type CarouselBuilder struct {
+	bubbles []*BubbleBuilder
+}
+
+// Carousel starts a carousel of bubbles.
+func Carousel(bubbles ...*BubbleBuilder) *CarouselBuilder {
+	return &CarouselBuilder{bubbles: bubbles}
+}
+
+// Add appends bubbles to the carousel.
+func (c *CarouselBuilder) Add(bubbles ...*BubbleBuilder) *CarouselBuilder {
+	c.bubbles = append(c.bubbles, bubbles...)
+	return c
+}
+
+// Build validates and returns the carousel.
+func (c *CarouselBuilder) Build() (line.CarouselContainer, error) {
+	carousel := line.CarouselContainer{Type: "carousel"}
+	for _, b := range c.bubbles {
+		carousel.Contents = append(carousel.Contents, b.b)
+	}
+	if err := Validate(carousel); err != nil {
+		return line.CarouselContainer{}, err
+	}
+	return carousel, nil
+}
+
+// Message builds the carousel into a Flex message.
+func (c *CarouselBuilder) Message(altText string) (line.FlexMessage, error) {
+	carousel, err := c.Build()
+	if err != nil {
+		return line.FlexMessage{}, err
+	}
+	return line.FlexMessage{AltText: altText, Contents: carousel}, nil
+}
+
+// BoxBuilder builds a box component.
+type BoxBuilder struct {
+	b line.BoxComponent
+}
+
+func newBox(layout string, contents []Component) *BoxBuilder {
+	return &BoxBuilder{b: line.BoxComponent{Type: "box", Layout: layout, Contents: components(contents)}}
+}
+
+// VBox starts a vertical box.
+func VBox(contents ...Component) *BoxBuilder {
+	return newBox("vertical", contents)
+}
+
+// HBox starts a horizontal box.
+func HBox(contents ...Component) *BoxBuilder {
+	return newBox("horizontal", contents)
+}
+
+// BaselineBox starts a baseline box, which aligns text and icons on their baseline.
+func BaselineBox(contents ...Component) *BoxBuilder {
+	return newBox("baseline", contents)
+}
+
+func (b *BoxBuilder) flexComponent() line.FlexComponent { return b.b }
+
+func (b *BoxBuilder) box() *line.BoxComponent {
+	box := b.b
+	return &box
+}
+
+// Add appends components to the box.
+func (b *BoxBuilder) Add(contents ...Component) *BoxBuilder {
+	b.b.Contents = append(b.b.Contents, components(contents)...)
+	return b
+}
+
+// Flex sets the flex ratio within the parent box.
+func (b *BoxBuilder) Flex(n int) *BoxBuilder {
+	b.b.Flex = &n
+	return b
+}
+
+// Spacing sets the space between children, e.g. "sm" or "8px".
+func (b *BoxBuilder) Spacing(spacing string) *BoxBuilder {
+	b.b.Spacing = spacing
+	return b
+}
+
+// Margin sets the space before the box.
+func (b *BoxBuilder) Margin(margin string) *BoxBuilder {
+	b.b.Margin = margin
+	return b
+}
+
+// Padding sets the padding on all sides.
+func (b *BoxBuilder) Padding(padding string) *BoxBuilder {
+	b.b.PaddingAll = padding
+	return b
+}
+
+// PaddingSides sets the padding of each side; empty values are left unset.
+func (b *BoxBuilder) PaddingSides(top, bottom, start, end string) *BoxBuilder {
+	b.b.PaddingTop, b.b.PaddingBottom, b.b.PaddingStart, b.b.PaddingEnd = top, bottom, start, end
+	return b
+}
+
+// BackgroundColor sets the background color.
+func (b *BoxBuilder) BackgroundColor(color string) *BoxBuilder {
+	b.b.BackgroundColor = color
+	return b
+}
+
+// Gradient sets a linear gradient background, e.g. angle "90deg".
+func (b *BoxBuilder) Gradient(angle, startColor, endColor string) *BoxBuilder {
+	b.b.Background = &line.BoxBackground{Type: "linearGradient", Angle: angle, StartColor: startColor, EndColor: endColor}
+	return b
+}
+
+// Border sets the border width and color.
+func (b *BoxBuilder) Border(width, color string) *BoxBuilder {
+	b.b.BorderWidth, b.b.BorderColor = width, color
+	return b
+}
+
+// CornerRadius sets the corner radius.
+func (b *BoxBuilder) CornerRadius(radius string) *BoxBuilder {
+	b.b.CornerRadius = radius
+	return b
+}
+
+// Width sets the width.
+func (b *BoxBuilder) Width(width string) *BoxBuilder {
+	b.b.Width = width
+	return b
+}
+
+// MaxWidth sets the maximum width.
+func (b *BoxBuilder) MaxWidth(width string) *BoxBuilder {
+	b.b.MaxWidth = width
+	return b
+}
+
+// Height sets the height.
+func (b *BoxBuilder) Height(height string) *BoxBuilder {
+	b.b.Height = height
+	return b
+}
+
+// MaxHeight sets the maximum height.
+func (b *BoxBuilder) MaxHeight(height string) *BoxBuilder {
+	b.b.MaxHeight = height
+	return b
+}
+
+// JustifyContent sets how children are distributed along the main axis.
+func (b *BoxBuilder) JustifyContent(value string) *BoxBuilder {
+	b.b.JustifyContent = value
+	return b
+}
+
+// AlignItems sets how children are aligned along the cross axis.
+func (b *BoxBuilder) AlignItems(value string) *BoxBuilder {
+	b.b.AlignItems = value
+	return b
+}
+
+// Absolute positions the box at the given offsets from its parent; empty
+// values are left unset.
+func (b *BoxBuilder) Absolute(top, bottom, start, end string) *BoxBuilder {
+	b.b.Position = "absolute"
+	b.b.OffsetTop, b.b.OffsetBottom, b.b.OffsetStart, b.b.OffsetEnd = top, bottom, start, end
+	return b
+}
+
+// Action sets the action run when the box is tapped.
+func (b *BoxBuilder) Action(a line.Action) *BoxBuilder {
+	b.b.Action = &a
+	return b
+}
+
+// TextBuilder builds a text component.
+type TextBuilder struct {
+	t line.TextComponent
+}
+
+// Text starts a text component.
+func Text(text string) *TextBuilder {
+	return &TextBuilder{t: line.TextComponent{Type: "text", Text: text}}
+}
+
+func (t *TextBuilder) flexComponent() line.FlexComponent { return t.t }
+
+// Spans adds styled spans; LINE shows them instead of the text.
+func (t *TextBuilder) Spans(spans ...*SpanBuilder) *TextBuilder {
+	for _, s := range spans {
+		t.t.Contents = append(t.t.Contents, s.s)
+	}
+	return t
+}
+
+// Bold sets the weight to bold.
+func (t *TextBuilder) Bold() *TextBuilder {
+	t.t.Weight = "bold"
+	return t
+}
+
+// Italic sets the style to italic.
+func (t *TextBuilder) Italic() *TextBuilder {
+	t.t.Style = "italic"
+	return t
+}
+
+// Decoration sets "underline" or "line-through".
+func (t *TextBuilder) Decoration(decoration string) *TextBuilder {
+	t.t.Decoration = decoration
+	return t
+}
+
+// Size sets the font size, e.g. "sm", "xl" or "16px".
+func (t *TextBuilder) Size(size string) *TextBuilder {
+	t.t.Size = size
+	return t
+}
+
+// Color sets the font color.
+func (t *TextBuilder) Color(color string) *TextBuilder {
+	t.t.Color = color
+	return t
+}
+
+// Align sets the horizontal alignment: "start", "end" or "center".
+func (t *TextBuilder) Align(align string) *TextBuilder {
+	t.t.Align = align
+	return t
+}
+
+// Gravity sets the vertical alignment: "top", "bottom" or "center".
+func (t *TextBuilder) Gravity(gravity string) *TextBuilder {
+	t.t.Gravity = gravity
+	return t
+}
+
+// Wrap wraps long text over several lines.
+func (t *TextBuilder) Wrap() *TextBuilder {
+	t.t.Wrap = true
+	return t
+}
+
+// MaxLines limits wrapped text to n lines.
+func (t *TextBuilder) MaxLines(n int) *TextBuilder {
+	t.t.MaxLines = n
+	return t
+}
+
+// LineSpacing sets the space between wrapped lines.
+func (t *TextBuilder) LineSpacing(spacing string) *TextBuilder {
+	t.t.LineSpacing = spacing
+	return t
+}
+
+// Flex sets the flex ratio within the parent box.
+func (t *TextBuilder) Flex(n int) *TextBuilder {
+	t.t.Flex = &n
+	return t
+}
+
+// Margin sets the space before the text.
+func (t *TextBuilder) Margin(margin string) *TextBuilder {
+	t.t.Margin = margin
+	return t
+}
+
+// ShrinkToFit shrinks the font to fit the width.
+func (t *TextBuilder) ShrinkToFit() *TextBuilder {
+	t.t.AdjustMode = "shrink-to-fit"
+	return t
+}
+
+// Action sets the action run when the text is tapped.
+func (t *TextBuilder) Action(a line.Action) *TextBuilder {
+	t.t.Action = &a
+	return t
+}
+
+// SpanBuilder builds a span inside a text component.
+type SpanBuilder struct {
+	s line.SpanComponent
+}
+
+// Span starts a span.
+func Span(text string) *SpanBuilder {
+	return &SpanBuilder{s: line.SpanComponent{Type: "span", Text: text}}
+}
+
+// Bold sets the weight to bold.
+func (s *SpanBuilder) Bold() *SpanBuilder {
+	s.s.Weight = "bold"
+	return s
+}
+
+// Italic sets the style to italic.
+func (s *SpanBuilder) Italic() *SpanBuilder {
+	s.s.Style = "italic"
+	return s
+}
+
+// Decoration sets "underline" or "line-through".
+func (s *SpanBuilder) Decoration(decoration string) *SpanBuilder {
+	s.s.Decoration = decoration
+	return s
+}
+
+// Size sets the font size.
+func (s *SpanBuilder) Size(size string) *SpanBuilder {
+	s.s.Size = size
+	return s
+}
+
+// Color sets the font color.
+func (s *SpanBuilder) Color(color string) *SpanBuilder {
+	s.s.Color = color
+	return s
+}
+
+// ImageBuilder builds an image component.
+type ImageBuilder struct {
+	i line.ImageComponent
+}
+
+// Image starts an image component. url must be HTTPS.
+func Image(url string) *ImageBuilder {
+	return &ImageBuilder{i: line.ImageComponent{Type: "image", URL: url}}
+}
+
+func (i *ImageBuilder) flexComponent() line.FlexComponent { return i.i }
+
+// Size sets the image width, e.g. "md", "full" or "50%".
+func (i *ImageBuilder) Size(size string) *ImageBuilder {
+	i.i.Size = size
+	return i
+}
+
+// AspectRatio sets the aspect ratio, e.g. "20:13".
+func (i *ImageBuilder) AspectRatio(ratio string) *ImageBuilder {
+	i.i.AspectRatio = ratio
+	return i
+}
+
+// AspectMode sets "cover" or "fit".
+func (i *ImageBuilder) AspectMode(mode string) *ImageBuilder {
+	i.i.AspectMode = mode
+	return i
+}
+
+// Align sets the horizontal alignment.
+func (i *ImageBuilder) Align(align string) *ImageBuilder {
+	i.i.Align = align
+	return i
+}
+
+// Gravity sets the vertical alignment.
+func (i *ImageBuilder) Gravity(gravity string) *ImageBuilder {
+	i.i.Gravity = gravity
+	return i
+}
+
+// BackgroundColor sets the color behind transparent or letterboxed images.
+func (i *ImageBuilder) BackgroundColor(color string) *ImageBuilder {
+	i.i.BackgroundColor = color
+	return i
+}
+
+// Animated plays APNG animations.
+func (i *ImageBuilder) Animated() *ImageBuilder {
+	i.i.Animated = true
+	return i
+}
+
+// Flex sets the flex ratio within the parent box.
+func (i *ImageBuilder) Flex(n int) *ImageBuilder {
+	i.i.Flex = &n
+	return i
+}
+
+// Margin sets the space before the image.
+func (i *ImageBuilder) Margin(margin string) *ImageBuilder {
+	i.i.Margin = margin
+	return i
+}
+
+// Action sets the action run when the image is tapped.
+func (i *ImageBuilder) Action(a line.Action) *ImageBuilder {
+	i.i.Action = &a
+	return i
+}
+
+// VideoBuilder builds a video component.
+type VideoBuilder struct {
+	v line.VideoComponent
+}
+
+// Video starts a video hero. alt is shown on clients that cannot play video.
+func Video(url, previewURL string, alt Component) *VideoBuilder {
+	return &VideoBuilder{v: line.VideoComponent{Type: "video", URL: url, PreviewURL: previewURL, AltContent: alt.flexComponent()}}
+}
+
+func (v *VideoBuilder) flexComponent() line.FlexComponent { return v.v }
+
+// AspectRatio sets the aspect ratio, e.g. "16:9".
+func (v *VideoBuilder) AspectRatio(ratio string) *VideoBuilder {
+	v.v.AspectRatio = ratio
+	return v
+}
+
+// Action sets the URI action shown after the video.
+func (v *VideoBuilder) Action(a line.Action) *VideoBuilder {
+	v.v.Action = &a
+	return v
+}
+
+// IconBuilder builds an icon component.
+type IconBuilder struct {
+	i line.IconComponent
+}
+
+// Icon starts an icon component for baseline boxes.
+func Icon(url string) *IconBuilder {
+	return &IconBuilder{i: line.IconComponent{Type: "icon", URL: url}}
+}
+
+func (i *IconBuilder) flexComponent() line.FlexComponent { return i.i }
+
+// Size sets the icon size.
+func (i *IconBuilder) Size(size string) *IconBuilder {
+	i.i.Size = size
+	return i
+}
+
+// AspectRatio sets the aspect ratio.
+func (i *IconBuilder) AspectRatio(ratio string) *IconBuilder {
+	i.i.AspectRatio = ratio
+	return i
+}
+
+// Margin sets the space before the icon.
+func (i *IconBuilder) Margin(margin string) *IconBuilder {
+	i.i.Margin = margin
+	return i
+}
+
+// ButtonBuilder builds a button component.
+type ButtonBuilder struct {
+	b line.ButtonComponent
+}
+
+// Button starts a button that runs a.
+func Button(a line.Action) *ButtonBuilder {
+	return &ButtonBuilder{b: line.ButtonComponent{Type: "button", Action: a}}
+}
+
+func (b *ButtonBuilder) flexComponent() line.FlexComponent { return b.b }
+
+// Primary styles the button as a filled primary button.
+func (b *ButtonBuilder) Primary() *ButtonBuilder {
+	b.b.Style = "primary"
+	return b
+}
+
+// Secondary styles the button as a filled secondary button.
+func (b *ButtonBuilder) Secondary() *ButtonBuilder {
+	b.b.Style = "secondary"
+	return b
+}
+
+// Link styles the button as a link.
+func (b *ButtonBuilder) Link() *ButtonBuilder {
+	b.b.Style = "link"
+	return b
+}
+
+// Color sets the button color.
+func (b *ButtonBuilder) Color(color string) *ButtonBuilder {
+	b.b.Color = color
+	return b
+}
+
+// Height sets "sm" or "md".
+func (b *ButtonBuilder) Height(height string) *ButtonBuilder {
+	b.b.Height = height
+	return b
+}
+
+// Gravity sets the vertical alignment.
+func (b *ButtonBuilder) Gravity(gravity string) *ButtonBuilder {
+	b.b.Gravity = gravity
+	return b
+}
+
+// Flex sets the flex ratio within the parent box.
+func (b *ButtonBuilder) Flex(n int) *ButtonBuilder {
+	b.b.Flex = &n
+	return b
+}
+
+// Margin sets the space before the button.
+func (b *ButtonBuilder) Margin(margin string) *ButtonBuilder {
+	b.b.Margin = margin
+	return b
+}
+
+// SeparatorBuilder builds a separator component.
+type SeparatorBuilder struct {
+	s line.SeparatorComponent
+}
+
+// Separator starts a separator line.
+func Separator() *SeparatorBuilder {
+	return &SeparatorBuilder{s: line.SeparatorComponent{Type: "separator"}}
+}
+
+func (s *SeparatorBuilder) flexComponent() line.FlexComponent { return s.s }
+
+// Margin sets the space before the separator.
+func (s *SeparatorBuilder) Margin(margin string) *SeparatorBuilder {
+	s.s.Margin = margin
+	return s
+}
+
+// Color sets the separator color.
+func (s *SeparatorBuilder) Color(color string) *SeparatorBuilder {
+	s.s.Color = color
+	return s
+}
+
+// Filler returns a filler component that takes up free space.
+func Filler() Component {
+	return raw{line.FillerComponent{Type: "filler"}}
+}
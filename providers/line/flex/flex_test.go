@@ -0,0 +1,143 @@
+package flex
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/thanpawatpiti/notify/notifytest"
+	"github.com/thanpawatpiti/notify/providers/line"
+)
+
+func TestBuild(t *testing.T) {
+	msg, err := Carousel(
+		Bubble().
+			Size("kilo").
+			Hero(Image("https://example.com/cover.png").Size("full").AspectRatio("20:13").AspectMode("cover")).
+			Body(VBox(
+				Text("Deploy finished").Bold().Size("xl"),
+				BaselineBox(
+					Icon("https://example.com/star.png").Size("sm"),
+					Text("api v1.2.0").Color("#999999").Flex(0),
+					Filler(),
+				).Margin("md"),
+				Text("").Spans(Span("3 ").Bold(), Span("services updated")),
+				Separator().Margin("lg"),
+			).Spacing("sm")).
+			Footer(VBox(Button(line.NewURIAction("Open", "https://example.com")).Primary())),
+		Bubble().Body(HBox(Text("Second"))),
+	).Message("Deploy finished")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	got, err := json.MarshalIndent(msg, "", "  ")
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	notifytest.Golden(t, "carousel", append(got, '\n'))
+
+	// The built message must survive a round trip through the decoder.
+	var decoded line.FlexMessage
+	if err := json.Unmarshal(got, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := Validate(decoded.Contents); err != nil {
+		t.Errorf("decoded message is invalid: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	video := Video("https://example.com/v.mp4", "https://example.com/v.png", Image("https://example.com/v.png"))
+
+	tests := []struct {
+		name    string
+		build   func() error
+		wantErr string
+	}{
+		{"valid bubble", func() error {
+			_, err := Bubble().Hero(video).Body(VBox(Text("ok"))).Build()
+			return err
+		}, ""},
+		{"empty bubble", func() error {
+			_, err := Bubble().Build()
+			return err
+		}, "bubble has no blocks"},
+		{"empty text", func() error {
+			_, err := Bubble().Body(VBox(Text("ok"), Text(""))).Build()
+			return err
+		}, "body.contents[1]: text is empty"},
+		{"button in baseline box", func() error {
+			_, err := Bubble().Body(BaselineBox(Button(line.NewMessageAction("Hi", "hi")))).Build()
+			return err
+		}, "cannot be placed in a baseline box"},
+		{"icon outside baseline box", func() error {
+			_, err := Bubble().Body(VBox(Icon("https://example.com/i.png"))).Build()
+			return err
+		}, "icons can only be placed in a baseline box"},
+		{"http image", func() error {
+			_, err := Bubble().Hero(Image("http://example.com/i.png")).Build()
+			return err
+		}, "hero.url"},
+		{"button without label", func() error {
+			_, err := Bubble().Footer(VBox(Button(line.Action{Type: line.ActionURI, URI: "https://example.com"}))).Build()
+			return err
+		}, "button action needs a label"},
+		{"video in carousel", func() error {
+			_, err := Carousel(Bubble().Hero(video)).Build()
+			return err
+		}, "contents[0].hero: video cannot be used in a carousel"},
+		{"unknown size", func() error {
+			_, err := Bubble().Size("huge").Body(VBox(Text("ok"))).Build()
+			return err
+		}, "unknown bubble size"},
+		{"too many bubbles", func() error {
+			c := Carousel()
+			for i := 0; i < maxCarouselBubbles+1; i++ {
+				c.Add(Bubble().Body(VBox(Text("ok"))))
+			}
+			_, err := c.Build()
+			return err
+		}, "carousel has 13 bubbles"},
+		{"pointer hero", func() error {
+			return Validate(line.BubbleContainer{Type: "bubble", Hero: &line.ImageComponent{Type: "image", URL: "https://example.com/i.png"}})
+		}, ""},
+		{"pointer container and components", func() error {
+			return Validate(&line.BubbleContainer{Type: "bubble", Body: &line.BoxComponent{
+				Type:     "box",
+				Layout:   "baseline",
+				Contents: []line.FlexComponent{&line.IconComponent{Type: "icon", URL: "https://example.com/i.png"}, &line.TextComponent{Type: "text", Text: "ok"}},
+			}})
+		}, ""},
+		{"invalid pointer hero", func() error {
+			return Validate(line.BubbleContainer{Type: "bubble", Hero: &line.ImageComponent{Type: "image", URL: "http://example.com/i.png"}})
+		}, "hero.url"},
+		{"nil pointer component", func() error {
+			_, err := Bubble().Body(VBox(Raw((*line.TextComponent)(nil)))).Build()
+			return err
+		}, "component is nil"},
+		{"raw pointer component", func() error {
+			_, err := Bubble().Body(VBox(Raw(&line.TextComponent{Type: "text", Text: "ok"}))).Build()
+			return err
+		}, ""},
+		{"raw component", func() error {
+			_, err := Bubble().Body(VBox(Raw(line.TextComponent{Text: "no type"}))).Build()
+			return err
+		}, `expected "text"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.build()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
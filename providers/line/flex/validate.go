@@ -0,0 +1,263 @@
+package flex
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thanpawatpiti/notify/providers/line"
+)
+
+// maxCarouselBubbles is the number of bubbles a carousel may hold.
+const maxCarouselBubbles = 12
+
+var bubbleSizes = map[string]bool{
+	"": true, "nano": true, "micro": true, "deca": true, "hecto": true, "kilo": true, "mega": true, "giga": true,
+}
+
+// Validate checks a Flex container against the rules the Messaging API
+// enforces: discriminator types, required properties, HTTPS URLs, where
+// video and baseline children may appear and the carousel size. The error
+// names the offending property, e.g. "contents[1].body.contents[0]: text is empty".
+// Containers and components may be values or pointers.
+func Validate(c line.FlexContainer) error {
+	switch v := c.(type) {
+	case *line.BubbleContainer:
+		if v == nil {
+			return fmt.Errorf("container is nil")
+		}
+		return Validate(*v)
+	case *line.CarouselContainer:
+		if v == nil {
+			return fmt.Errorf("container is nil")
+		}
+		return Validate(*v)
+	case line.BubbleContainer:
+		return validateBubble(v, "", false)
+	case line.CarouselContainer:
+		if v.Type != "carousel" {
+			return fmt.Errorf("type: expected \"carousel\", got %q", v.Type)
+		}
+		if len(v.Contents) == 0 {
+			return fmt.Errorf("contents: carousel has no bubbles")
+		}
+		if len(v.Contents) > maxCarouselBubbles {
+			return fmt.Errorf("contents: carousel has %d bubbles (max %d)", len(v.Contents), maxCarouselBubbles)
+		}
+		for i, b := range v.Contents {
+			if err := validateBubble(b, fmt.Sprintf("contents[%d].", i), true); err != nil {
+				return err
+			}
+		}
+		return nil
+	case nil:
+		return fmt.Errorf("container is nil")
+	default:
+		return fmt.Errorf("unsupported container %T", c)
+	}
+}
+
+func validateBubble(b line.BubbleContainer, path string, inCarousel bool) error {
+	if b.Type != "bubble" {
+		return fmt.Errorf("%stype: expected \"bubble\", got %q", path, b.Type)
+	}
+	if !bubbleSizes[b.Size] {
+		return fmt.Errorf("%ssize: unknown bubble size %q", path, b.Size)
+	}
+	if b.Header == nil && b.Hero == nil && b.Body == nil && b.Footer == nil {
+		return fmt.Errorf("%sbubble has no blocks", strings.TrimSuffix(path, "."))
+	}
+	for _, block := range []struct {
+		name string
+		box  *line.BoxComponent
+	}{{"header", b.Header}, {"body", b.Body}, {"footer", b.Footer}} {
+		if block.box != nil {
+			if err := validateComponent(*block.box, path+block.name, ""); err != nil {
+				return err
+			}
+		}
+	}
+	if hero := deref(b.Hero); hero != nil {
+		switch hero.(type) {
+		case line.BoxComponent, line.ImageComponent:
+		case line.VideoComponent:
+			if inCarousel {
+				return fmt.Errorf("%shero: video cannot be used in a carousel", path)
+			}
+			if b.Size != "" && b.Size != "kilo" && b.Size != "mega" && b.Size != "giga" {
+				return fmt.Errorf("%shero: video needs a kilo, mega or giga bubble", path)
+			}
+		default:
+			return fmt.Errorf("%shero: %T cannot be used as a hero", path, hero)
+		}
+		if err := validateComponent(hero, path+"hero", ""); err != nil {
+			return err
+		}
+	}
+	if b.Action != nil {
+		return validateAction(*b.Action, path+"action")
+	}
+	return nil
+}
+
+// validateComponent checks c at path; parentLayout is the layout of the enclosing box.
+func validateComponent(c line.FlexComponent, path, parentLayout string) error {
+	c = deref(c)
+	if parentLayout == "baseline" {
+		switch c.(type) {
+		case line.TextComponent, line.IconComponent, line.FillerComponent:
+		default:
+			return fmt.Errorf("%s: %T cannot be placed in a baseline box", path, c)
+		}
+	}
+
+	switch v := c.(type) {
+	case line.BoxComponent:
+		if v.Type != "box" {
+			return fmt.Errorf("%s.type: expected \"box\", got %q", path, v.Type)
+		}
+		switch v.Layout {
+		case "horizontal", "vertical", "baseline":
+		default:
+			return fmt.Errorf("%s.layout: unknown layout %q", path, v.Layout)
+		}
+		for i, child := range v.Contents {
+			if err := validateComponent(child, fmt.Sprintf("%s.contents[%d]", path, i), v.Layout); err != nil {
+				return err
+			}
+		}
+		if v.Action != nil {
+			return validateAction(*v.Action, path+".action")
+		}
+	case line.TextComponent:
+		if v.Type != "text" {
+			return fmt.Errorf("%s.type: expected \"text\", got %q", path, v.Type)
+		}
+		if v.Text == "" && len(v.Contents) == 0 {
+			return fmt.Errorf("%s: text is empty", path)
+		}
+		for i, s := range v.Contents {
+			if s.Type != "span" {
+				return fmt.Errorf("%s.contents[%d].type: expected \"span\", got %q", path, i, s.Type)
+			}
+		}
+		if v.Action != nil {
+			return validateAction(*v.Action, path+".action")
+		}
+	case line.ImageComponent:
+		if v.Type != "image" {
+			return fmt.Errorf("%s.type: expected \"image\", got %q", path, v.Type)
+		}
+		if err := validateURL(v.URL, path+".url"); err != nil {
+			return err
+		}
+		if v.Action != nil {
+			return validateAction(*v.Action, path+".action")
+		}
+	case line.VideoComponent:
+		if v.Type != "video" {
+			return fmt.Errorf("%s.type: expected \"video\", got %q", path, v.Type)
+		}
+		if err := validateURL(v.URL, path+".url"); err != nil {
+			return err
+		}
+		if err := validateURL(v.PreviewURL, path+".previewUrl"); err != nil {
+			return err
+		}
+		switch alt := deref(v.AltContent); alt.(type) {
+		case line.BoxComponent, line.ImageComponent:
+			if err := validateComponent(alt, path+".altContent", ""); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s.altContent: expected a box or image, got %T", path, v.AltContent)
+		}
+	case line.IconComponent:
+		if v.Type != "icon" {
+			return fmt.Errorf("%s.type: expected \"icon\", got %q", path, v.Type)
+		}
+		if parentLayout != "baseline" {
+			return fmt.Errorf("%s: icons can only be placed in a baseline box", path)
+		}
+		return validateURL(v.URL, path+".url")
+	case line.ButtonComponent:
+		if v.Type != "button" {
+			return fmt.Errorf("%s.type: expected \"button\", got %q", path, v.Type)
+		}
+		if v.Action.Label == "" {
+			return fmt.Errorf("%s.action: button action needs a label", path)
+		}
+		return validateAction(v.Action, path+".action")
+	case line.SeparatorComponent:
+		if v.Type != "separator" {
+			return fmt.Errorf("%s.type: expected \"separator\", got %q", path, v.Type)
+		}
+	case line.FillerComponent:
+		if v.Type != "filler" {
+			return fmt.Errorf("%s.type: expected \"filler\", got %q", path, v.Type)
+		}
+	case nil:
+		return fmt.Errorf("%s: component is nil", path)
+	default:
+		return fmt.Errorf("%s: unsupported component %T", path, c)
+	}
+	return nil
+}
+
+// deref returns the component a pointer component points to, or nil for a
+// nil pointer, so that pointers validate like values.
+func deref(c line.FlexComponent) line.FlexComponent {
+	switch v := c.(type) {
+	case *line.BoxComponent:
+		if v != nil {
+			return *v
+		}
+	case *line.TextComponent:
+		if v != nil {
+			return *v
+		}
+	case *line.ImageComponent:
+		if v != nil {
+			return *v
+		}
+	case *line.VideoComponent:
+		if v != nil {
+			return *v
+		}
+	case *line.IconComponent:
+		if v != nil {
+			return *v
+		}
+	case *line.ButtonComponent:
+		if v != nil {
+			return *v
+		}
+	case *line.SeparatorComponent:
+		if v != nil {
+			return *v
+		}
+	case *line.FillerComponent:
+		if v != nil {
+			return *v
+		}
+	default:
+		return c
+	}
+	return nil
+}
+
+func validateAction(a line.Action, path string) error {
+	if a.Type == "" {
+		return fmt.Errorf("%s.type: action type is empty", path)
+	}
+	if a.Type == line.ActionURI && a.URI == "" {
+		return fmt.Errorf("%s.uri: uri action has no uri", path)
+	}
+	return nil
+}
+
+func validateURL(url, path string) error {
+	if !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("%s: %q is not an HTTPS URL", path, url)
+	}
+	return nil
+}
@@ -5,114 +5,421 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
 
 	"github.com/thanpawatpiti/notify"
+	"github.com/thanpawatpiti/notify/format"
 )
 
-const lineMessagingAPI = "https://api.line.me/v2/bot/message/push"
+const lineAPIBase = "https://api.line.me/v2/bot"
+
+const lineMessagingAPI = lineAPIBase + "/message/push"
+
+// lineDataAPIBase serves uploads and downloads of binary content.
+const lineDataAPIBase = "https://api-data.line.me/v2/bot"
+
+// maxMessages is the number of messages the Messaging API accepts per request.
+const maxMessages = 5
+
+// Name identifies this provider to a notify.MentionResolver.
+const Name = "line"
 
 // Provider implements the Notifier interface for LINE Messaging API.
 type Provider struct {
 	channelToken string
-	targetID     string // UserID or GroupID
+	recipient    Recipient
 	opts         notify.Options
+	config       options
+	quota        quotaState
 }
 
-// New creates a new LINE Messaging API provider.
+// options are the LINE-specific settings given to New.
+type options struct {
+	clock      notify.Clock
+	retry      retryPolicy
+	quotaGuard *QuotaGuard
+}
+
+// WithClock configures the clock that times retries and quota caching.
+// Defaults to notify.SystemClock.
+func WithClock(c notify.Clock) notify.Option {
+	return notify.ProviderOption(func(o *options) {
+		o.clock = c
+	})
+}
+
+// New creates a new LINE Messaging API provider that pushes messages to
+// targetID, a user, group or room ID.
 func New(channelToken, targetID string, opts ...notify.Option) *Provider {
+	return NewWithRecipient(channelToken, Push(targetID), opts...)
+}
+
+// NewMulticast creates a provider that sends every message to userIDs.
+func NewMulticast(channelToken string, userIDs []string, opts ...notify.Option) *Provider {
+	return NewWithRecipient(channelToken, Multicast(userIDs), opts...)
+}
+
+// NewBroadcast creates a provider that sends every message to all friends
+// of the LINE Official Account.
+func NewBroadcast(channelToken string, opts ...notify.Option) *Provider {
+	return NewWithRecipient(channelToken, Broadcast{}, opts...)
+}
+
+// NewNarrowcast creates a provider that sends every message to the
+// recipients selected by n.
+func NewNarrowcast(channelToken string, n Narrowcast, opts ...notify.Option) *Provider {
+	return NewWithRecipient(channelToken, n, opts...)
+}
+
+// NewWithRecipient creates a new LINE Messaging API provider for any Recipient.
+func NewWithRecipient(channelToken string, recipient Recipient, opts ...notify.Option) *Provider {
 	p := &Provider{
 		channelToken: channelToken,
-		targetID:     targetID,
+		recipient:    recipient,
 		opts: notify.Options{
 			HTTPClient: &http.Client{},
 		},
+		config: options{clock: notify.SystemClock},
 	}
 
 	for _, opt := range opts {
 		opt(&p.opts)
 	}
+	notify.ApplyProviderOptions(&p.opts, &p.config)
 
 	return p
 }
 
 // Send sends a message via LINE Messaging API.
 // payload can be:
-// - string: Simple text message.
-// - notify.CommonMessage: Generic rich message (Text + Image).
-// - line.FlexMessage: Advanced Flex Message.
+//   - string: Simple text message.
+//   - notify.CommonMessage: Generic rich message (Text + Image + Attachments).
+//   - line.Message: A typed message (TextMessage, ImageMessage,
+//     TemplateMessage or FlexMessage), optionally with a QuickReply.
+//
+// LINE cannot receive file uploads, so attachments require an uploader
+// configured with notify.WithAttachmentUploader; images are sent as image
+// messages and other files as links. Without one, notify.ErrAttachmentsUnsupported is returned.
+// CommonMessage mentions are sent as a text v2 message with mention
+// substitutions; user mentions need LINE user IDs, role mentions mention everyone.
+//
+// Every request carries an X-Line-Retry-Key, generated per call or taken
+// from ContextWithRetryKey, so retries never deliver a message twice.
 func (p *Provider) Send(ctx context.Context, payload interface{}) error {
-	if p.channelToken == "" || p.targetID == "" {
-		return fmt.Errorf("line channel token or target ID is missing")
+	_, err := p.send(ctx, payload)
+	return err
+}
+
+// Narrowcast sends payload like Send and returns the narrowcast request ID
+// for NarrowcastProgress. The provider must be created with NewNarrowcast.
+func (p *Provider) Narrowcast(ctx context.Context, payload interface{}) (string, error) {
+	if _, ok := p.recipient.(Narrowcast); !ok {
+		return "", fmt.Errorf("line provider is not configured for narrowcast")
+	}
+	return p.send(ctx, payload)
+}
+
+// NarrowcastProgress returns the delivery status of a narrowcast.
+func (p *Provider) NarrowcastProgress(ctx context.Context, requestID string) (*NarrowcastProgress, error) {
+	var progress NarrowcastProgress
+	if err := p.call(ctx, http.MethodGet, lineAPIBase+"/message/progress/narrowcast?requestId="+neturl.QueryEscape(requestID), nil, &progress); err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+// WaitNarrowcast polls NarrowcastProgress every interval, timed by the
+// clock set with WithClock, until the narrowcast has finished or ctx is done.
+func (p *Provider) WaitNarrowcast(ctx context.Context, requestID string, interval time.Duration) (*NarrowcastProgress, error) {
+	for {
+		progress, err := p.NarrowcastProgress(ctx, requestID)
+		if err != nil {
+			return nil, err
+		}
+		if progress.Done() {
+			return progress, nil
+		}
+		timer, stop := notify.NewTimer(p.config.clock, interval)
+		select {
+		case <-ctx.Done():
+			stop()
+			return progress, ctx.Err()
+		case <-timer:
+		}
+	}
+}
+
+// send sends every request rendered for payload with its own retry key and
+// returns the request ID of the last one.
+func (p *Provider) send(ctx context.Context, payload interface{}) (string, error) {
+	if err := p.checkQuota(ctx, payload); err != nil {
+		return "", err
+	}
+
+	reqs, err := p.Render(ctx, payload)
+	if err != nil {
+		return "", err
+	}
+
+	var requestID string
+	for i, req := range reqs {
+		req.Header.Set("X-Line-Retry-Key", retryKey(ctx, i))
+		if requestID, err = p.doWithRetry(req); err != nil {
+			return "", err
+		}
+	}
+
+	return requestID, nil
+}
+
+// Render builds the requests Send would make for payload without sending
+// them: one per call to the recipient's endpoint, so multicasts to more than
+// 500 users produce several requests. Attachments are still uploaded through the configured
+// notify.AttachmentUploader, since the messages reference their URLs.
+func (p *Provider) Render(ctx context.Context, payload interface{}) ([]*http.Request, error) {
+	return p.render(ctx, p.recipient, payload)
+}
+
+// render builds the requests that send payload to recipient.
+func (p *Provider) render(ctx context.Context, recipient Recipient, payload interface{}) ([]*http.Request, error) {
+	if p.channelToken == "" {
+		return nil, fmt.Errorf("line channel token is missing")
+	}
+	if recipient == nil {
+		return nil, fmt.Errorf("line recipient is missing")
 	}
 
 	var messages []interface{}
 
 	switch v := payload.(type) {
 	case string:
-		messages = append(messages, map[string]string{
-			"type": "text",
-			"text": v,
-		})
+		messages = append(messages, TextMessage{Text: v})
 	case notify.CommonMessage:
 		if v.ImageURL != "" {
-			messages = append(messages, map[string]string{
-				"type":               "image",
-				"originalContentUrl": v.ImageURL,
-				"previewImageUrl":    v.ImageURL,
-			})
+			messages = append(messages, ImageMessage{OriginalContentURL: v.ImageURL, PreviewImageURL: v.ImageURL})
 		}
-		if v.Content != "" {
-			text := v.Content
+		if len(v.Mentions) > 0 {
+			mentions, err := notify.ResolveMentions(ctx, p.opts.MentionResolver, Name, v.Mentions)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, mentionMessage(v.Title, format.Convert(v.Content, v.Format, format.Plain), mentions))
+		} else if v.Content != "" {
+			text := format.Convert(v.Content, v.Format, format.Plain)
 			if v.Title != "" {
-				text = fmt.Sprintf("%s\n%s", v.Title, v.Content)
+				text = fmt.Sprintf("%s\n%s", v.Title, text)
 			}
-			messages = append(messages, map[string]string{
-				"type": "text",
-				"text": text,
-			})
+			messages = append(messages, TextMessage{Text: text})
+		}
+		for _, a := range v.Attachments {
+			url, err := p.uploadAttachment(ctx, a)
+			if err != nil {
+				return nil, err
+			}
+			if a.IsImage() {
+				messages = append(messages, ImageMessage{OriginalContentURL: url, PreviewImageURL: url})
+			} else {
+				messages = append(messages, TextMessage{Text: fmt.Sprintf("%s\n%s", a.Name, url)})
+			}
+		}
+	case Message:
+		messages = append(messages, v)
+	case Messages:
+		for i, m := range v {
+			if m == nil {
+				return nil, fmt.Errorf("message %d is nil", i)
+			}
+			messages = append(messages, m)
 		}
-	case FlexMessage:
-		messages = append(messages, map[string]interface{}{
-			"type":     "flex",
-			"altText":  v.AltText,
-			"contents": v.Contents,
-		})
 	default:
-		return fmt.Errorf("unsupported payload type: %T", v)
+		return nil, fmt.Errorf("unsupported payload type: %T", v)
 	}
 
 	if len(messages) == 0 {
-		return fmt.Errorf("no messages to send")
+		return nil, fmt.Errorf("no messages to send")
 	}
-
-	reqPayload := map[string]interface{}{
-		"to":       p.targetID,
-		"messages": messages,
+	if len(messages) > maxMessages {
+		return nil, fmt.Errorf("too many messages: %d (max %d per request)", len(messages), maxMessages)
 	}
 
-	body, err := json.Marshal(reqPayload)
+	path, bodies, err := recipient.requests(messages)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
+		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, lineMessagingAPI, bytes.NewReader(body))
+	reqs := make([]*http.Request, 0, len(bodies))
+	for _, b := range bodies {
+		body, err := json.Marshal(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		req, err := p.newRequest(ctx, http.MethodPost, lineAPIBase+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+
+	return reqs, nil
+}
+
+// newRequest creates an authenticated Messaging API request.
+func (p *Provider) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	req.Header.Set("Authorization", "Bearer "+p.channelToken)
+	return req, nil
+}
 
+// call sends a Messaging API request with in as the JSON body, or no body
+// when in is nil, and decodes the response into out unless out is nil.
+func (p *Provider) call(ctx context.Context, method, url string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := p.newRequest(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	return p.decode(req, out)
+}
+
+// decode sends req and decodes a successful response into out unless out is nil.
+func (p *Provider) decode(req *http.Request, out interface{}) error {
 	resp, err := p.opts.HTTPClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("line messaging api returned status: %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return newAPIError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
 	}
-
 	return nil
 }
+
+// do sends req, checks the response status and returns the X-Line-Request-Id
+// header. A 409 Conflict for a retry key that was already accepted returns the
+// request ID of the accepted request.
+func (p *Provider) do(req *http.Request) (string, error) {
+	resp, err := p.opts.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		if id := resp.Header.Get("X-Line-Accepted-Request-Id"); id != "" {
+			return id, nil
+		}
+	}
+
+	// Narrowcast requests are accepted asynchronously with 202.
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return "", newAPIError(resp)
+	}
+
+	return resp.Header.Get("X-Line-Request-Id"), nil
+}
+
+// APIError is an error response from the Messaging API.
+type APIError struct {
+	StatusCode int
+	// Message is the error summary, e.g. "Invalid reply token".
+	Message string `json:"message"`
+	Details []struct {
+		Message  string `json:"message"`
+		Property string `json:"property"`
+	} `json:"details"`
+	// RequestID is the X-Line-Request-Id of the failed request.
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("line messaging api returned status: %d", e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	for _, d := range e.Details {
+		msg += fmt.Sprintf(" (%s: %s)", d.Property, d.Message)
+	}
+	return msg
+}
+
+// newAPIError reads the error body of resp.
+func newAPIError(resp *http.Response) *APIError {
+	e := &APIError{StatusCode: resp.StatusCode, RequestID: resp.Header.Get("X-Line-Request-Id")}
+	json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(e)
+	return e
+}
+
+// uploadAttachment hosts a through the configured notify.AttachmentUploader.
+func (p *Provider) uploadAttachment(ctx context.Context, a notify.Attachment) (string, error) {
+	if p.opts.AttachmentUploader == nil {
+		return "", fmt.Errorf("line: %w", notify.ErrAttachmentsUnsupported)
+	}
+	url, err := p.opts.AttachmentUploader.Upload(ctx, a)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload attachment %s: %w", a.Name, err)
+	}
+	return url, nil
+}
+
+// braceEscaper escapes literal braces in text v2 messages, where {key}
+// denotes a substitution.
+var braceEscaper = strings.NewReplacer("{", "{{", "}", "}}")
+
+// mentionMessage builds a text v2 message with the mentions on the line
+// between title and text.
+func mentionMessage(title, text string, mentions []notify.ResolvedMention) map[string]interface{} {
+	substitution := make(map[string]interface{}, len(mentions))
+	keys := make([]string, 0, len(mentions))
+	for i, m := range mentions {
+		key := fmt.Sprintf("mention%d", i)
+		keys = append(keys, "{"+key+"}")
+		mentionee := map[string]string{"type": "all"}
+		if m.Kind != notify.MentionRole {
+			mentionee = map[string]string{"type": "user", "userId": m.ID}
+		}
+		substitution[key] = map[string]interface{}{
+			"type":      "mention",
+			"mentionee": mentionee,
+		}
+	}
+
+	var lines []string
+	if title != "" {
+		lines = append(lines, braceEscaper.Replace(title))
+	}
+	lines = append(lines, strings.Join(keys, " "))
+	if text != "" {
+		lines = append(lines, braceEscaper.Replace(text))
+	}
+
+	return map[string]interface{}{
+		"type":         "textV2",
+		"text":         strings.Join(lines, "\n"),
+		"substitution": substitution,
+	}
+}
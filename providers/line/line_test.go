@@ -1,35 +1,41 @@
 package line
 
 import (
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/thanpawatpiti/notify"
+	"github.com/thanpawatpiti/notify/notifytest"
 )
 
 func TestSend(t *testing.T) {
-	client := &http.Client{
-		Transport: &mockTransport{
-			roundTrip: func(req *http.Request) (*http.Response, error) {
-				if req.URL.String() != lineMessagingAPI {
-					t.Errorf("expected URL %s, got %s", lineMessagingAPI, req.URL.String())
-				}
-				return &http.Response{
-					StatusCode: http.StatusOK,
-					Body:       http.NoBody,
-				}, nil
-			},
-		},
-	}
-
-	p := New("test-token", "test-user", notify.WithHTTPClient(client))
+	srv := notifytest.NewLINEServer(t)
+	p := New("test-token", "test-user", notify.WithHTTPClient(srv.Client()))
 
 	// Test 1: CommonMessage
 	err := p.Send(context.Background(), notify.CommonMessage{Content: "test"})
 	if err != nil {
 		t.Errorf("CommonMessage: expected no error, got %v", err)
 	}
+	req := srv.LastRequest(t)
+	if got := "https://" + req.Host + req.Path; got != lineMessagingAPI {
+		t.Errorf("expected URL %s, got %s", lineMessagingAPI, got)
+	}
+	if req.Header.Get("Authorization") != "Bearer test-token" {
+		t.Errorf("unexpected Authorization header %q", req.Header.Get("Authorization"))
+	}
 
 	// Test 2: FlexMessage
 	flexMsg := FlexMessage{
@@ -49,12 +55,691 @@ func TestSend(t *testing.T) {
 	if err != nil {
 		t.Errorf("FlexMessage: expected no error, got %v", err)
 	}
+
+	// Test 3: API error
+	srv.Fail(http.StatusBadRequest, "The request body has 1 error(s)")
+	if err := p.Send(context.Background(), "test"); err == nil {
+		t.Errorf("expected error for 400 response")
+	}
+}
+
+func TestSendAttachments(t *testing.T) {
+	srv := notifytest.NewLINEServer(t)
+	msg := notify.CommonMessage{
+		Content:     "chart",
+		Attachments: []notify.Attachment{{Name: "chart.png", Data: []byte("png")}},
+	}
+
+	p := New("test-token", "test-user", notify.WithHTTPClient(srv.Client()))
+	if err := p.Send(context.Background(), msg); !errors.Is(err, notify.ErrAttachmentsUnsupported) {
+		t.Errorf("expected ErrAttachmentsUnsupported, got %v", err)
+	}
+
+	uploader := notify.AttachmentUploaderFunc(func(ctx context.Context, a notify.Attachment) (string, error) {
+		return "https://cdn.example.com/" + a.Name, nil
+	})
+	p = New("test-token", "test-user", notify.WithHTTPClient(srv.Client()), notify.WithAttachmentUploader(uploader))
+	if err := p.Send(context.Background(), msg); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var body struct {
+		Messages []map[string]interface{} `json:"messages"`
+	}
+	srv.LastRequest(t).JSON(&body)
+	image := body.Messages[1]
+	if image["type"] != "image" || image["originalContentUrl"] != "https://cdn.example.com/chart.png" {
+		t.Errorf("unexpected image message: %v", image)
+	}
+}
+
+func TestSendMentions(t *testing.T) {
+	srv := notifytest.NewLINEServer(t)
+	p := New("test-token", "test-group", notify.WithHTTPClient(srv.Client()))
+
+	err := p.Send(context.Background(), notify.CommonMessage{
+		Title:    "Incident",
+		Content:  "CPU {host-1} at 99%",
+		Mentions: []notify.Mention{{Name: "U123"}},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var body struct {
+		Messages []map[string]interface{} `json:"messages"`
+	}
+	srv.LastRequest(t).JSON(&body)
+	msg := body.Messages[0]
+	if msg["type"] != "textV2" || msg["text"] != "Incident\n{mention0}\nCPU {{host-1}} at 99%" {
+		t.Errorf("unexpected message %v", msg)
+	}
+	sub := msg["substitution"].(map[string]interface{})["mention0"].(map[string]interface{})
+	if mentionee := sub["mentionee"].(map[string]interface{}); mentionee["userId"] != "U123" {
+		t.Errorf("unexpected mentionee %v", mentionee)
+	}
+}
+
+func TestRender(t *testing.T) {
+	p := New("test-token", "U0123456789")
+
+	tests := []struct {
+		name    string
+		payload interface{}
+	}{
+		{"string", "Deploy finished for api_server"},
+		{"common_message", notify.CommonMessage{
+			Title:    "Incident",
+			Content:  "Database **down** since 02:00 {UTC}",
+			ImageURL: "https://example.com/graph.png",
+			Mentions: []notify.Mention{{Name: "U123"}, {Name: "everyone", Kind: notify.MentionRole}},
+		}},
+		{"flex_message", FlexMessage{
+			AltText: "Order shipped",
+			Contents: BubbleContainer{
+				Type: "bubble",
+				Body: &BoxComponent{
+					Type:   "box",
+					Layout: "vertical",
+					Contents: []FlexComponent{
+						TextComponent{Type: "text", Text: "Order #1234 shipped", Weight: "bold"},
+					},
+				},
+			},
+		}},
+		{"buttons_template", TemplateMessage{
+			AltText: "Deploy v1.2.0?",
+			Template: ButtonsTemplate{
+				ThumbnailImageURL: "https://example.com/release.png",
+				Title:             "Release v1.2.0",
+				Text:              "Deploy to production?",
+				DefaultAction:     &Action{Type: ActionURI, Label: "View", URI: "https://example.com/releases/1.2.0", AltURI: &AltURI{Desktop: "https://example.com/desktop"}},
+				Actions: []Action{
+					{Type: ActionPostback, Label: "Deploy", Data: "deploy=1.2.0", DisplayText: "Deploy", InputOption: "closeRichMenu"},
+					NewDatetimePickerAction("Schedule", "schedule=1.2.0", "datetime"),
+					NewURIAction("Changelog", "https://example.com/changelog"),
+					NewClipboardAction("Copy tag", "v1.2.0"),
+				},
+			},
+		}},
+		{"confirm_template", TemplateMessage{
+			AltText: "Acknowledge incident?",
+			Template: ConfirmTemplate{
+				Text:    "Acknowledge incident #42?",
+				Actions: []Action{NewPostbackAction("Yes", "ack=42"), NewMessageAction("No", "ignore")},
+			},
+			QuickReply: &QuickReply{Items: []QuickReplyItem{
+				{ImageURL: "https://example.com/camera.png", Action: NewCameraAction("Camera")},
+				{Action: NewCameraRollAction("Photos")},
+				{Action: NewLocationAction("Location")},
+				{Action: NewRichMenuSwitchAction("Menu", "richmenu-alias-b", "menu=b")},
+			}},
+		}},
+		{"carousel_template", TemplateMessage{
+			AltText: "Services",
+			Template: CarouselTemplate{
+				Columns: []CarouselColumn{
+					{Title: "api", Text: "healthy", Actions: []Action{NewURIAction("Logs", "https://example.com/api")}},
+					{Title: "worker", Text: "degraded", Actions: []Action{NewURIAction("Logs", "https://example.com/worker")}},
+				},
+			},
+		}},
+		{"image_carousel_template", TemplateMessage{
+			AltText: "Dashboards",
+			Template: ImageCarouselTemplate{
+				Columns: []ImageCarouselColumn{
+					{ImageURL: "https://example.com/cpu.png", Action: NewURIAction("CPU", "https://example.com/cpu")},
+				},
+			},
+		}},
+		{"messages", Messages{
+			TextMessage{Text: "Deploy finished"},
+			StickerMessage{PackageID: "446", StickerID: "1988"},
+			LocationMessage{Title: "Site B", Address: "Bangkok", Latitude: 13.7563, Longitude: 100.5018},
+			VideoMessage{OriginalContentURL: "https://example.com/v.mp4", PreviewImageURL: "https://example.com/v.jpg", TrackingID: "deploy-1"},
+			AudioMessage{OriginalContentURL: "https://example.com/alert.m4a", Duration: 60000},
+		}},
+		{"imagemap", ImagemapMessage{
+			BaseURL:  "https://example.com/floorplan",
+			AltText:  "Floor plan",
+			BaseSize: ImagemapBaseSize{Width: 1040, Height: 1040},
+			Video: &ImagemapVideo{
+				OriginalContentURL: "https://example.com/cam.mp4",
+				PreviewImageURL:    "https://example.com/cam.jpg",
+				Area:               ImagemapArea{X: 0, Y: 0, Width: 1040, Height: 585},
+				ExternalLink:       &ImagemapExternalLink{LinkURI: "https://example.com/cams", Label: "All cameras"},
+			},
+			Actions: []ImagemapAction{
+				{Type: "uri", Label: "Room A", LinkURI: "https://example.com/a", Area: ImagemapArea{X: 0, Y: 585, Width: 520, Height: 455}},
+				{Type: "message", Label: "Room B", Text: "status room b", Area: ImagemapArea{X: 520, Y: 585, Width: 520, Height: 455}},
+			},
+		}},
+		{"text_quick_reply", TextMessage{Text: "Pick an environment", QuickReply: NewQuickReply(
+			NewMessageAction("staging", "deploy staging"),
+			NewMessageAction("production", "deploy production"),
+		)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqs, err := p.Render(context.Background(), tt.payload)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			notifytest.GoldenRequests(t, tt.name, reqs)
+		})
+	}
+}
+
+func TestSendMulticast(t *testing.T) {
+	srv := notifytest.NewLINEServer(t)
+	userIDs := make([]string, 1201)
+	for i := range userIDs {
+		userIDs[i] = fmt.Sprintf("U%d", i)
+	}
+	p := NewMulticast("test-token", userIDs, notify.WithHTTPClient(srv.Client()))
+
+	if err := p.Send(context.Background(), "maintenance tonight"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 3 {
+		t.Fatalf("expected 3 multicast requests, got %d", len(reqs))
+	}
+	var sizes []int
+	for _, req := range reqs {
+		if req.Path != "/v2/bot/message/multicast" {
+			t.Errorf("unexpected path %s", req.Path)
+		}
+		var body struct {
+			To []string `json:"to"`
+		}
+		req.JSON(&body)
+		sizes = append(sizes, len(body.To))
+	}
+	if sizes[0] != 500 || sizes[1] != 500 || sizes[2] != 201 {
+		t.Errorf("unexpected chunk sizes %v", sizes)
+	}
+}
+
+func TestRetryKey(t *testing.T) {
+	srv := notifytest.NewLINEServer(t)
+	p := NewNarrowcast("test-token", Narrowcast{}, notify.WithHTTPClient(srv.Client()), WithRetry(3, time.Millisecond))
+
+	key := NewRetryKey()
+	ctx := ContextWithRetryKey(context.Background(), key)
+	srv.Fail(http.StatusServiceUnavailable, "Service Unavailable")
+	requestID, err := p.Narrowcast(ctx, "hello")
+	if err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+	reqs := srv.Requests()
+	if len(reqs) != 2 || reqs[0].Header.Get("X-Line-Retry-Key") != key || reqs[1].Header.Get("X-Line-Retry-Key") != key {
+		t.Fatalf("expected 2 requests with retry key %s, got %d", key, len(reqs))
+	}
+
+	// Retrying the same message reports the original request as accepted.
+	again, err := p.Narrowcast(ctx, "hello")
+	if err != nil || again != requestID {
+		t.Errorf("expected 409 to return request ID %q, got %q, %v", requestID, again, err)
+	}
+
+	srv.Reset()
+	srv.Fail(http.StatusBadRequest, "The request body has 1 error(s)")
+	if _, err := p.Narrowcast(context.Background(), "hello"); err == nil {
+		t.Errorf("expected 400 to fail")
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Errorf("expected 400 not to be retried, got %d requests", n)
+	}
+
+	srv.Reset()
+	userIDs := make([]string, 501)
+	for i := range userIDs {
+		userIDs[i] = fmt.Sprintf("U%d", i)
+	}
+	m := NewMulticast("test-token", userIDs, notify.WithHTTPClient(srv.Client()))
+	if err := m.Send(ctx, "hello"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := m.Send(context.Background(), "hello"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	keys := map[string]bool{}
+	for _, req := range srv.Requests() {
+		k := req.Header.Get("X-Line-Retry-Key")
+		if len(k) != 36 || keys[k] {
+			t.Errorf("expected a distinct UUID retry key per request, got %q", k)
+		}
+		keys[k] = true
+	}
+	if !keys[key] {
+		t.Errorf("expected the first chunk to use the supplied key")
+	}
+}
+
+func TestQuota(t *testing.T) {
+	srv := notifytest.NewLINEServer(t)
+	clock := notifytest.NewClock(time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC))
+	var exceeded []QuotaUsage
+	p := New("test-token", "U1", notify.WithHTTPClient(srv.Client()), WithClock(clock), WithQuotaGuard(QuotaGuard{
+		Threshold:  0.9,
+		Block:      true,
+		OnExceeded: func(u QuotaUsage) { exceeded = append(exceeded, u) },
+	}))
+
+	srv.Respond(http.StatusOK, `{"type":"limited","value":1000}`)
+	srv.Respond(http.StatusOK, `{"totalUsage":950}`)
+	if err := p.Send(context.Background(), "newsletter"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+	if len(exceeded) != 1 || exceeded[0].Fraction() != 0.95 {
+		t.Errorf("expected OnExceeded once at 95%%, got %+v", exceeded)
+	}
+
+	critical := notify.CommonMessage{Content: "database down", Severity: notify.SeverityCritical}
+	if err := p.Send(context.Background(), critical); err != nil {
+		t.Errorf("expected critical message to bypass the guard, got %v", err)
+	}
+	if reqs := srv.Requests(); len(reqs) != 3 || reqs[2].Path != "/v2/bot/message/push" {
+		t.Errorf("expected cached quota and one push, got %d requests", len(reqs))
+	}
+	if len(exceeded) != 1 {
+		t.Errorf("expected OnExceeded not to fire again, got %d calls", len(exceeded))
+	}
+	if err := p.Send(notify.ContextWithSeverity(context.Background(), notify.SeverityCritical), "disk full"); err != nil {
+		t.Errorf("expected critical context to bypass the guard, got %v", err)
+	}
+
+	// A failed fetch lets messages through and is not retried until the cache expires.
+	srv.Reset()
+	clock.Advance(5 * time.Minute)
+	srv.Fail(http.StatusInternalServerError, "Internal Server Error")
+	for range 2 {
+		if err := p.Send(context.Background(), "newsletter"); err != nil {
+			t.Errorf("expected send after failed quota fetch, got %v", err)
+		}
+	}
+	if reqs := srv.Requests(); len(reqs) != 3 || reqs[0].Path != "/v2/bot/message/quota" {
+		t.Errorf("expected one quota fetch and two pushes, got %d requests", len(reqs))
+	}
+
+	srv.Reset()
+	clock.Advance(5 * time.Minute)
+	srv.Respond(http.StatusOK, `{"type":"limited","value":1000}`)
+	srv.Respond(http.StatusOK, `{"totalUsage":990}`)
+	if err := p.Send(context.Background(), "newsletter"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded after refetch, got %v", err)
+	}
+
+	srv.Reset()
+	usage, err := p.QuotaUsage(context.Background())
+	if err != nil || usage.Quota.Type != "limited" || usage.Quota.Value != 300 || usage.Used != 0 {
+		t.Errorf("unexpected usage %+v, %v", usage, err)
+	}
+	date := time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC) // already the 20th in Japan
+	stats, err := p.DeliveryStats(context.Background(), DeliveryMulticast, date)
+	if err != nil || stats.Status != "ready" {
+		t.Errorf("unexpected stats %+v, %v", stats, err)
+	}
+	req := srv.LastRequest(t)
+	if req.Path != "/v2/bot/message/delivery/multicast" || req.Query.Get("date") != "20261020" {
+		t.Errorf("unexpected delivery request %s?%s", req.Path, req.Query.Encode())
+	}
+}
+
+func TestRichMenu(t *testing.T) {
+	srv := notifytest.NewLINEServer(t)
+	p := New("test-token", "U1", notify.WithHTTPClient(srv.Client()))
+	ctx := context.Background()
+
+	menu := RichMenu{
+		Size:        RichMenuSize{Width: 2500, Height: 843},
+		Name:        "main",
+		ChatBarText: "Menu",
+		Areas: []RichMenuArea{
+			{Bounds: RichMenuBounds{Width: 1250, Height: 843}, Action: NewRichMenuSwitchAction("Settings", "settings", "switch")},
+			{Bounds: RichMenuBounds{X: 1250, Width: 1250, Height: 843}, Action: NewPostbackAction("Status", "status")},
+		},
+	}
+	id, err := p.CreateRichMenu(ctx, menu)
+	if err != nil || id == "" {
+		t.Fatalf("CreateRichMenu: %q, %v", id, err)
+	}
+	var created RichMenu
+	srv.LastRequest(t).JSON(&created)
+	if !reflect.DeepEqual(created, menu) {
+		t.Errorf("unexpected rich menu body %+v", created)
+	}
+
+	if err := p.UploadRichMenuImage(ctx, id, "image/png", bytes.NewReader([]byte("png"))); err != nil {
+		t.Fatalf("UploadRichMenuImage: %v", err)
+	}
+	req := srv.LastRequest(t)
+	if req.Host != "api-data.line.me" || req.Path != "/v2/bot/richmenu/"+id+"/content" || req.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("unexpected upload %s%s (%s)", req.Host, req.Path, req.Header.Get("Content-Type"))
+	}
+
+	if err := p.LinkRichMenu(ctx, "U1", id); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.CreateRichMenuAlias(ctx, "main", id); err != nil {
+		t.Fatal(err)
+	}
+	userIDs := make([]string, 501)
+	for i := range userIDs {
+		userIDs[i] = fmt.Sprintf("U%d", i)
+	}
+	if err := p.BulkLinkRichMenu(ctx, id, userIDs); err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, r := range srv.Requests()[2:] {
+		paths = append(paths, r.Method+" "+r.Path)
+	}
+	want := []string{
+		"POST /v2/bot/user/U1/richmenu/" + id,
+		"POST /v2/bot/richmenu/alias",
+		"POST /v2/bot/richmenu/bulk/link",
+		"POST /v2/bot/richmenu/bulk/link",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("expected requests %v, got %v", want, paths)
+	}
+
+	srv.Respond(http.StatusOK, `{"richmenus":[{"richMenuId":"rm-1","size":{"width":2500,"height":843},"name":"main","chatBarText":"Menu","areas":[]}]}`)
+	menus, err := p.RichMenus(ctx)
+	if err != nil || len(menus) != 1 || menus[0].RichMenuID != "rm-1" || menus[0].Size.Height != 843 {
+		t.Errorf("unexpected rich menus %+v, %v", menus, err)
+	}
+
+	srv.Fail(http.StatusNotFound, "Not found")
+	var apiErr *APIError
+	if _, err := p.GetRichMenu(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a 404 APIError, got %v", err)
+	}
 }
 
-type mockTransport struct {
-	roundTrip func(*http.Request) (*http.Response, error)
+func TestNarrowcast(t *testing.T) {
+	srv := notifytest.NewLINEServer(t)
+	clock := notifytest.NewClock(time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC))
+	p := NewNarrowcast("test-token", Narrowcast{
+		Recipient: AudienceRecipient{AudienceGroupID: 5614991017776},
+		Filter: &NarrowcastFilter{Demographic: OperatorFilter{
+			And: []DemographicFilter{
+				AgeFilter{GTE: "age_20", LT: "age_40"},
+				AreaFilter{OneOf: []string{"th_01"}},
+			},
+		}},
+		Limit: &NarrowcastLimit{Max: 100},
+	}, notify.WithHTTPClient(srv.Client()), WithClock(clock))
+
+	reqs, err := p.Render(context.Background(), "promotion")
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	notifytest.GoldenRequests(t, "narrowcast", reqs)
+
+	id, err := p.Narrowcast(context.Background(), "promotion")
+	if err != nil || id == "" {
+		t.Fatalf("expected request ID, got %q, %v", id, err)
+	}
+	srv.Respond(http.StatusOK, `{"phase":"sending"}`)
+	type result struct {
+		progress *NarrowcastProgress
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		progress, err := p.WaitNarrowcast(context.Background(), id, time.Minute)
+		done <- result{progress, err}
+	}()
+	if !clock.BlockUntil(1, time.Second) {
+		t.Fatal("WaitNarrowcast is not waiting on the provider clock")
+	}
+	clock.Advance(time.Minute)
+	res := <-done
+	progress, err := res.progress, res.err
+	if err != nil {
+		t.Fatalf("WaitNarrowcast: %v", err)
+	}
+	if progress.Phase != NarrowcastSucceeded || progress.SuccessCount != 1 {
+		t.Errorf("unexpected progress %+v", progress)
+	}
+	if req := srv.LastRequest(t); req.Query.Get("requestId") != id {
+		t.Errorf("expected progress query for %s, got %v", id, req.Query)
+	}
+
+	if _, err := New("test-token", "U1").Narrowcast(context.Background(), "x"); err == nil {
+		t.Errorf("expected error for push provider")
+	}
+}
+
+func TestReply(t *testing.T) {
+	srv := notifytest.NewLINEServer(t)
+	p := New("test-token", "U1", notify.WithHTTPClient(srv.Client()))
+
+	if err := p.Reply(context.Background(), NewReplyToken("token-1"), notify.CommonMessage{Title: "Status", Content: "All systems operational"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	req := srv.LastRequest(t)
+	var body struct {
+		ReplyToken string `json:"replyToken"`
+	}
+	req.JSON(&body)
+	if req.Path != "/v2/bot/message/reply" || body.ReplyToken != "token-1" {
+		t.Errorf("unexpected reply request %s %s", req.Path, req.Body)
+	}
+
+	srv.Fail(http.StatusBadRequest, "Invalid reply token")
+	err := p.Reply(context.Background(), ReplyToken{Value: "used"}, "hi")
+	var apiErr *APIError
+	if !errors.Is(err, ErrInvalidReplyToken) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected ErrInvalidReplyToken wrapping an APIError, got %v", err)
+	}
+
+	old := ReplyToken{Value: "old", ReceivedAt: time.Now().Add(-2 * ReplyTokenTTL)}
+	if err := p.Reply(context.Background(), old, "late"); !errors.Is(err, ErrReplyTokenExpired) {
+		t.Errorf("expected ErrReplyTokenExpired, got %v", err)
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Errorf("expected expired token to be rejected locally, got %d requests", n)
+	}
+
+	// Expiry follows the provider clock, like the webhook that stamps tokens.
+	clock := notifytest.NewClock(time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC))
+	p = New("test-token", "U1", notify.WithHTTPClient(srv.Client()), WithClock(clock))
+	token := ReplyToken{Value: "token-2", ReceivedAt: clock.Now()}
+	clock.Advance(ReplyTokenTTL + time.Second)
+	if err := p.Reply(context.Background(), token, "late"); !errors.Is(err, ErrReplyTokenExpired) {
+		t.Errorf("expected ErrReplyTokenExpired on the provider clock, got %v", err)
+	}
+}
+
+func TestSendTooManyMessages(t *testing.T) {
+	p := New("test-token", "U1")
+	msgs := make(Messages, 6)
+	for i := range msgs {
+		msgs[i] = StickerMessage{PackageID: "446", StickerID: "1988"}
+	}
+	if _, err := p.Render(context.Background(), msgs); err == nil {
+		t.Errorf("expected error for more than 5 messages")
+	}
 }
 
-func (m *mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	return m.roundTrip(req)
+func TestFlexJSONRoundTrip(t *testing.T) {
+	data, err := os.ReadFile("testdata/flex_simulator.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := UnmarshalFlexContainer(data)
+	if err != nil {
+		t.Fatalf("UnmarshalFlexContainer: %v", err)
+	}
+	carousel, ok := c.(CarouselContainer)
+	if !ok {
+		t.Fatalf("expected CarouselContainer, got %T", c)
+	}
+	hero, ok := carousel.Contents[0].Hero.(VideoComponent)
+	if !ok {
+		t.Fatalf("expected video hero, got %T", carousel.Contents[0].Hero)
+	}
+	if _, ok := hero.AltContent.(ImageComponent); !ok {
+		t.Errorf("expected image altContent, got %T", hero.AltContent)
+	}
+
+	out, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want, got interface{}
+	json.Unmarshal(data, &want)
+	json.Unmarshal(out, &got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\n%s", out)
+	}
+
+	var msg FlexMessage
+	if err := json.Unmarshal([]byte(`{"type":"flex","altText":"Cafe","contents":`+string(data)+`}`), &msg); err != nil {
+		t.Fatalf("FlexMessage.UnmarshalJSON: %v", err)
+	}
+	if _, ok := msg.Contents.(CarouselContainer); !ok || msg.AltText != "Cafe" {
+		t.Errorf("unexpected message %+v", msg)
+	}
+
+	// Heroes set as *ImageComponent, the type Hero used to have, marshal as before.
+	ptr, _ := json.Marshal(BubbleContainer{Type: "bubble", Hero: &ImageComponent{Type: "image", URL: "https://example.com/a.png"}})
+	val, _ := json.Marshal(BubbleContainer{Type: "bubble", Hero: ImageComponent{Type: "image", URL: "https://example.com/a.png"}})
+	if string(ptr) != string(val) {
+		t.Errorf("pointer hero marshals as %s, want %s", ptr, val)
+	}
+	if img := (BubbleContainer{Hero: &ImageComponent{URL: "a"}}).HeroImage(); img == nil || img.URL != "a" {
+		t.Errorf("expected pointer hero image, got %+v", img)
+	}
+	if img := (BubbleContainer{Hero: ImageComponent{URL: "b"}}).HeroImage(); img == nil || img.URL != "b" {
+		t.Errorf("expected value hero image, got %+v", img)
+	}
+	if img := carousel.Contents[0].HeroImage(); img != nil {
+		t.Errorf("expected no image for a video hero, got %+v", img)
+	}
+
+	if _, err := UnmarshalFlexComponent([]byte(`{"type":"spacer"}`)); err == nil {
+		t.Errorf("expected error for unknown component type")
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	srv := notifytest.NewLINEServer(t)
+	p := New("test-token", "U1", notify.WithHTTPClient(srv.Client()))
+
+	var handlerErrs []error
+	wh := NewWebhook("channel-secret", WithWebhookProvider(p), WithWebhookErrorHandler(func(err error) {
+		handlerErrs = append(handlerErrs, err)
+	}))
+
+	var texts, followers, postbacks []string
+	var types []EventType
+	wh.OnMessage(func(ctx context.Context, e *MessageEvent) error {
+		if m, ok := e.Message.(*TextMessageContent); ok {
+			texts = append(texts, m.Text)
+			return e.Reply(ctx, "pong")
+		}
+		return nil
+	})
+	wh.OnFollow(func(ctx context.Context, e *FollowEvent) error {
+		followers = append(followers, e.Source.ID())
+		return nil
+	})
+	wh.OnPostback(func(ctx context.Context, e *PostbackEvent) error {
+		postbacks = append(postbacks, e.Data+" "+e.Params["datetime"])
+		return nil
+	})
+	wh.Handle(EventUnsend, func(ctx context.Context, e Event) error {
+		return e.Base().Reply(ctx, "too late")
+	})
+	wh.HandleAll(func(ctx context.Context, e Event) error {
+		types = append(types, e.Base().Type)
+		return nil
+	})
+
+	body, err := os.ReadFile("testdata/webhook.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac := hmac.New(sha256.New, []byte("channel-secret"))
+	mac.Write(body)
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader(body))
+	req.Header.Set("X-Line-Signature", signature)
+	rec := httptest.NewRecorder()
+	wh.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if !reflect.DeepEqual(texts, []string{"@bot ping"}) {
+		t.Errorf("unexpected texts %v", texts)
+	}
+	if !reflect.DeepEqual(followers, []string{"U2"}) {
+		t.Errorf("unexpected followers %v", followers)
+	}
+	if !reflect.DeepEqual(postbacks, []string{"ack:incident-1 2026-10-19T12:00"}) {
+		t.Errorf("unexpected postbacks %v", postbacks)
+	}
+	wantTypes := []EventType{EventMessage, EventMessage, EventFollow, EventPostback, EventMemberJoined, EventUnsend, "somethingNew"}
+	if !reflect.DeepEqual(types, wantTypes) {
+		t.Errorf("expected event types %v, got %v", wantTypes, types)
+	}
+	if len(handlerErrs) != 1 {
+		t.Errorf("expected the unsend reply to fail without a reply token, got %v", handlerErrs)
+	}
+
+	var reply struct {
+		ReplyToken string `json:"replyToken"`
+	}
+	srv.LastRequest(t).JSON(&reply)
+	if reply.ReplyToken != "reply-1" {
+		t.Errorf("expected reply with reply-1, got %q", reply.ReplyToken)
+	}
+
+	events, err := ParseEvents(body, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, ok := events[1].(*MessageEvent).Message.(*ImageMessageContent)
+	if !ok || img.ImageSet == nil || img.ImageSet.Total != 2 || !events[1].Base().IsRedelivery || events[1].Base().Source.ID() != "C1" {
+		t.Errorf("unexpected image event %+v", events[1])
+	}
+	if joined := events[4].(*MemberJoinedEvent); len(joined.Members) != 1 || joined.Members[0].UserID != "U3" {
+		t.Errorf("unexpected memberJoined event %+v", joined)
+	}
+	if unsend := events[5].(*UnsendEvent); unsend.MessageID != "100" {
+		t.Errorf("unexpected unsend event %+v", unsend)
+	}
+	if _, ok := events[6].(*UnknownEvent); !ok {
+		t.Errorf("expected UnknownEvent, got %T", events[6])
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader(body))
+	req.Header.Set("X-Line-Signature", base64.StdEncoding.EncodeToString([]byte("forged")))
+	rec = httptest.NewRecorder()
+	wh.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a bad signature, got %d", rec.Code)
+	}
+
+	// Without a secret anyone could sign, so requests are refused.
+	unsigned := hmac.New(sha256.New, nil)
+	unsigned.Write(body)
+	req = httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader(body))
+	req.Header.Set("X-Line-Signature", base64.StdEncoding.EncodeToString(unsigned.Sum(nil)))
+	rec = httptest.NewRecorder()
+	NewWebhook("").ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 without a channel secret, got %d", rec.Code)
+	}
 }
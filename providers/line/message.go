@@ -0,0 +1,338 @@
+package line
+
+import "encoding/json"
+
+// Message is a LINE message object accepted by Send: TextMessage,
+// ImageMessage, StickerMessage, VideoMessage, AudioMessage, LocationMessage,
+// ImagemapMessage, TemplateMessage or FlexMessage. The message type is added
+// when the message is marshalled.
+type Message interface {
+	isMessage()
+}
+
+// Messages sends up to 5 messages of any type in a single Send call.
+type Messages []Message
+
+// TextMessage represents a text message.
+type TextMessage struct {
+	Text       string      `json:"text"`
+	QuickReply *QuickReply `json:"quickReply,omitempty"`
+}
+
+func (m TextMessage) isMessage() {}
+
+// MarshalJSON implements json.Marshaler.
+func (m TextMessage) MarshalJSON() ([]byte, error) {
+	type message TextMessage
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		message
+	}{"text", message(m)})
+}
+
+// ImageMessage represents an image message. Both URLs must be HTTPS.
+type ImageMessage struct {
+	OriginalContentURL string      `json:"originalContentUrl"`
+	PreviewImageURL    string      `json:"previewImageUrl"`
+	QuickReply         *QuickReply `json:"quickReply,omitempty"`
+}
+
+func (m ImageMessage) isMessage() {}
+
+// MarshalJSON implements json.Marshaler.
+func (m ImageMessage) MarshalJSON() ([]byte, error) {
+	type message ImageMessage
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		message
+	}{"image", message(m)})
+}
+
+// StickerMessage represents a sticker message. See the LINE sticker list
+// for available package and sticker IDs.
+type StickerMessage struct {
+	PackageID  string      `json:"packageId"`
+	StickerID  string      `json:"stickerId"`
+	QuickReply *QuickReply `json:"quickReply,omitempty"`
+}
+
+func (m StickerMessage) isMessage() {}
+
+// MarshalJSON implements json.Marshaler.
+func (m StickerMessage) MarshalJSON() ([]byte, error) {
+	type message StickerMessage
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		message
+	}{"sticker", message(m)})
+}
+
+// VideoMessage represents an MP4 video message.
+type VideoMessage struct {
+	OriginalContentURL string `json:"originalContentUrl"`
+	PreviewImageURL    string `json:"previewImageUrl"`
+	// TrackingID identifies the video in video viewing complete events.
+	TrackingID string      `json:"trackingId,omitempty"`
+	QuickReply *QuickReply `json:"quickReply,omitempty"`
+}
+
+func (m VideoMessage) isMessage() {}
+
+// MarshalJSON implements json.Marshaler.
+func (m VideoMessage) MarshalJSON() ([]byte, error) {
+	type message VideoMessage
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		message
+	}{"video", message(m)})
+}
+
+// AudioMessage represents an M4A or MP3 audio message. Duration is in milliseconds.
+type AudioMessage struct {
+	OriginalContentURL string      `json:"originalContentUrl"`
+	Duration           int         `json:"duration"`
+	QuickReply         *QuickReply `json:"quickReply,omitempty"`
+}
+
+func (m AudioMessage) isMessage() {}
+
+// MarshalJSON implements json.Marshaler.
+func (m AudioMessage) MarshalJSON() ([]byte, error) {
+	type message AudioMessage
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		message
+	}{"audio", message(m)})
+}
+
+// LocationMessage represents a location message.
+type LocationMessage struct {
+	Title      string      `json:"title"`
+	Address    string      `json:"address"`
+	Latitude   float64     `json:"latitude"`
+	Longitude  float64     `json:"longitude"`
+	QuickReply *QuickReply `json:"quickReply,omitempty"`
+}
+
+func (m LocationMessage) isMessage() {}
+
+// MarshalJSON implements json.Marshaler.
+func (m LocationMessage) MarshalJSON() ([]byte, error) {
+	type message LocationMessage
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		message
+	}{"location", message(m)})
+}
+
+// ImagemapMessage represents an imagemap: an image with tappable areas and
+// an optional video. BaseURL serves the image at widths 240, 300, 460, 700
+// and 1040 as BaseURL + "/" + width.
+type ImagemapMessage struct {
+	BaseURL  string           `json:"baseUrl"`
+	AltText  string           `json:"altText"`
+	BaseSize ImagemapBaseSize `json:"baseSize"`
+	Video    *ImagemapVideo   `json:"video,omitempty"`
+	Actions  []ImagemapAction `json:"actions"`
+	// QuickReply is shown below the imagemap.
+	QuickReply *QuickReply `json:"quickReply,omitempty"`
+}
+
+func (m ImagemapMessage) isMessage() {}
+
+// MarshalJSON implements json.Marshaler.
+func (m ImagemapMessage) MarshalJSON() ([]byte, error) {
+	type message ImagemapMessage
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		message
+	}{"imagemap", message(m)})
+}
+
+// ImagemapBaseSize is the size of the imagemap image when its width is 1040.
+type ImagemapBaseSize struct {
+	Width  int `json:"width"` // 1040
+	Height int `json:"height"`
+}
+
+// ImagemapArea is a rectangle on the 1040 wide imagemap.
+type ImagemapArea struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// ImagemapAction represents a tappable imagemap area.
+type ImagemapAction struct {
+	Type  string       `json:"type"` // "uri", "message", "clipboard"
+	Label string       `json:"label,omitempty"`
+	Area  ImagemapArea `json:"area"`
+	// LinkURI is opened by uri actions.
+	LinkURI string `json:"linkUri,omitempty"`
+	// Text is sent by message actions.
+	Text string `json:"text,omitempty"`
+	// ClipboardText is copied by clipboard actions.
+	ClipboardText string `json:"clipboardText,omitempty"`
+}
+
+// ImagemapVideo plays a video in an area of the imagemap.
+type ImagemapVideo struct {
+	OriginalContentURL string       `json:"originalContentUrl"`
+	PreviewImageURL    string       `json:"previewImageUrl"`
+	Area               ImagemapArea `json:"area"`
+	// ExternalLink is shown after the video has finished.
+	ExternalLink *ImagemapExternalLink `json:"externalLink,omitempty"`
+}
+
+// ImagemapExternalLink is the link shown after an imagemap video.
+type ImagemapExternalLink struct {
+	LinkURI string `json:"linkUri"`
+	Label   string `json:"label"`
+}
+
+// TemplateMessage represents a template message. AltText is shown in
+// notifications and on clients that cannot display templates.
+type TemplateMessage struct {
+	AltText    string      `json:"altText"`
+	Template   Template    `json:"template"`
+	QuickReply *QuickReply `json:"quickReply,omitempty"`
+}
+
+func (m TemplateMessage) isMessage() {}
+
+// MarshalJSON implements json.Marshaler.
+func (m TemplateMessage) MarshalJSON() ([]byte, error) {
+	type message TemplateMessage
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		message
+	}{"template", message(m)})
+}
+
+// Template is the interface for template message layouts (Buttons,
+// Confirm, Carousel, Image Carousel).
+type Template interface {
+	isTemplate()
+}
+
+// ButtonsTemplate represents a Buttons template with up to 4 actions.
+type ButtonsTemplate struct {
+	ThumbnailImageURL    string   `json:"thumbnailImageUrl,omitempty"`
+	ImageAspectRatio     string   `json:"imageAspectRatio,omitempty"` // "rectangle", "square"
+	ImageSize            string   `json:"imageSize,omitempty"`        // "cover", "contain"
+	ImageBackgroundColor string   `json:"imageBackgroundColor,omitempty"`
+	Title                string   `json:"title,omitempty"`
+	Text                 string   `json:"text"`
+	DefaultAction        *Action  `json:"defaultAction,omitempty"`
+	Actions              []Action `json:"actions"`
+}
+
+func (t ButtonsTemplate) isTemplate() {}
+
+// MarshalJSON implements json.Marshaler.
+func (t ButtonsTemplate) MarshalJSON() ([]byte, error) {
+	type template ButtonsTemplate
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		template
+	}{"buttons", template(t)})
+}
+
+// ConfirmTemplate represents a Confirm template with exactly 2 actions.
+type ConfirmTemplate struct {
+	Text    string   `json:"text"`
+	Actions []Action `json:"actions"`
+}
+
+func (t ConfirmTemplate) isTemplate() {}
+
+// MarshalJSON implements json.Marshaler.
+func (t ConfirmTemplate) MarshalJSON() ([]byte, error) {
+	type template ConfirmTemplate
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		template
+	}{"confirm", template(t)})
+}
+
+// CarouselTemplate represents a Carousel template with up to 10 columns.
+// Every column must have the same number of actions.
+type CarouselTemplate struct {
+	Columns          []CarouselColumn `json:"columns"`
+	ImageAspectRatio string           `json:"imageAspectRatio,omitempty"`
+	ImageSize        string           `json:"imageSize,omitempty"`
+}
+
+func (t CarouselTemplate) isTemplate() {}
+
+// MarshalJSON implements json.Marshaler.
+func (t CarouselTemplate) MarshalJSON() ([]byte, error) {
+	type template CarouselTemplate
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		template
+	}{"carousel", template(t)})
+}
+
+// CarouselColumn represents a column of a Carousel template.
+type CarouselColumn struct {
+	ThumbnailImageURL    string   `json:"thumbnailImageUrl,omitempty"`
+	ImageBackgroundColor string   `json:"imageBackgroundColor,omitempty"`
+	Title                string   `json:"title,omitempty"`
+	Text                 string   `json:"text"`
+	DefaultAction        *Action  `json:"defaultAction,omitempty"`
+	Actions              []Action `json:"actions"`
+}
+
+// ImageCarouselTemplate represents an Image Carousel template with up to 10 columns.
+type ImageCarouselTemplate struct {
+	Columns []ImageCarouselColumn `json:"columns"`
+}
+
+func (t ImageCarouselTemplate) isTemplate() {}
+
+// MarshalJSON implements json.Marshaler.
+func (t ImageCarouselTemplate) MarshalJSON() ([]byte, error) {
+	type template ImageCarouselTemplate
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		template
+	}{"image_carousel", template(t)})
+}
+
+// ImageCarouselColumn represents a column of an Image Carousel template.
+type ImageCarouselColumn struct {
+	ImageURL string `json:"imageUrl"`
+	Action   Action `json:"action"`
+}
+
+// QuickReply holds up to 13 buttons shown at the bottom of the chat.
+type QuickReply struct {
+	Items []QuickReplyItem `json:"items"`
+}
+
+// NewQuickReply returns a QuickReply with one button per action.
+func NewQuickReply(actions ...Action) *QuickReply {
+	q := &QuickReply{}
+	for _, a := range actions {
+		q.Items = append(q.Items, QuickReplyItem{Action: a})
+	}
+	return q
+}
+
+// QuickReplyItem represents a quick reply button.
+type QuickReplyItem struct {
+	// ImageURL is an optional HTTPS PNG icon.
+	ImageURL string `json:"imageUrl,omitempty"`
+	Action   Action `json:"action"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (i QuickReplyItem) MarshalJSON() ([]byte, error) {
+	type item QuickReplyItem
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		item
+	}{"action", item(i)})
+}
@@ -0,0 +1,185 @@
+package line
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/thanpawatpiti/notify"
+)
+
+// ErrQuotaExceeded is returned by Send when a QuotaGuard with Block refuses
+// a non-critical message.
+var ErrQuotaExceeded = errors.New("line message quota threshold exceeded")
+
+// lineTimezone is the time zone LINE uses for daily statistics.
+var lineTimezone = time.FixedZone("JST", 9*60*60)
+
+// Quota is the monthly message quota of the LINE Official Account.
+type Quota struct {
+	// Type is "limited", or "none" when the plan has no limit.
+	Type string `json:"type"`
+	// Value is the number of messages per month for limited quotas.
+	Value int64 `json:"value"`
+}
+
+// QuotaUsage is the quota together with the messages sent this month.
+type QuotaUsage struct {
+	Quota Quota
+	// Used is the number of messages sent this month; replies are not counted.
+	Used int64
+}
+
+// Fraction returns Used as a fraction of the quota, or 0 without a limit.
+func (u QuotaUsage) Fraction() float64 {
+	if u.Quota.Type != "limited" || u.Quota.Value <= 0 {
+		return 0
+	}
+	return float64(u.Used) / float64(u.Quota.Value)
+}
+
+// DeliveryKind selects the messages counted by DeliveryStats.
+type DeliveryKind string
+
+const (
+	DeliveryPush      DeliveryKind = "push"
+	DeliveryMulticast DeliveryKind = "multicast"
+	DeliveryBroadcast DeliveryKind = "broadcast"
+	DeliveryReply     DeliveryKind = "reply"
+)
+
+// DeliveryStats is the number of messages sent on a day.
+type DeliveryStats struct {
+	// Status is "ready", "unready" while LINE is still counting, or
+	// "out_of_service" for dates before statistics were collected.
+	Status  string `json:"status"`
+	Success int64  `json:"success"`
+}
+
+// Quota returns the monthly message quota.
+func (p *Provider) Quota(ctx context.Context) (*Quota, error) {
+	var q Quota
+	if err := p.call(ctx, http.MethodGet, lineAPIBase+"/message/quota", nil, &q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// QuotaConsumption returns the number of messages sent this month.
+func (p *Provider) QuotaConsumption(ctx context.Context) (int64, error) {
+	var c struct {
+		TotalUsage int64 `json:"totalUsage"`
+	}
+	if err := p.call(ctx, http.MethodGet, lineAPIBase+"/message/quota/consumption", nil, &c); err != nil {
+		return 0, err
+	}
+	return c.TotalUsage, nil
+}
+
+// QuotaUsage returns the quota and the messages sent this month.
+func (p *Provider) QuotaUsage(ctx context.Context) (*QuotaUsage, error) {
+	q, err := p.Quota(ctx)
+	if err != nil {
+		return nil, err
+	}
+	used, err := p.QuotaConsumption(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &QuotaUsage{Quota: *q, Used: used}, nil
+}
+
+// DeliveryStats returns the number of messages of kind sent on date. Days
+// are counted in Japan time, as by LINE.
+func (p *Provider) DeliveryStats(ctx context.Context, kind DeliveryKind, date time.Time) (*DeliveryStats, error) {
+	var s DeliveryStats
+	url := fmt.Sprintf("%s/message/delivery/%s?date=%s", lineAPIBase, kind, date.In(lineTimezone).Format("20060102"))
+	if err := p.call(ctx, http.MethodGet, url, nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// QuotaGuard watches quota consumption before each Send. Reply is never
+// guarded, since replies are free.
+type QuotaGuard struct {
+	// Threshold is the fraction of the quota, e.g. 0.9, at which the guard trips.
+	Threshold float64
+	// Block refuses messages below notify.SeverityCritical with
+	// ErrQuotaExceeded once the guard has tripped. The severity is taken
+	// from notify.SeverityOf, so other payloads can be marked critical
+	// with notify.ContextWithSeverity.
+	Block bool
+	// OnExceeded is called when consumption first reaches Threshold.
+	OnExceeded func(QuotaUsage)
+	// CacheFor is how long quota figures are reused between checks.
+	// Defaults to 5 minutes.
+	CacheFor time.Duration
+}
+
+// WithQuotaGuard configures a QuotaGuard. If the quota cannot be fetched,
+// messages are sent anyway and the fetch is not retried for CacheFor.
+func WithQuotaGuard(g QuotaGuard) notify.Option {
+	if g.CacheFor <= 0 {
+		g.CacheFor = 5 * time.Minute
+	}
+	return notify.ProviderOption(func(o *options) {
+		o.quotaGuard = &g
+	})
+}
+
+// quotaState caches the usage fetched by the QuotaGuard.
+type quotaState struct {
+	mu        sync.Mutex
+	usage     *QuotaUsage
+	err       error // the last fetch failed; messages are not guarded
+	fetchedAt time.Time
+	exceeded  bool
+}
+
+// checkQuota applies the configured QuotaGuard to payload.
+func (p *Provider) checkQuota(ctx context.Context, payload interface{}) error {
+	g := p.config.quotaGuard
+	if g == nil {
+		return nil
+	}
+	clock := p.config.clock
+
+	p.quota.mu.Lock()
+	stale := p.quota.fetchedAt.IsZero() || clock.Now().Sub(p.quota.fetchedAt) >= g.CacheFor
+	p.quota.mu.Unlock()
+
+	if stale {
+		usage, err := p.QuotaUsage(ctx)
+		if err != nil && ctx.Err() != nil {
+			return nil // the caller gave up; don't cache its failure
+		}
+
+		p.quota.mu.Lock()
+		p.quota.usage, p.quota.err, p.quota.fetchedAt = usage, err, clock.Now()
+		tripped := false
+		if err == nil {
+			exceeded := usage.Fraction() >= g.Threshold && usage.Quota.Type == "limited"
+			tripped = exceeded && !p.quota.exceeded
+			p.quota.exceeded = exceeded
+		}
+		p.quota.mu.Unlock()
+
+		if tripped && g.OnExceeded != nil {
+			g.OnExceeded(*usage)
+		}
+	}
+
+	p.quota.mu.Lock()
+	defer p.quota.mu.Unlock()
+	if p.quota.err != nil || !g.Block || !p.quota.exceeded {
+		return nil
+	}
+	if notify.SeverityOf(ctx, payload) >= notify.SeverityCritical {
+		return nil
+	}
+	return fmt.Errorf("%w: %d of %d messages used", ErrQuotaExceeded, p.quota.usage.Used, p.quota.usage.Quota.Value)
+}
@@ -0,0 +1,283 @@
+package line
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// maxMulticastRecipients is the number of user IDs the multicast endpoint
+// accepts per request.
+const maxMulticastRecipients = 500
+
+// Recipient selects who receives the messages sent by a Provider:
+// Push, Multicast, Broadcast or Narrowcast.
+type Recipient interface {
+	// requests returns the endpoint path and one request body per API call.
+	requests(messages []interface{}) (string, []interface{}, error)
+}
+
+// Push sends to a single user, group or room ID.
+type Push string
+
+func (r Push) requests(messages []interface{}) (string, []interface{}, error) {
+	if r == "" {
+		return "", nil, fmt.Errorf("line target ID is missing")
+	}
+	return "/message/push", []interface{}{map[string]interface{}{
+		"to":       string(r),
+		"messages": messages,
+	}}, nil
+}
+
+// Multicast sends to a list of user IDs. Lists longer than 500 IDs are
+// split into several requests.
+type Multicast []string
+
+func (r Multicast) requests(messages []interface{}) (string, []interface{}, error) {
+	if len(r) == 0 {
+		return "", nil, fmt.Errorf("line multicast has no recipients")
+	}
+	var bodies []interface{}
+	for start := 0; start < len(r); start += maxMulticastRecipients {
+		end := start + maxMulticastRecipients
+		if end > len(r) {
+			end = len(r)
+		}
+		bodies = append(bodies, map[string]interface{}{
+			"to":       []string(r[start:end]),
+			"messages": messages,
+		})
+	}
+	return "/message/multicast", bodies, nil
+}
+
+// Broadcast sends to every user who added the LINE Official Account as a friend.
+type Broadcast struct{}
+
+func (Broadcast) requests(messages []interface{}) (string, []interface{}, error) {
+	return "/message/broadcast", []interface{}{map[string]interface{}{
+		"messages": messages,
+	}}, nil
+}
+
+// Narrowcast sends to a subset of friends selected by audience and
+// demographic filters. Delivery is asynchronous; use Provider.Narrowcast to
+// get the request ID and Provider.NarrowcastProgress to follow it.
+type Narrowcast struct {
+	// Recipient selects audiences; nil means all friends.
+	Recipient RecipientObject `json:"recipient,omitempty"`
+	// Filter narrows the recipients by demographics.
+	Filter *NarrowcastFilter `json:"filter,omitempty"`
+	// Limit caps the number of recipients.
+	Limit *NarrowcastLimit `json:"limit,omitempty"`
+}
+
+func (r Narrowcast) requests(messages []interface{}) (string, []interface{}, error) {
+	return "/message/narrowcast", []interface{}{struct {
+		Messages []interface{} `json:"messages"`
+		Narrowcast
+	}{messages, r}}, nil
+}
+
+// RecipientObject is a narrowcast recipient: AudienceRecipient,
+// RedeliveryRecipient or an OperatorRecipient combining them.
+type RecipientObject interface {
+	isRecipientObject()
+}
+
+// AudienceRecipient selects the users of an audience group.
+type AudienceRecipient struct {
+	AudienceGroupID int64 `json:"audienceGroupId"`
+}
+
+func (r AudienceRecipient) isRecipientObject() {}
+
+// MarshalJSON implements json.Marshaler.
+func (r AudienceRecipient) MarshalJSON() ([]byte, error) {
+	type recipient AudienceRecipient
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		recipient
+	}{"audience", recipient(r)})
+}
+
+// RedeliveryRecipient selects the recipients of a previous narrowcast.
+type RedeliveryRecipient struct {
+	RequestID string `json:"requestId"`
+}
+
+func (r RedeliveryRecipient) isRecipientObject() {}
+
+// MarshalJSON implements json.Marshaler.
+func (r RedeliveryRecipient) MarshalJSON() ([]byte, error) {
+	type recipient RedeliveryRecipient
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		recipient
+	}{"redelivery", recipient(r)})
+}
+
+// OperatorRecipient combines recipient objects. Set exactly one of And, Or and Not.
+type OperatorRecipient struct {
+	And []RecipientObject `json:"and,omitempty"`
+	Or  []RecipientObject `json:"or,omitempty"`
+	Not RecipientObject   `json:"not,omitempty"`
+}
+
+func (r OperatorRecipient) isRecipientObject() {}
+
+// MarshalJSON implements json.Marshaler.
+func (r OperatorRecipient) MarshalJSON() ([]byte, error) {
+	type recipient OperatorRecipient
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		recipient
+	}{"operator", recipient(r)})
+}
+
+// NarrowcastFilter holds the demographic filter of a narrowcast.
+type NarrowcastFilter struct {
+	Demographic DemographicFilter `json:"demographic"`
+}
+
+// DemographicFilter is a narrowcast demographic filter: GenderFilter,
+// AgeFilter, AppTypeFilter, AreaFilter, SubscriptionPeriodFilter or an
+// OperatorFilter combining them.
+type DemographicFilter interface {
+	isDemographicFilter()
+}
+
+// GenderFilter selects users by gender ("male", "female").
+type GenderFilter struct {
+	OneOf []string `json:"oneOf"`
+}
+
+func (f GenderFilter) isDemographicFilter() {}
+
+// MarshalJSON implements json.Marshaler.
+func (f GenderFilter) MarshalJSON() ([]byte, error) {
+	type filter GenderFilter
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		filter
+	}{"gender", filter(f)})
+}
+
+// AgeFilter selects users by age range, e.g. GTE "age_20" and LT "age_40".
+type AgeFilter struct {
+	GTE string `json:"gte,omitempty"`
+	LT  string `json:"lt,omitempty"`
+}
+
+func (f AgeFilter) isDemographicFilter() {}
+
+// MarshalJSON implements json.Marshaler.
+func (f AgeFilter) MarshalJSON() ([]byte, error) {
+	type filter AgeFilter
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		filter
+	}{"age", filter(f)})
+}
+
+// AppTypeFilter selects users by OS ("ios", "android").
+type AppTypeFilter struct {
+	OneOf []string `json:"oneOf"`
+}
+
+func (f AppTypeFilter) isDemographicFilter() {}
+
+// MarshalJSON implements json.Marshaler.
+func (f AppTypeFilter) MarshalJSON() ([]byte, error) {
+	type filter AppTypeFilter
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		filter
+	}{"appType", filter(f)})
+}
+
+// AreaFilter selects users by region code, e.g. "th_01" for Bangkok.
+type AreaFilter struct {
+	OneOf []string `json:"oneOf"`
+}
+
+func (f AreaFilter) isDemographicFilter() {}
+
+// MarshalJSON implements json.Marshaler.
+func (f AreaFilter) MarshalJSON() ([]byte, error) {
+	type filter AreaFilter
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		filter
+	}{"area", filter(f)})
+}
+
+// SubscriptionPeriodFilter selects users by friendship duration, e.g. GTE "day_7".
+type SubscriptionPeriodFilter struct {
+	GTE string `json:"gte,omitempty"`
+	LT  string `json:"lt,omitempty"`
+}
+
+func (f SubscriptionPeriodFilter) isDemographicFilter() {}
+
+// MarshalJSON implements json.Marshaler.
+func (f SubscriptionPeriodFilter) MarshalJSON() ([]byte, error) {
+	type filter SubscriptionPeriodFilter
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		filter
+	}{"subscriptionPeriod", filter(f)})
+}
+
+// OperatorFilter combines demographic filters. Set exactly one of And, Or and Not.
+type OperatorFilter struct {
+	And []DemographicFilter `json:"and,omitempty"`
+	Or  []DemographicFilter `json:"or,omitempty"`
+	Not DemographicFilter   `json:"not,omitempty"`
+}
+
+func (f OperatorFilter) isDemographicFilter() {}
+
+// MarshalJSON implements json.Marshaler.
+func (f OperatorFilter) MarshalJSON() ([]byte, error) {
+	type filter OperatorFilter
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		filter
+	}{"operator", filter(f)})
+}
+
+// NarrowcastLimit caps the number of narrowcast recipients.
+type NarrowcastLimit struct {
+	Max int `json:"max,omitempty"`
+	// UpToRemainingQuota limits the recipients to the remaining message quota.
+	UpToRemainingQuota bool `json:"upToRemainingQuota,omitempty"`
+}
+
+// NarrowcastPhase is the delivery phase of a narrowcast.
+type NarrowcastPhase string
+
+const (
+	NarrowcastWaiting   NarrowcastPhase = "waiting"
+	NarrowcastSending   NarrowcastPhase = "sending"
+	NarrowcastSucceeded NarrowcastPhase = "succeeded"
+	NarrowcastFailed    NarrowcastPhase = "failed"
+)
+
+// NarrowcastProgress is the delivery status of a narrowcast.
+type NarrowcastProgress struct {
+	Phase             NarrowcastPhase `json:"phase"`
+	SuccessCount      int64           `json:"successCount"`
+	FailureCount      int64           `json:"failureCount"`
+	TargetCount       int64           `json:"targetCount"`
+	FailedDescription string          `json:"failedDescription,omitempty"`
+	ErrorCode         int             `json:"errorCode,omitempty"`
+	AcceptedTime      time.Time       `json:"acceptedTime"`
+	CompletedTime     time.Time       `json:"completedTime"`
+}
+
+// Done reports whether the narrowcast has finished, successfully or not.
+func (p *NarrowcastProgress) Done() bool {
+	return p.Phase == NarrowcastSucceeded || p.Phase == NarrowcastFailed
+}
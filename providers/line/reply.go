@@ -0,0 +1,82 @@
+package line
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ReplyTokenTTL is how long after the webhook event a reply token can be used.
+const ReplyTokenTTL = time.Minute
+
+var (
+	// ErrReplyTokenExpired is returned by Reply for tokens older than ReplyTokenTTL.
+	ErrReplyTokenExpired = errors.New("line reply token expired")
+	// ErrInvalidReplyToken is returned by Reply when LINE rejects the token
+	// because it was already used, has expired or never existed.
+	ErrInvalidReplyToken = errors.New("line reply token is invalid")
+)
+
+// ReplyToken is the reply token of a webhook event. Each token can be used
+// once, shortly after the event.
+type ReplyToken struct {
+	Value string
+	// ReceivedAt is when the webhook event arrived. When set, Reply fails
+	// with ErrReplyTokenExpired once ReplyTokenTTL has passed.
+	ReceivedAt time.Time
+}
+
+// NewReplyToken returns a ReplyToken received now.
+func NewReplyToken(value string) ReplyToken {
+	return ReplyToken{Value: value, ReceivedAt: time.Now()}
+}
+
+// Expired reports whether the token is older than ReplyTokenTTL.
+// Tokens without ReceivedAt never report as expired.
+func (t ReplyToken) Expired() bool {
+	return t.expiredAt(time.Now())
+}
+
+func (t ReplyToken) expiredAt(now time.Time) bool {
+	return !t.ReceivedAt.IsZero() && now.Sub(t.ReceivedAt) > ReplyTokenTTL
+}
+
+// replyRecipient sends messages through the reply endpoint.
+type replyRecipient string
+
+func (r replyRecipient) requests(messages []interface{}) (string, []interface{}, error) {
+	if r == "" {
+		return "", nil, fmt.Errorf("line reply token is missing")
+	}
+	return "/message/reply", []interface{}{map[string]interface{}{
+		"replyToken": string(r),
+		"messages":   messages,
+	}}, nil
+}
+
+// Reply answers a webhook event with payload, which can be any type accepted
+// by Send. Replies do not count towards the monthly message quota. Expiry
+// is judged by the clock set with WithClock.
+func (p *Provider) Reply(ctx context.Context, token ReplyToken, payload interface{}) error {
+	if token.expiredAt(p.config.clock.Now()) {
+		return ErrReplyTokenExpired
+	}
+
+	reqs, err := p.render(ctx, replyRecipient(token.Value), payload)
+	if err != nil {
+		return err
+	}
+
+	for _, req := range reqs {
+		if _, err := p.do(req); err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.Message == "Invalid reply token" {
+				return fmt.Errorf("%w: %w", ErrInvalidReplyToken, err)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
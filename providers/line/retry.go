@@ -0,0 +1,117 @@
+package line
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/thanpawatpiti/notify"
+)
+
+// retryKeyContextKey is the context key of a caller supplied retry key.
+type retryKeyContextKey struct{}
+
+// retryPolicy is set with WithRetry.
+type retryPolicy struct {
+	attempts int
+	backoff  time.Duration
+}
+
+// WithRetry makes Send retry requests that fail with a network error, 429
+// or 5xx response up to attempts times in total, waiting backoff, then
+// twice backoff and so on between attempts. Retries reuse the request's
+// X-Line-Retry-Key, so LINE delivers the message at most once.
+func WithRetry(attempts int, backoff time.Duration) notify.Option {
+	return notify.ProviderOption(func(o *options) {
+		o.retry = retryPolicy{attempts: attempts, backoff: backoff}
+	})
+}
+
+// NewRetryKey returns a random UUID for use with ContextWithRetryKey. It panics
+// if the system's random number generator fails.
+func NewRetryKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("line: failed to generate retry key: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return formatUUID(b)
+}
+
+// ContextWithRetryKey returns a context that makes Send use key as the
+// X-Line-Retry-Key of the message. Pass the same key when retrying the same
+// message yourself: LINE rejects a repeated key with 409 Conflict, which Send
+// reports as success with the request ID of the accepted request.
+// Without a key, Send generates one per call. Keys must be UUIDs and are
+// honoured by LINE for 24 hours.
+func ContextWithRetryKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, retryKeyContextKey{}, key)
+}
+
+// retryKey returns the retry key for the i-th request of a message. Without
+// a key in ctx every request gets a new one; otherwise the first request uses
+// it and the following multicast chunks use keys derived from it.
+func retryKey(ctx context.Context, i int) string {
+	key, _ := ctx.Value(retryKeyContextKey{}).(string)
+	if key == "" {
+		return NewRetryKey()
+	}
+	if i == 0 {
+		return key
+	}
+	sum := sha1.Sum([]byte(key + "/" + strconv.Itoa(i)))
+	var b [16]byte
+	copy(b[:], sum[:])
+	b[6] = b[6]&0x0f | 0x50 // version 5
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b)
+}
+
+func formatUUID(b [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// doWithRetry sends req with the configured retry policy.
+func (p *Provider) doWithRetry(req *http.Request) (string, error) {
+	policy := p.config.retry
+
+	for attempt := 1; ; attempt++ {
+		requestID, err := p.do(req)
+		if err == nil || attempt >= policy.attempts || !retryable(req, err) {
+			return requestID, err
+		}
+
+		timer, stop := notify.NewTimer(p.config.clock, policy.backoff*time.Duration(attempt))
+		select {
+		case <-req.Context().Done():
+			stop()
+			return "", err
+		case <-timer:
+		}
+
+		body, err := req.GetBody()
+		if err != nil {
+			return "", fmt.Errorf("failed to rewind request body: %w", err)
+		}
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+}
+
+// retryable reports whether a request that failed with err may be sent again.
+func retryable(req *http.Request, err error) bool {
+	if req.Context().Err() != nil || req.GetBody == nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	return true
+}
@@ -0,0 +1,225 @@
+package line
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+)
+
+// maxBulkRichMenuUsers is the number of user IDs the bulk link and unlink
+// endpoints accept per request.
+const maxBulkRichMenuUsers = 500
+
+// RichMenu is a rich menu definition. The image uploaded with
+// UploadRichMenuImage must match Size.
+type RichMenu struct {
+	// Size is 2500x1686 or 2500x843, or any width of 800 to 2500 with an
+	// aspect ratio of at least 1.45.
+	Size RichMenuSize `json:"size"`
+	// Selected opens the menu by default.
+	Selected bool `json:"selected"`
+	// Name identifies the menu in management tools; it is not shown to users.
+	Name string `json:"name"`
+	// ChatBarText is the label of the menu button in the chat bar.
+	ChatBarText string         `json:"chatBarText"`
+	Areas       []RichMenuArea `json:"areas"`
+}
+
+// RichMenuSize is the size of the rich menu image in pixels.
+type RichMenuSize struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// RichMenuArea is a tappable area of a rich menu.
+type RichMenuArea struct {
+	Bounds RichMenuBounds `json:"bounds"`
+	Action Action         `json:"action"`
+}
+
+// RichMenuBounds is a rectangle on the rich menu image.
+type RichMenuBounds struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// RichMenuInfo is a rich menu stored on the LINE platform.
+type RichMenuInfo struct {
+	RichMenuID string `json:"richMenuId"`
+	RichMenu
+}
+
+// RichMenuAlias names a rich menu for rich menu switch actions.
+type RichMenuAlias struct {
+	RichMenuAliasID string `json:"richMenuAliasId"`
+	RichMenuID      string `json:"richMenuId"`
+}
+
+// CreateRichMenu creates a rich menu and returns its ID. The menu is shown
+// once an image has been uploaded and it is linked or set as default.
+func (p *Provider) CreateRichMenu(ctx context.Context, menu RichMenu) (string, error) {
+	var resp struct {
+		RichMenuID string `json:"richMenuId"`
+	}
+	if err := p.call(ctx, http.MethodPost, lineAPIBase+"/richmenu", menu, &resp); err != nil {
+		return "", err
+	}
+	return resp.RichMenuID, nil
+}
+
+// ValidateRichMenu checks menu without creating it.
+func (p *Provider) ValidateRichMenu(ctx context.Context, menu RichMenu) error {
+	return p.call(ctx, http.MethodPost, lineAPIBase+"/richmenu/validate", menu, nil)
+}
+
+// GetRichMenu returns the rich menu with id.
+func (p *Provider) GetRichMenu(ctx context.Context, id string) (*RichMenuInfo, error) {
+	var menu RichMenuInfo
+	if err := p.call(ctx, http.MethodGet, lineAPIBase+"/richmenu/"+neturl.PathEscape(id), nil, &menu); err != nil {
+		return nil, err
+	}
+	return &menu, nil
+}
+
+// RichMenus returns all rich menus created through the API.
+func (p *Provider) RichMenus(ctx context.Context) ([]RichMenuInfo, error) {
+	var resp struct {
+		RichMenus []RichMenuInfo `json:"richmenus"`
+	}
+	if err := p.call(ctx, http.MethodGet, lineAPIBase+"/richmenu/list", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.RichMenus, nil
+}
+
+// DeleteRichMenu deletes the rich menu with id.
+func (p *Provider) DeleteRichMenu(ctx context.Context, id string) error {
+	return p.call(ctx, http.MethodDelete, lineAPIBase+"/richmenu/"+neturl.PathEscape(id), nil, nil)
+}
+
+// UploadRichMenuImage uploads the image of a rich menu. contentType is
+// "image/png" or "image/jpeg"; the image must be at most 1 MB. An image can
+// only be uploaded once per menu.
+func (p *Provider) UploadRichMenuImage(ctx context.Context, id, contentType string, image io.Reader) error {
+	req, err := p.newRequest(ctx, http.MethodPost, lineDataAPIBase+"/richmenu/"+neturl.PathEscape(id)+"/content", image)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return p.decode(req, nil)
+}
+
+// SetDefaultRichMenu shows the rich menu with id to users without a linked menu.
+func (p *Provider) SetDefaultRichMenu(ctx context.Context, id string) error {
+	return p.call(ctx, http.MethodPost, lineAPIBase+"/user/all/richmenu/"+neturl.PathEscape(id), nil, nil)
+}
+
+// DefaultRichMenu returns the ID of the default rich menu.
+func (p *Provider) DefaultRichMenu(ctx context.Context) (string, error) {
+	return p.richMenuID(ctx, lineAPIBase+"/user/all/richmenu")
+}
+
+// CancelDefaultRichMenu removes the default rich menu.
+func (p *Provider) CancelDefaultRichMenu(ctx context.Context) error {
+	return p.call(ctx, http.MethodDelete, lineAPIBase+"/user/all/richmenu", nil, nil)
+}
+
+// LinkRichMenu shows the rich menu with id to userID instead of the default menu.
+func (p *Provider) LinkRichMenu(ctx context.Context, userID, id string) error {
+	return p.call(ctx, http.MethodPost, lineAPIBase+"/user/"+neturl.PathEscape(userID)+"/richmenu/"+neturl.PathEscape(id), nil, nil)
+}
+
+// UnlinkRichMenu removes the rich menu linked to userID.
+func (p *Provider) UnlinkRichMenu(ctx context.Context, userID string) error {
+	return p.call(ctx, http.MethodDelete, lineAPIBase+"/user/"+neturl.PathEscape(userID)+"/richmenu", nil, nil)
+}
+
+// UserRichMenu returns the ID of the rich menu linked to userID.
+func (p *Provider) UserRichMenu(ctx context.Context, userID string) (string, error) {
+	return p.richMenuID(ctx, lineAPIBase+"/user/"+neturl.PathEscape(userID)+"/richmenu")
+}
+
+// BulkLinkRichMenu links the rich menu with id to userIDs. Lists longer than
+// 500 IDs are split into several requests. Linking is asynchronous.
+func (p *Provider) BulkLinkRichMenu(ctx context.Context, id string, userIDs []string) error {
+	return p.bulkRichMenu(ctx, "/richmenu/bulk/link", id, userIDs)
+}
+
+// BulkUnlinkRichMenu removes the rich menus linked to userIDs. Lists longer
+// than 500 IDs are split into several requests.
+func (p *Provider) BulkUnlinkRichMenu(ctx context.Context, userIDs []string) error {
+	return p.bulkRichMenu(ctx, "/richmenu/bulk/unlink", "", userIDs)
+}
+
+func (p *Provider) bulkRichMenu(ctx context.Context, path, id string, userIDs []string) error {
+	if len(userIDs) == 0 {
+		return fmt.Errorf("line rich menu bulk request has no users")
+	}
+	for start := 0; start < len(userIDs); start += maxBulkRichMenuUsers {
+		end := start + maxBulkRichMenuUsers
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+		body := struct {
+			RichMenuID string   `json:"richMenuId,omitempty"`
+			UserIDs    []string `json:"userIds"`
+		}{id, userIDs[start:end]}
+		if err := p.call(ctx, http.MethodPost, lineAPIBase+path, body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateRichMenuAlias names the rich menu with id aliasID.
+func (p *Provider) CreateRichMenuAlias(ctx context.Context, aliasID, id string) error {
+	return p.call(ctx, http.MethodPost, lineAPIBase+"/richmenu/alias", RichMenuAlias{RichMenuAliasID: aliasID, RichMenuID: id}, nil)
+}
+
+// UpdateRichMenuAlias points aliasID at the rich menu with id.
+func (p *Provider) UpdateRichMenuAlias(ctx context.Context, aliasID, id string) error {
+	body := struct {
+		RichMenuID string `json:"richMenuId"`
+	}{id}
+	return p.call(ctx, http.MethodPost, lineAPIBase+"/richmenu/alias/"+neturl.PathEscape(aliasID), body, nil)
+}
+
+// DeleteRichMenuAlias deletes aliasID. The rich menu itself is kept.
+func (p *Provider) DeleteRichMenuAlias(ctx context.Context, aliasID string) error {
+	return p.call(ctx, http.MethodDelete, lineAPIBase+"/richmenu/alias/"+neturl.PathEscape(aliasID), nil, nil)
+}
+
+// GetRichMenuAlias returns aliasID.
+func (p *Provider) GetRichMenuAlias(ctx context.Context, aliasID string) (*RichMenuAlias, error) {
+	var alias RichMenuAlias
+	if err := p.call(ctx, http.MethodGet, lineAPIBase+"/richmenu/alias/"+neturl.PathEscape(aliasID), nil, &alias); err != nil {
+		return nil, err
+	}
+	return &alias, nil
+}
+
+// RichMenuAliases returns all rich menu aliases.
+func (p *Provider) RichMenuAliases(ctx context.Context) ([]RichMenuAlias, error) {
+	var resp struct {
+		Aliases []RichMenuAlias `json:"aliases"`
+	}
+	if err := p.call(ctx, http.MethodGet, lineAPIBase+"/richmenu/alias/list", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Aliases, nil
+}
+
+// richMenuID fetches a {"richMenuId": ...} response.
+func (p *Provider) richMenuID(ctx context.Context, url string) (string, error) {
+	var resp struct {
+		RichMenuID string `json:"richMenuId"`
+	}
+	if err := p.call(ctx, http.MethodGet, url, nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.RichMenuID, nil
+}
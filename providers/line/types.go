@@ -1,9 +1,48 @@
 package line
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // FlexMessage represents a LINE Flex Message.
 type FlexMessage struct {
-	AltText  string
-	Contents FlexContainer
+	AltText    string        `json:"altText"`
+	Contents   FlexContainer `json:"contents"`
+	QuickReply *QuickReply   `json:"quickReply,omitempty"`
+}
+
+func (m FlexMessage) isMessage() {}
+
+// MarshalJSON implements json.Marshaler.
+func (m FlexMessage) MarshalJSON() ([]byte, error) {
+	type message FlexMessage
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		message
+	}{"flex", message(m)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *FlexMessage) UnmarshalJSON(data []byte) error {
+	type message FlexMessage
+	var raw struct {
+		message
+		Contents json.RawMessage `json:"contents"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = FlexMessage(raw.message)
+	if isNull(raw.Contents) {
+		return nil
+	}
+	c, err := UnmarshalFlexContainer(raw.Contents)
+	if err != nil {
+		return err
+	}
+	m.Contents = c
+	return nil
 }
 
 // FlexContainer is the interface for Flex Message containers (Bubble, Carousel).
@@ -13,17 +52,56 @@ type FlexContainer interface {
 
 // BubbleContainer represents a Bubble container.
 type BubbleContainer struct {
-	Type   string          `json:"type"` // "bubble"
-	Header *BoxComponent   `json:"header,omitempty"`
-	Hero   *ImageComponent `json:"hero,omitempty"`
-	Body   *BoxComponent   `json:"body,omitempty"`
-	Footer *BoxComponent   `json:"footer,omitempty"`
-	Styles *BubbleStyles   `json:"styles,omitempty"`
+	Type      string        `json:"type"`                // "bubble"
+	Size      string        `json:"size,omitempty"`      // "nano", "micro", "deca", "hecto", "kilo", "mega", "giga"
+	Direction string        `json:"direction,omitempty"` // "ltr", "rtl"
+	Header    *BoxComponent `json:"header,omitempty"`
+	// Hero is a BoxComponent, ImageComponent or VideoComponent, as a value
+	// or a pointer. Decoded heroes are values.
+	Hero   FlexComponent `json:"hero,omitempty"`
+	Body   *BoxComponent `json:"body,omitempty"`
+	Footer *BoxComponent `json:"footer,omitempty"`
+	Styles *BubbleStyles `json:"styles,omitempty"`
+	Action *Action       `json:"action,omitempty"`
 }
 
 func (c BubbleContainer) isFlexContainer() {}
 
-// CarouselContainer represents a Carousel container.
+// HeroImage returns the hero if it is an image, given as a value or a
+// pointer, or nil otherwise.
+func (c BubbleContainer) HeroImage() *ImageComponent {
+	switch h := c.Hero.(type) {
+	case ImageComponent:
+		return &h
+	case *ImageComponent:
+		return h
+	}
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *BubbleContainer) UnmarshalJSON(data []byte) error {
+	type bubble BubbleContainer
+	var raw struct {
+		bubble
+		Hero json.RawMessage `json:"hero"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*c = BubbleContainer(raw.bubble)
+	if isNull(raw.Hero) {
+		return nil
+	}
+	hero, err := UnmarshalFlexComponent(raw.Hero)
+	if err != nil {
+		return fmt.Errorf("hero: %w", err)
+	}
+	c.Hero = hero
+	return nil
+}
+
+// CarouselContainer represents a Carousel container of up to 12 bubbles.
 type CarouselContainer struct {
 	Type     string            `json:"type"` // "carousel"
 	Contents []BubbleContainer `json:"contents"`
@@ -45,26 +123,106 @@ type BoxComponent struct {
 	Spacing  string          `json:"spacing,omitempty"`
 	Margin   string          `json:"margin,omitempty"`
 	Action   *Action         `json:"action,omitempty"`
+
+	BackgroundColor string         `json:"backgroundColor,omitempty"`
+	Background      *BoxBackground `json:"background,omitempty"`
+	BorderColor     string         `json:"borderColor,omitempty"`
+	BorderWidth     string         `json:"borderWidth,omitempty"`
+	CornerRadius    string         `json:"cornerRadius,omitempty"`
+	Width           string         `json:"width,omitempty"`
+	MaxWidth        string         `json:"maxWidth,omitempty"`
+	Height          string         `json:"height,omitempty"`
+	MaxHeight       string         `json:"maxHeight,omitempty"`
+	PaddingAll      string         `json:"paddingAll,omitempty"`
+	PaddingTop      string         `json:"paddingTop,omitempty"`
+	PaddingBottom   string         `json:"paddingBottom,omitempty"`
+	PaddingStart    string         `json:"paddingStart,omitempty"`
+	PaddingEnd      string         `json:"paddingEnd,omitempty"`
+	JustifyContent  string         `json:"justifyContent,omitempty"` // "flex-start", "center", "flex-end", "space-between", "space-around", "space-evenly"
+	AlignItems      string         `json:"alignItems,omitempty"`     // "flex-start", "center", "flex-end"
+	Position        string         `json:"position,omitempty"`       // "relative", "absolute"
+	OffsetTop       string         `json:"offsetTop,omitempty"`
+	OffsetBottom    string         `json:"offsetBottom,omitempty"`
+	OffsetStart     string         `json:"offsetStart,omitempty"`
+	OffsetEnd       string         `json:"offsetEnd,omitempty"`
 }
 
 func (c BoxComponent) isFlexComponent() {}
 
-// TextComponent represents a Text component.
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *BoxComponent) UnmarshalJSON(data []byte) error {
+	type box BoxComponent
+	var raw struct {
+		box
+		Contents []json.RawMessage `json:"contents"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*c = BoxComponent(raw.box)
+	c.Contents = make([]FlexComponent, 0, len(raw.Contents))
+	for i, r := range raw.Contents {
+		component, err := UnmarshalFlexComponent(r)
+		if err != nil {
+			return fmt.Errorf("contents[%d]: %w", i, err)
+		}
+		c.Contents = append(c.Contents, component)
+	}
+	return nil
+}
+
+// BoxBackground represents a box background. Only linear gradients are supported.
+type BoxBackground struct {
+	Type           string `json:"type"`            // "linearGradient"
+	Angle          string `json:"angle,omitempty"` // e.g. "90deg"
+	StartColor     string `json:"startColor,omitempty"`
+	EndColor       string `json:"endColor,omitempty"`
+	CenterColor    string `json:"centerColor,omitempty"`
+	CenterPosition string `json:"centerPosition,omitempty"` // e.g. "10%"
+}
+
+// TextComponent represents a Text component. Contents, when set, replaces
+// Text with styled spans.
 type TextComponent struct {
-	Type   string  `json:"type"` // "text"
-	Text   string  `json:"text"`
-	Flex   *int    `json:"flex,omitempty"`
-	Margin string  `json:"margin,omitempty"`
-	Size   string  `json:"size,omitempty"`
-	Align  string  `json:"align,omitempty"`
-	Weight string  `json:"weight,omitempty"`
-	Color  string  `json:"color,omitempty"`
-	Wrap   bool    `json:"wrap,omitempty"`
-	Action *Action `json:"action,omitempty"`
+	Type     string          `json:"type"` // "text"
+	Text     string          `json:"text"`
+	Contents []SpanComponent `json:"contents,omitempty"`
+	Flex     *int            `json:"flex,omitempty"`
+	Margin   string          `json:"margin,omitempty"`
+	Size     string          `json:"size,omitempty"`
+	Align    string          `json:"align,omitempty"`
+	Weight   string          `json:"weight,omitempty"`
+	Color    string          `json:"color,omitempty"`
+	Wrap     bool            `json:"wrap,omitempty"`
+	Action   *Action         `json:"action,omitempty"`
+
+	Gravity      string `json:"gravity,omitempty"`
+	LineSpacing  string `json:"lineSpacing,omitempty"`
+	MaxLines     int    `json:"maxLines,omitempty"`
+	Style        string `json:"style,omitempty"`      // "normal", "italic"
+	Decoration   string `json:"decoration,omitempty"` // "none", "underline", "line-through"
+	AdjustMode   string `json:"adjustMode,omitempty"` // "shrink-to-fit"
+	Scaling      bool   `json:"scaling,omitempty"`
+	Position     string `json:"position,omitempty"`
+	OffsetTop    string `json:"offsetTop,omitempty"`
+	OffsetBottom string `json:"offsetBottom,omitempty"`
+	OffsetStart  string `json:"offsetStart,omitempty"`
+	OffsetEnd    string `json:"offsetEnd,omitempty"`
 }
 
 func (c TextComponent) isFlexComponent() {}
 
+// SpanComponent represents a styled run of text inside a TextComponent.
+type SpanComponent struct {
+	Type       string `json:"type"` // "span"
+	Text       string `json:"text"`
+	Size       string `json:"size,omitempty"`
+	Weight     string `json:"weight,omitempty"`
+	Color      string `json:"color,omitempty"`
+	Style      string `json:"style,omitempty"`
+	Decoration string `json:"decoration,omitempty"`
+}
+
 // ImageComponent represents an Image component.
 type ImageComponent struct {
 	Type        string  `json:"type"` // "image"
@@ -77,10 +235,72 @@ type ImageComponent struct {
 	AspectRatio string  `json:"aspectRatio,omitempty"`
 	AspectMode  string  `json:"aspectMode,omitempty"`
 	Action      *Action `json:"action,omitempty"`
+
+	BackgroundColor string `json:"backgroundColor,omitempty"`
+	Animated        bool   `json:"animated,omitempty"`
+	Position        string `json:"position,omitempty"`
+	OffsetTop       string `json:"offsetTop,omitempty"`
+	OffsetBottom    string `json:"offsetBottom,omitempty"`
+	OffsetStart     string `json:"offsetStart,omitempty"`
+	OffsetEnd       string `json:"offsetEnd,omitempty"`
 }
 
 func (c ImageComponent) isFlexComponent() {}
 
+// VideoComponent represents a Video component. It can only be used as the
+// hero of a bubble that is not part of a carousel.
+type VideoComponent struct {
+	Type       string `json:"type"` // "video"
+	URL        string `json:"url"`
+	PreviewURL string `json:"previewUrl"`
+	// AltContent is shown on clients that cannot play the video; a
+	// BoxComponent or ImageComponent.
+	AltContent  FlexComponent `json:"altContent"`
+	AspectRatio string        `json:"aspectRatio,omitempty"`
+	Action      *Action       `json:"action,omitempty"`
+}
+
+func (c VideoComponent) isFlexComponent() {}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *VideoComponent) UnmarshalJSON(data []byte) error {
+	type video VideoComponent
+	var raw struct {
+		video
+		AltContent json.RawMessage `json:"altContent"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*c = VideoComponent(raw.video)
+	if isNull(raw.AltContent) {
+		return nil
+	}
+	alt, err := UnmarshalFlexComponent(raw.AltContent)
+	if err != nil {
+		return fmt.Errorf("altContent: %w", err)
+	}
+	c.AltContent = alt
+	return nil
+}
+
+// IconComponent represents an Icon component, used in baseline boxes.
+type IconComponent struct {
+	Type         string `json:"type"` // "icon"
+	URL          string `json:"url"`
+	Margin       string `json:"margin,omitempty"`
+	Size         string `json:"size,omitempty"`
+	AspectRatio  string `json:"aspectRatio,omitempty"`
+	Scaling      bool   `json:"scaling,omitempty"`
+	Position     string `json:"position,omitempty"`
+	OffsetTop    string `json:"offsetTop,omitempty"`
+	OffsetBottom string `json:"offsetBottom,omitempty"`
+	OffsetStart  string `json:"offsetStart,omitempty"`
+	OffsetEnd    string `json:"offsetEnd,omitempty"`
+}
+
+func (c IconComponent) isFlexComponent() {}
+
 // ButtonComponent represents a Button component.
 type ButtonComponent struct {
 	Type   string `json:"type"` // "button"
@@ -90,6 +310,15 @@ type ButtonComponent struct {
 	Height string `json:"height,omitempty"`
 	Style  string `json:"style,omitempty"` // "link", "primary", "secondary"
 	Color  string `json:"color,omitempty"`
+
+	Gravity      string `json:"gravity,omitempty"`
+	AdjustMode   string `json:"adjustMode,omitempty"`
+	Scaling      bool   `json:"scaling,omitempty"`
+	Position     string `json:"position,omitempty"`
+	OffsetTop    string `json:"offsetTop,omitempty"`
+	OffsetBottom string `json:"offsetBottom,omitempty"`
+	OffsetStart  string `json:"offsetStart,omitempty"`
+	OffsetEnd    string `json:"offsetEnd,omitempty"`
 }
 
 func (c ButtonComponent) isFlexComponent() {}
@@ -103,15 +332,15 @@ type SeparatorComponent struct {
 
 func (c SeparatorComponent) isFlexComponent() {}
 
-// Action represents an action.
-type Action struct {
-	Type  string `json:"type"`
-	Label string `json:"label,omitempty"`
-	URI   string `json:"uri,omitempty"`
-	Data  string `json:"data,omitempty"`
-	Text  string `json:"text,omitempty"`
+// FillerComponent represents a Filler component. Prefer box padding and
+// justifyContent in new designs.
+type FillerComponent struct {
+	Type string `json:"type"` // "filler"
+	Flex *int   `json:"flex,omitempty"`
 }
 
+func (c FillerComponent) isFlexComponent() {}
+
 // BubbleStyles represents styles for a Bubble container.
 type BubbleStyles struct {
 	Header *BlockStyle `json:"header,omitempty"`
@@ -126,3 +355,79 @@ type BlockStyle struct {
 	Separator       bool   `json:"separator,omitempty"`
 	SeparatorColor  string `json:"separatorColor,omitempty"`
 }
+
+// UnmarshalFlexContainer decodes a bubble or carousel, such as a design
+// exported from the Flex Message Simulator.
+func UnmarshalFlexContainer(data []byte) (FlexContainer, error) {
+	typ, err := flexType(data)
+	if err != nil {
+		return nil, err
+	}
+	switch typ {
+	case "bubble":
+		var c BubbleContainer
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, err
+		}
+		return c, nil
+	case "carousel":
+		var c CarouselContainer
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, err
+		}
+		return c, nil
+	default:
+		return nil, fmt.Errorf("unknown flex container type %q", typ)
+	}
+}
+
+// UnmarshalFlexComponent decodes a Flex component according to its type property.
+func UnmarshalFlexComponent(data []byte) (FlexComponent, error) {
+	typ, err := flexType(data)
+	if err != nil {
+		return nil, err
+	}
+	switch typ {
+	case "box":
+		return decodeComponent[BoxComponent](data)
+	case "text":
+		return decodeComponent[TextComponent](data)
+	case "image":
+		return decodeComponent[ImageComponent](data)
+	case "video":
+		return decodeComponent[VideoComponent](data)
+	case "icon":
+		return decodeComponent[IconComponent](data)
+	case "button":
+		return decodeComponent[ButtonComponent](data)
+	case "separator":
+		return decodeComponent[SeparatorComponent](data)
+	case "filler":
+		return decodeComponent[FillerComponent](data)
+	default:
+		return nil, fmt.Errorf("unknown flex component type %q", typ)
+	}
+}
+
+func decodeComponent[T FlexComponent](data []byte) (FlexComponent, error) {
+	var c T
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// flexType returns the type property of a Flex JSON object.
+func flexType(data []byte) (string, error) {
+	var v struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", err
+	}
+	return v.Type, nil
+}
+
+func isNull(data json.RawMessage) bool {
+	return len(data) == 0 || string(data) == "null"
+}
@@ -0,0 +1,229 @@
+package line
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/thanpawatpiti/notify"
+)
+
+// maxWebhookBody is the largest webhook request body the handler reads.
+const maxWebhookBody = 1 << 20
+
+var (
+	// ErrInvalidSignature is returned by ParseRequest when the X-Line-Signature
+	// header does not match the request body.
+	ErrInvalidSignature = errors.New("line webhook signature is invalid")
+	// ErrMissingChannelSecret is returned by ParseRequest when the webhook
+	// has no channel secret, so no request can be verified.
+	ErrMissingChannelSecret = errors.New("line webhook channel secret is missing")
+)
+
+// EventHandler handles a webhook event. The context is cancelled once the
+// webhook request has been answered.
+type EventHandler func(ctx context.Context, e Event) error
+
+// Webhook is an http.Handler that receives LINE webhook events, verifies
+// their signature and dispatches them to the registered handlers.
+// Handler errors are passed to the error handler; LINE always receives
+// 200 OK for correctly signed requests so that events are not redelivered.
+type Webhook struct {
+	channelSecret string
+	provider      *Provider
+	clock         notify.Clock
+	onError       func(error)
+
+	mu       sync.RWMutex
+	handlers map[EventType][]EventHandler
+	all      []EventHandler
+}
+
+// WebhookOption configures a Webhook.
+type WebhookOption func(*Webhook)
+
+// WithWebhookProvider configures the provider used by EventBase.Reply.
+func WithWebhookProvider(p *Provider) WebhookOption {
+	return func(w *Webhook) {
+		w.provider = p
+	}
+}
+
+// WithWebhookClock configures the clock that stamps reply tokens. Defaults to notify.SystemClock.
+func WithWebhookClock(c notify.Clock) WebhookOption {
+	return func(w *Webhook) {
+		w.clock = c
+	}
+}
+
+// WithWebhookErrorHandler configures a callback for handler errors and
+// rejected requests.
+func WithWebhookErrorHandler(f func(error)) WebhookOption {
+	return func(w *Webhook) {
+		w.onError = f
+	}
+}
+
+// NewWebhook creates a webhook handler for the channel with channelSecret.
+// Without a secret every request is refused with 500 Internal Server Error.
+func NewWebhook(channelSecret string, opts ...WebhookOption) *Webhook {
+	w := &Webhook{
+		channelSecret: channelSecret,
+		clock:         notify.SystemClock,
+		onError:       func(error) {},
+		handlers:      make(map[EventType][]EventHandler),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Handle registers h for events of type t. Handlers run in registration order.
+func (w *Webhook) Handle(t EventType, h EventHandler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[t] = append(w.handlers[t], h)
+}
+
+// HandleAll registers h for every event, after the handlers for its type.
+func (w *Webhook) HandleAll(h EventHandler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.all = append(w.all, h)
+}
+
+// OnMessage registers h for message events.
+func (w *Webhook) OnMessage(h func(ctx context.Context, e *MessageEvent) error) {
+	w.Handle(EventMessage, func(ctx context.Context, e Event) error {
+		return h(ctx, e.(*MessageEvent))
+	})
+}
+
+// OnFollow registers h for follow events.
+func (w *Webhook) OnFollow(h func(ctx context.Context, e *FollowEvent) error) {
+	w.Handle(EventFollow, func(ctx context.Context, e Event) error {
+		return h(ctx, e.(*FollowEvent))
+	})
+}
+
+// OnPostback registers h for postback events.
+func (w *Webhook) OnPostback(h func(ctx context.Context, e *PostbackEvent) error) {
+	w.Handle(EventPostback, func(ctx context.Context, e Event) error {
+		return h(ctx, e.(*PostbackEvent))
+	})
+}
+
+// ServeHTTP implements http.Handler.
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	events, err := w.ParseRequest(rw, r)
+	switch {
+	case errors.Is(err, ErrMissingChannelSecret):
+		w.onError(err)
+		http.Error(rw, "webhook is not configured", http.StatusInternalServerError)
+		return
+	case errors.Is(err, ErrInvalidSignature):
+		w.onError(err)
+		http.Error(rw, "invalid signature", http.StatusUnauthorized)
+		return
+	case err != nil:
+		w.onError(err)
+		http.Error(rw, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	for _, e := range events {
+		w.dispatch(r.Context(), e)
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+// ParseRequest verifies and decodes a webhook request without dispatching
+// it, for applications that route events themselves. rw may be nil; it is
+// only used to limit the body size.
+func (w *Webhook) ParseRequest(rw http.ResponseWriter, r *http.Request) ([]Event, error) {
+	if w.channelSecret == "" {
+		return nil, ErrMissingChannelSecret
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, maxWebhookBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read line webhook body: %w", err)
+	}
+	if !VerifySignature(w.channelSecret, body, r.Header.Get("X-Line-Signature")) {
+		return nil, ErrInvalidSignature
+	}
+
+	events, err := ParseEvents(body, w.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		e.Base().provider = w.provider
+	}
+	return events, nil
+}
+
+func (w *Webhook) dispatch(ctx context.Context, e Event) {
+	w.mu.RLock()
+	handlers := append(append([]EventHandler(nil), w.handlers[e.Base().Type]...), w.all...)
+	w.mu.RUnlock()
+
+	for _, h := range handlers {
+		if err := h(ctx, e); err != nil {
+			w.onError(fmt.Errorf("line %s event %s: %w", e.Base().Type, e.Base().WebhookEventID, err))
+		}
+	}
+}
+
+// VerifySignature reports whether signature, the X-Line-Signature header,
+// is the base64 HMAC-SHA256 of body keyed with channelSecret. It is always
+// false for an empty channelSecret, with which anyone could sign.
+func VerifySignature(channelSecret string, body []byte, signature string) bool {
+	if channelSecret == "" {
+		return false
+	}
+	got, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(channelSecret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// ParseEvents decodes the events of a webhook request body. Reply tokens
+// are stamped with receivedAt. The body is not verified; use
+// VerifySignature first.
+func ParseEvents(body []byte, receivedAt time.Time) ([]Event, error) {
+	var req struct {
+		Events []json.RawMessage `json:"events"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("failed to decode line webhook: %w", err)
+	}
+
+	events := make([]Event, 0, len(req.Events))
+	for _, data := range req.Events {
+		e, err := parseEvent(data, receivedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
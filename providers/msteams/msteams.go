@@ -6,10 +6,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/thanpawatpiti/notify"
+	"github.com/thanpawatpiti/notify/format"
 )
 
+// Name identifies this provider to a notify.MentionResolver.
+const Name = "msteams"
+
 // Provider implements the Notifier interface for Microsoft Teams.
 type Provider struct {
 	webhookURL string
@@ -35,11 +40,35 @@ func New(webhookURL string, opts ...notify.Option) *Provider {
 // Send sends a message via Microsoft Teams Incoming Webhook.
 // payload can be:
 // - string: Simple text message.
-// - notify.CommonMessage: Generic rich message (Text + Image).
+// - notify.CommonMessage: Generic rich message (Text + Image + Attachments).
 // - msteams.AdaptiveCard: Full Adaptive Card.
+//
+// Incoming webhooks cannot receive files, so attachments require an uploader
+// configured with notify.WithAttachmentUploader; images are embedded in the
+// card and other files become "open" actions. Without one,
+// notify.ErrAttachmentsUnsupported is returned.
+// CommonMessage mentions are rendered as <at> tags backed by msteams
+// mention entities; resolve users to their UPN or AAD object ID.
 func (p *Provider) Send(ctx context.Context, payload interface{}) error {
+	reqs, err := p.Render(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	for _, req := range reqs {
+		if err := p.do(req); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Render builds the webhook request Send would make for payload without
+// sending it.
+func (p *Provider) Render(ctx context.Context, payload interface{}) ([]*http.Request, error) {
 	if p.webhookURL == "" {
-		return fmt.Errorf("msteams webhook url is missing")
+		return nil, fmt.Errorf("msteams webhook url is missing")
 	}
 
 	var card AdaptiveCard
@@ -68,10 +97,24 @@ func (p *Provider) Send(ctx context.Context, payload interface{}) error {
 				Size:   "Medium",
 			})
 		}
+		var msteams *MSTeamsProperties
+		if len(v.Mentions) > 0 {
+			mentions, err := notify.ResolveMentions(ctx, p.opts.MentionResolver, Name, v.Mentions)
+			if err != nil {
+				return nil, err
+			}
+			var text string
+			text, msteams = renderMentions(mentions)
+			body = append(body, TextBlock{
+				Type: "TextBlock",
+				Text: text,
+				Wrap: true,
+			})
+		}
 		if v.Content != "" {
 			body = append(body, TextBlock{
 				Type: "TextBlock",
-				Text: v.Content,
+				Text: format.Convert(v.Content, v.Format, format.AdaptiveCard),
 				Wrap: true,
 			})
 		}
@@ -82,11 +125,34 @@ func (p *Provider) Send(ctx context.Context, payload interface{}) error {
 				Size: "Stretch",
 			})
 		}
+		var actions []interface{}
+		for _, a := range v.Attachments {
+			url, err := p.uploadAttachment(ctx, a)
+			if err != nil {
+				return nil, err
+			}
+			if a.IsImage() {
+				body = append(body, Image{
+					Type: "Image",
+					URL:  url,
+					Size: "Stretch",
+					Alt:  a.Name,
+				})
+			} else {
+				actions = append(actions, ActionOpenUrl{
+					Type:  "Action.OpenUrl",
+					Title: a.Name,
+					URL:   url,
+				})
+			}
+		}
 		card = AdaptiveCard{
 			Type:    "AdaptiveCard",
 			Version: "1.2",
 			Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
 			Body:    body,
+			Actions: actions,
+			MSTeams: msteams,
 		}
 	case AdaptiveCard:
 		card = v
@@ -100,7 +166,7 @@ func (p *Provider) Send(ctx context.Context, payload interface{}) error {
 			card.Version = "1.2"
 		}
 	default:
-		return fmt.Errorf("unsupported payload type: %T", v)
+		return nil, fmt.Errorf("unsupported payload type: %T", v)
 	}
 
 	wp := WebhookPayload{
@@ -115,16 +181,21 @@ func (p *Provider) Send(ctx context.Context, payload interface{}) error {
 
 	body, err := json.Marshal(wp)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.webhookURL, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
+	return []*http.Request{req}, nil
+}
+
+// do sends req and checks the response status.
+func (p *Provider) do(req *http.Request) error {
 	resp, err := p.opts.HTTPClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
@@ -137,3 +208,36 @@ func (p *Provider) Send(ctx context.Context, payload interface{}) error {
 
 	return nil
 }
+
+// uploadAttachment hosts a through the configured notify.AttachmentUploader.
+func (p *Provider) uploadAttachment(ctx context.Context, a notify.Attachment) (string, error) {
+	if p.opts.AttachmentUploader == nil {
+		return "", fmt.Errorf("msteams: %w", notify.ErrAttachmentsUnsupported)
+	}
+	url, err := p.opts.AttachmentUploader.Upload(ctx, a)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload attachment %s: %w", a.Name, err)
+	}
+	return url, nil
+}
+
+// renderMentions returns a line of <at> tags for mentions and the msteams
+// properties holding the matching mention entities.
+func renderMentions(mentions []notify.ResolvedMention) (string, *MSTeamsProperties) {
+	props := &MSTeamsProperties{}
+	parts := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		tag := "<at>" + m.DisplayName + "</at>"
+		parts = append(parts, tag)
+		mentioned := &Mentioned{ID: m.ID, Name: m.DisplayName}
+		if m.Kind == notify.MentionRole {
+			mentioned.Type = "tag"
+		}
+		props.Entities = append(props.Entities, Entity{
+			Type:      "mention",
+			Text:      tag,
+			Mentioned: mentioned,
+		})
+	}
+	return strings.Join(parts, " "), props
+}
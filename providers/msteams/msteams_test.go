@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/thanpawatpiti/notify"
+	"github.com/thanpawatpiti/notify/notifytest"
 )
 
 func TestSend(t *testing.T) {
@@ -35,3 +36,39 @@ func TestSend(t *testing.T) {
 		t.Errorf("AdaptiveCard: expected no error, got %v", err)
 	}
 }
+
+func TestRender(t *testing.T) {
+	p := New("https://example.webhook.office.com/webhookb2/token")
+
+	tests := []struct {
+		name    string
+		payload interface{}
+	}{
+		{"string", "Deploy finished for api_server"},
+		{"common_message", notify.CommonMessage{
+			Title:    "Incident",
+			Content:  "Database **down** since 02:00. See [runbook](https://example.com/runbook).",
+			ImageURL: "https://example.com/graph.png",
+			Mentions: []notify.Mention{{Name: "alice@example.com"}},
+		}},
+		{"adaptive_card", AdaptiveCard{
+			Body: []interface{}{
+				TextBlock{Type: "TextBlock", Text: "Release v1.2.0", Weight: "Bolder"},
+				FactSet{Type: "FactSet", Facts: []Fact{{Title: "Service", Value: "api"}}},
+			},
+			Actions: []interface{}{
+				ActionOpenUrl{Type: "Action.OpenUrl", Title: "Changelog", URL: "https://example.com/changelog"},
+			},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqs, err := p.Render(context.Background(), tt.payload)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			notifytest.GoldenRequests(t, tt.name, reqs)
+		})
+	}
+}
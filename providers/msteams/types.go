@@ -7,6 +7,28 @@ type AdaptiveCard struct {
 	Body    []interface{} `json:"body"`
 	Actions []interface{} `json:"actions,omitempty"`
 	Schema  string        `json:"$schema,omitempty"`
+	// MSTeams holds Teams specific card properties such as mention entities.
+	MSTeams *MSTeamsProperties `json:"msteams,omitempty"`
+}
+
+// MSTeamsProperties represents the "msteams" property of an Adaptive Card.
+type MSTeamsProperties struct {
+	Width    string   `json:"width,omitempty"` // "Full"
+	Entities []Entity `json:"entities,omitempty"`
+}
+
+// Entity represents an entity referenced from card text, such as a mention.
+type Entity struct {
+	Type      string     `json:"type"` // "mention"
+	Text      string     `json:"text"` // "<at>Name</at>"
+	Mentioned *Mentioned `json:"mentioned,omitempty"`
+}
+
+// Mentioned identifies the user or tag of a mention entity.
+type Mentioned struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"` // "person" (default) or "tag"
 }
 
 // TextBlock represents a TextBlock element.
@@ -0,0 +1,107 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	"github.com/thanpawatpiti/notify"
+)
+
+// Chat actions shown to chat members while the bot is busy.
+const (
+	ChatActionTyping          = "typing"
+	ChatActionUploadPhoto     = "upload_photo"
+	ChatActionRecordVideo     = "record_video"
+	ChatActionUploadVideo     = "upload_video"
+	ChatActionRecordVoice     = "record_voice"
+	ChatActionUploadVoice     = "upload_voice"
+	ChatActionUploadDocument  = "upload_document"
+	ChatActionChooseSticker   = "choose_sticker"
+	ChatActionFindLocation    = "find_location"
+	ChatActionRecordVideoNote = "record_video_note"
+	ChatActionUploadVideoNote = "upload_video_note"
+)
+
+// chatActionInterval is how often KeepChatAction repeats an action, which
+// Telegram shows for 5 seconds or until the bot sends a message.
+const chatActionInterval = 4 * time.Second
+
+// ChatAction is a sendChatAction payload, e.g. "typing…" while a long
+// report is generated. SendMessages returns no messages for it.
+type ChatAction struct {
+	BusinessConnectionID string `json:"business_connection_id,omitempty"`
+	ChatID               string `json:"chat_id"`
+	MessageThreadID      int    `json:"message_thread_id,omitempty"`
+	// Action is one of the ChatAction constants.
+	Action string `json:"action"`
+}
+
+// ChatActionOption configures KeepChatAction.
+type ChatActionOption func(*chatActionConfig)
+
+type chatActionConfig struct {
+	clock   notify.Clock
+	onError func(error)
+}
+
+// WithChatActionClock configures the clock that times repeated actions.
+// Defaults to notify.SystemClock.
+func WithChatActionClock(c notify.Clock) ChatActionOption {
+	return func(cfg *chatActionConfig) {
+		cfg.clock = c
+	}
+}
+
+// WithChatActionErrorHandler configures a callback for failures of the
+// repeated sends.
+func WithChatActionErrorHandler(f func(error)) ChatActionOption {
+	return func(cfg *chatActionConfig) {
+		cfg.onError = f
+	}
+}
+
+// KeepChatAction sends action and repeats it until stop is called or ctx
+// is done, so that it stays visible during work that takes longer than 5
+// seconds. The first send is reported by KeepChatAction; later failures go
+// to WithChatActionErrorHandler and are otherwise ignored.
+//
+//	stop, err := p.KeepChatAction(ctx, telegram.ChatAction{Action: telegram.ChatActionTyping})
+//	if err != nil {
+//		return err
+//	}
+//	report := buildReport(ctx)
+//	stop()
+//	return p.Send(ctx, report)
+func (p *Provider) KeepChatAction(ctx context.Context, action ChatAction, opts ...ChatActionOption) (stop func(), err error) {
+	cfg := chatActionConfig{clock: notify.SystemClock}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if err := p.Send(ctx, action); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			timer, stopTimer := notify.NewTimer(cfg.clock, chatActionInterval)
+			select {
+			case <-ctx.Done():
+				stopTimer()
+				return
+			case <-timer:
+			}
+			if err := p.Send(ctx, action); err != nil && ctx.Err() == nil && cfg.onError != nil {
+				cfg.onError(err)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}, nil
+}
@@ -0,0 +1,125 @@
+package telegram
+
+import "fmt"
+
+// LivePeriodForever keeps a live location updatable until it is stopped.
+const LivePeriodForever = 0x7FFFFFFF
+
+// Location is a sendLocation payload. With a LivePeriod it is a live
+// location, moved with EditLiveLocation and ended with StopLiveLocation.
+type Location struct {
+	BusinessConnectionID string  `json:"business_connection_id,omitempty"`
+	ChatID               string  `json:"chat_id"`
+	MessageThreadID      int     `json:"message_thread_id,omitempty"`
+	Latitude             float64 `json:"latitude"`
+	Longitude            float64 `json:"longitude"`
+	// HorizontalAccuracy is the uncertainty radius in meters, up to 1500.
+	HorizontalAccuracy float64 `json:"horizontal_accuracy,omitempty"`
+	// LivePeriod is how long in seconds the location can be updated, 60 to
+	// 86400, or LivePeriodForever.
+	LivePeriod int `json:"live_period,omitempty"`
+	// Heading is the direction of movement in degrees, 1 to 360; live only.
+	Heading int `json:"heading,omitempty"`
+	// ProximityAlertRadius is the distance in meters, up to 100000, at which
+	// chat members are alerted when approaching; live only.
+	ProximityAlertRadius int              `json:"proximity_alert_radius,omitempty"`
+	DisableNotification  bool             `json:"disable_notification,omitempty"`
+	ProtectContent       bool             `json:"protect_content,omitempty"`
+	AllowPaidBroadcast   bool             `json:"allow_paid_broadcast,omitempty"`
+	MessageEffectID      string           `json:"message_effect_id,omitempty"`
+	ReplyParameters      *ReplyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup          ReplyMarkup      `json:"reply_markup,omitempty"`
+}
+
+// EditLiveLocation is an editMessageLiveLocation payload that moves a live
+// location until its LivePeriod expires or it is stopped.
+type EditLiveLocation struct {
+	BusinessConnectionID string `json:"business_connection_id,omitempty"`
+	// ChatID and MessageID identify the location message; the provider's
+	// chat is used when ChatID is empty.
+	ChatID    string `json:"chat_id,omitempty"`
+	MessageID int    `json:"message_id,omitempty"`
+	// InlineMessageID identifies an inline message instead.
+	InlineMessageID    string  `json:"inline_message_id,omitempty"`
+	Latitude           float64 `json:"latitude"`
+	Longitude          float64 `json:"longitude"`
+	HorizontalAccuracy float64 `json:"horizontal_accuracy,omitempty"`
+	// LivePeriod replaces the period, counted from when the message was
+	// sent; zero keeps it.
+	LivePeriod           int                   `json:"live_period,omitempty"`
+	Heading              int                   `json:"heading,omitempty"`
+	ProximityAlertRadius int                   `json:"proximity_alert_radius,omitempty"`
+	ReplyMarkup          *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+// StopLiveLocation is a stopMessageLiveLocation payload that ends a live
+// location before its LivePeriod expires.
+type StopLiveLocation struct {
+	BusinessConnectionID string                `json:"business_connection_id,omitempty"`
+	ChatID               string                `json:"chat_id,omitempty"`
+	MessageID            int                   `json:"message_id,omitempty"`
+	InlineMessageID      string                `json:"inline_message_id,omitempty"`
+	ReplyMarkup          *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+// editChat returns the chat of an edit of messageID, or "" for inline messages.
+func (p *Provider) editChat(chatID string, messageID int, inlineMessageID string) (string, error) {
+	if inlineMessageID != "" {
+		return "", nil
+	}
+	if messageID == 0 {
+		return "", fmt.Errorf("telegram message ID is missing")
+	}
+	return p.chat(chatID), nil
+}
+
+// Venue is a sendVenue payload: a location with a title and an address.
+type Venue struct {
+	BusinessConnectionID string  `json:"business_connection_id,omitempty"`
+	ChatID               string  `json:"chat_id"`
+	MessageThreadID      int     `json:"message_thread_id,omitempty"`
+	Latitude             float64 `json:"latitude"`
+	Longitude            float64 `json:"longitude"`
+	Title                string  `json:"title"`
+	Address              string  `json:"address"`
+	// FoursquareID and GooglePlaceID link the venue to a place listing.
+	FoursquareID        string           `json:"foursquare_id,omitempty"`
+	FoursquareType      string           `json:"foursquare_type,omitempty"`
+	GooglePlaceID       string           `json:"google_place_id,omitempty"`
+	GooglePlaceType     string           `json:"google_place_type,omitempty"`
+	DisableNotification bool             `json:"disable_notification,omitempty"`
+	ProtectContent      bool             `json:"protect_content,omitempty"`
+	AllowPaidBroadcast  bool             `json:"allow_paid_broadcast,omitempty"`
+	MessageEffectID     string           `json:"message_effect_id,omitempty"`
+	ReplyParameters     *ReplyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup         ReplyMarkup      `json:"reply_markup,omitempty"`
+}
+
+// Contact is a sendContact payload for a phone contact.
+type Contact struct {
+	BusinessConnectionID string `json:"business_connection_id,omitempty"`
+	ChatID               string `json:"chat_id"`
+	MessageThreadID      int    `json:"message_thread_id,omitempty"`
+	PhoneNumber          string `json:"phone_number"`
+	FirstName            string `json:"first_name"`
+	LastName             string `json:"last_name,omitempty"`
+	// VCard adds details such as an email address, up to 2048 bytes.
+	VCard               string           `json:"vcard,omitempty"`
+	DisableNotification bool             `json:"disable_notification,omitempty"`
+	ProtectContent      bool             `json:"protect_content,omitempty"`
+	AllowPaidBroadcast  bool             `json:"allow_paid_broadcast,omitempty"`
+	MessageEffectID     string           `json:"message_effect_id,omitempty"`
+	ReplyParameters     *ReplyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup         ReplyMarkup      `json:"reply_markup,omitempty"`
+}
+
+// Coordinates is a location received in a Message.
+type Coordinates struct {
+	Latitude           float64 `json:"latitude"`
+	Longitude          float64 `json:"longitude"`
+	HorizontalAccuracy float64 `json:"horizontal_accuracy,omitempty"`
+	// LivePeriod, Heading and ProximityAlertRadius are set for live locations.
+	LivePeriod           int `json:"live_period,omitempty"`
+	Heading              int `json:"heading,omitempty"`
+	ProximityAlertRadius int `json:"proximity_alert_radius,omitempty"`
+}
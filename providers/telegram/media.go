@@ -0,0 +1,362 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/thanpawatpiti/notify"
+	"github.com/thanpawatpiti/notify/internal/multipart"
+)
+
+// Media types of an InputMedia.
+const (
+	MediaPhoto    = "photo"
+	MediaVideo    = "video"
+	MediaAudio    = "audio"
+	MediaDocument = "document"
+)
+
+// InputFile is a file to send: a URL Telegram downloads itself, the file_id
+// of a file already on Telegram's servers, or an upload sent with
+// multipart/form-data. Create one with FileURL, FileID, FileUpload or FilePath.
+type InputFile struct {
+	url    string
+	fileID string
+	upload *notify.Attachment
+	attach string // form field name of the upload, assigned by Render
+}
+
+// FileURL returns a file Telegram downloads from url. Photos may be up to
+// 5 MB and other files up to 20 MB.
+func FileURL(url string) InputFile {
+	return InputFile{url: url}
+}
+
+// FileID returns a file previously sent to Telegram, e.g. one returned by
+// Message.FileID. Reusing it avoids uploading the file again.
+func FileID(id string) InputFile {
+	return InputFile{fileID: id}
+}
+
+// FileUpload returns a file uploaded from a. Uploads may be up to 50 MB,
+// photos up to 10 MB.
+func FileUpload(a notify.Attachment) InputFile {
+	return InputFile{upload: &a}
+}
+
+// FilePath returns a local file to upload. The file is opened when the
+// request body is read and closed once it has been sent or the request is
+// abandoned, so the same InputFile can be sent any number of times.
+func FilePath(path string) InputFile {
+	return FileUpload(notify.Attachment{Name: filepath.Base(path), Reader: &fileReader{path: path}})
+}
+
+// IsZero reports whether f is unset.
+func (f InputFile) IsZero() bool {
+	return f.url == "" && f.fileID == "" && f.upload == nil
+}
+
+// MarshalJSON encodes f as its URL, its file_id or an attach:// reference
+// to the uploaded form field.
+func (f InputFile) MarshalJSON() ([]byte, error) {
+	switch {
+	case f.upload != nil:
+		if f.attach == "" {
+			return nil, fmt.Errorf("telegram upload %s can only be sent through the provider", f.upload.Name)
+		}
+		return json.Marshal("attach://" + f.attach)
+	case f.fileID != "":
+		return json.Marshal(f.fileID)
+	default:
+		return json.Marshal(f.url)
+	}
+}
+
+// fileReader opens a local file on the first Read and closes it at the
+// end. Each request reads its own copy; see uploadAttachment.
+type fileReader struct {
+	path string
+	f    *os.File
+}
+
+func (r *fileReader) Read(b []byte) (int, error) {
+	if r.f == nil {
+		f, err := os.Open(r.path)
+		if err != nil {
+			return 0, err
+		}
+		r.f = f
+	}
+	n, err := r.f.Read(b)
+	if err != nil {
+		r.Release()
+	}
+	return n, err
+}
+
+// Release closes the file if it is open, e.g. when an upload is abandoned
+// before the end of the file.
+func (r *fileReader) Release() {
+	if r.f != nil {
+		r.f.Close()
+		r.f = nil
+	}
+}
+
+// uploadAttachment returns the attachment of an upload for one request,
+// with a fresh reader for files given by path.
+func uploadAttachment(a notify.Attachment) notify.Attachment {
+	if fr, ok := a.Reader.(*fileReader); ok {
+		a.Reader = &fileReader{path: fr.path}
+	}
+	return a
+}
+
+// Photo is a sendPhoto payload. Unlike Payload.Photo it accepts any InputFile.
+type Photo struct {
+	BusinessConnectionID string           `json:"business_connection_id,omitempty"`
+	ChatID               string           `json:"chat_id"`
+	MessageThreadID      int              `json:"message_thread_id,omitempty"`
+	Photo                InputFile        `json:"photo"`
+	Caption              string           `json:"caption,omitempty"`
+	ParseMode            string           `json:"parse_mode,omitempty"`
+	CaptionEntities      []MessageEntity  `json:"caption_entities,omitempty"`
+	HasSpoiler           bool             `json:"has_spoiler,omitempty"`
+	DisableNotification  bool             `json:"disable_notification,omitempty"`
+	ProtectContent       bool             `json:"protect_content,omitempty"`
+	AllowPaidBroadcast   bool             `json:"allow_paid_broadcast,omitempty"`
+	MessageEffectID      string           `json:"message_effect_id,omitempty"`
+	ReplyParameters      *ReplyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup          ReplyMarkup      `json:"reply_markup,omitempty"`
+}
+
+// Document is a sendDocument payload for a general file.
+type Document struct {
+	BusinessConnectionID string    `json:"business_connection_id,omitempty"`
+	ChatID               string    `json:"chat_id"`
+	MessageThreadID      int       `json:"message_thread_id,omitempty"`
+	Document             InputFile `json:"document"`
+	// Thumbnail is a JPEG of at most 200 kB and 320x320 px. It must be an upload.
+	Thumbnail       InputFile       `json:"thumbnail,omitzero"`
+	Caption         string          `json:"caption,omitempty"`
+	ParseMode       string          `json:"parse_mode,omitempty"`
+	CaptionEntities []MessageEntity `json:"caption_entities,omitempty"`
+	// DisableContentTypeDetection keeps uploads as documents even if they
+	// look like photos or videos.
+	DisableContentTypeDetection bool             `json:"disable_content_type_detection,omitempty"`
+	DisableNotification         bool             `json:"disable_notification,omitempty"`
+	ProtectContent              bool             `json:"protect_content,omitempty"`
+	AllowPaidBroadcast          bool             `json:"allow_paid_broadcast,omitempty"`
+	MessageEffectID             string           `json:"message_effect_id,omitempty"`
+	ReplyParameters             *ReplyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup                 ReplyMarkup      `json:"reply_markup,omitempty"`
+}
+
+// Video is a sendVideo payload for an MPEG4 video.
+type Video struct {
+	BusinessConnectionID string           `json:"business_connection_id,omitempty"`
+	ChatID               string           `json:"chat_id"`
+	MessageThreadID      int              `json:"message_thread_id,omitempty"`
+	Video                InputFile        `json:"video"`
+	Thumbnail            InputFile        `json:"thumbnail,omitzero"`
+	Duration             int              `json:"duration,omitempty"` // seconds
+	Width                int              `json:"width,omitempty"`
+	Height               int              `json:"height,omitempty"`
+	Caption              string           `json:"caption,omitempty"`
+	ParseMode            string           `json:"parse_mode,omitempty"`
+	CaptionEntities      []MessageEntity  `json:"caption_entities,omitempty"`
+	HasSpoiler           bool             `json:"has_spoiler,omitempty"`
+	SupportsStreaming    bool             `json:"supports_streaming,omitempty"`
+	DisableNotification  bool             `json:"disable_notification,omitempty"`
+	ProtectContent       bool             `json:"protect_content,omitempty"`
+	AllowPaidBroadcast   bool             `json:"allow_paid_broadcast,omitempty"`
+	MessageEffectID      string           `json:"message_effect_id,omitempty"`
+	ReplyParameters      *ReplyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup          ReplyMarkup      `json:"reply_markup,omitempty"`
+}
+
+// Audio is a sendAudio payload for an MP3 or M4A file shown in the music player.
+type Audio struct {
+	BusinessConnectionID string           `json:"business_connection_id,omitempty"`
+	ChatID               string           `json:"chat_id"`
+	MessageThreadID      int              `json:"message_thread_id,omitempty"`
+	Audio                InputFile        `json:"audio"`
+	Thumbnail            InputFile        `json:"thumbnail,omitzero"`
+	Duration             int              `json:"duration,omitempty"` // seconds
+	Performer            string           `json:"performer,omitempty"`
+	Title                string           `json:"title,omitempty"`
+	Caption              string           `json:"caption,omitempty"`
+	ParseMode            string           `json:"parse_mode,omitempty"`
+	CaptionEntities      []MessageEntity  `json:"caption_entities,omitempty"`
+	DisableNotification  bool             `json:"disable_notification,omitempty"`
+	ProtectContent       bool             `json:"protect_content,omitempty"`
+	AllowPaidBroadcast   bool             `json:"allow_paid_broadcast,omitempty"`
+	MessageEffectID      string           `json:"message_effect_id,omitempty"`
+	ReplyParameters      *ReplyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup          ReplyMarkup      `json:"reply_markup,omitempty"`
+}
+
+// Animation is a sendAnimation payload for a GIF or silent MPEG4 video.
+type Animation struct {
+	BusinessConnectionID string           `json:"business_connection_id,omitempty"`
+	ChatID               string           `json:"chat_id"`
+	MessageThreadID      int              `json:"message_thread_id,omitempty"`
+	Animation            InputFile        `json:"animation"`
+	Thumbnail            InputFile        `json:"thumbnail,omitzero"`
+	Duration             int              `json:"duration,omitempty"` // seconds
+	Width                int              `json:"width,omitempty"`
+	Height               int              `json:"height,omitempty"`
+	Caption              string           `json:"caption,omitempty"`
+	ParseMode            string           `json:"parse_mode,omitempty"`
+	CaptionEntities      []MessageEntity  `json:"caption_entities,omitempty"`
+	HasSpoiler           bool             `json:"has_spoiler,omitempty"`
+	DisableNotification  bool             `json:"disable_notification,omitempty"`
+	ProtectContent       bool             `json:"protect_content,omitempty"`
+	AllowPaidBroadcast   bool             `json:"allow_paid_broadcast,omitempty"`
+	MessageEffectID      string           `json:"message_effect_id,omitempty"`
+	ReplyParameters      *ReplyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup          ReplyMarkup      `json:"reply_markup,omitempty"`
+}
+
+// Voice is a sendVoice payload for an OGG/Opus, MP3 or M4A voice message.
+type Voice struct {
+	BusinessConnectionID string           `json:"business_connection_id,omitempty"`
+	ChatID               string           `json:"chat_id"`
+	MessageThreadID      int              `json:"message_thread_id,omitempty"`
+	Voice                InputFile        `json:"voice"`
+	Duration             int              `json:"duration,omitempty"` // seconds
+	Caption              string           `json:"caption,omitempty"`
+	ParseMode            string           `json:"parse_mode,omitempty"`
+	CaptionEntities      []MessageEntity  `json:"caption_entities,omitempty"`
+	DisableNotification  bool             `json:"disable_notification,omitempty"`
+	ProtectContent       bool             `json:"protect_content,omitempty"`
+	AllowPaidBroadcast   bool             `json:"allow_paid_broadcast,omitempty"`
+	MessageEffectID      string           `json:"message_effect_id,omitempty"`
+	ReplyParameters      *ReplyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup          ReplyMarkup      `json:"reply_markup,omitempty"`
+}
+
+// MediaGroup is a sendMediaGroup payload that sends 2 to 10 items as an
+// album. Photos and videos can be mixed; documents and audio files can
+// only be grouped with their own kind.
+type MediaGroup struct {
+	BusinessConnectionID string           `json:"business_connection_id,omitempty"`
+	ChatID               string           `json:"chat_id"`
+	MessageThreadID      int              `json:"message_thread_id,omitempty"`
+	Media                []InputMedia     `json:"media"`
+	DisableNotification  bool             `json:"disable_notification,omitempty"`
+	ProtectContent       bool             `json:"protect_content,omitempty"`
+	AllowPaidBroadcast   bool             `json:"allow_paid_broadcast,omitempty"`
+	MessageEffectID      string           `json:"message_effect_id,omitempty"`
+	ReplyParameters      *ReplyParameters `json:"reply_parameters,omitempty"`
+}
+
+// InputMedia is an item of a MediaGroup.
+type InputMedia struct {
+	// Type is MediaPhoto, MediaVideo, MediaAudio or MediaDocument.
+	Type            string          `json:"type"`
+	Media           InputFile       `json:"media"`
+	Thumbnail       InputFile       `json:"thumbnail,omitzero"` // video, audio and document
+	Caption         string          `json:"caption,omitempty"`
+	ParseMode       string          `json:"parse_mode,omitempty"`
+	CaptionEntities []MessageEntity `json:"caption_entities,omitempty"`
+	HasSpoiler      bool            `json:"has_spoiler,omitempty"` // photo and video
+	// Video and audio.
+	Duration int `json:"duration,omitempty"`
+	// Video.
+	Width             int  `json:"width,omitempty"`
+	Height            int  `json:"height,omitempty"`
+	SupportsStreaming bool `json:"supports_streaming,omitempty"`
+	// Audio.
+	Performer string `json:"performer,omitempty"`
+	Title     string `json:"title,omitempty"`
+}
+
+// validate checks the size of the album and the combination of media types.
+func (g MediaGroup) validate() error {
+	if len(g.Media) < 2 || len(g.Media) > 10 {
+		return fmt.Errorf("telegram media group needs 2 to 10 items, got %d", len(g.Media))
+	}
+	kinds := make(map[string]bool)
+	for i, m := range g.Media {
+		switch m.Type {
+		case MediaPhoto, MediaVideo:
+			kinds[MediaPhoto] = true
+		case MediaAudio, MediaDocument:
+			kinds[m.Type] = true
+		default:
+			return fmt.Errorf("telegram media group item %d has unsupported type %q", i, m.Type)
+		}
+		if m.Media.IsZero() {
+			return fmt.Errorf("telegram media group item %d has no media", i)
+		}
+	}
+	if len(kinds) > 1 {
+		return fmt.Errorf("telegram media group can only group documents and audio files with their own type")
+	}
+	return nil
+}
+
+// payloadRequests builds the request for a typed payload. v points to a
+// copy of the payload and files to its input files, if any. Without uploads the
+// payload is sent as JSON. Otherwise every field becomes a form field and
+// each upload a file part, named after the field it fills or referenced
+// with attach:// from inside JSON values such as MediaGroup.Media.
+func (p *Provider) payloadRequests(ctx context.Context, method string, v interface{}, files ...*InputFile) ([]*http.Request, error) {
+	var uploads []multipart.File
+	for i, f := range files {
+		if f.upload == nil {
+			continue
+		}
+		f.attach = "file" + strconv.Itoa(i)
+		uploads = append(uploads, multipart.File{Field: f.attach, Attachment: uploadAttachment(*f.upload)})
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	var (
+		r           io.Reader = bytes.NewReader(body)
+		contentType           = "application/json"
+	)
+	if len(uploads) > 0 {
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		fields := make(map[string]string, len(raw))
+		for name, value := range raw {
+			var s string
+			if err := json.Unmarshal(value, &s); err == nil {
+				fields[name] = s
+			} else {
+				fields[name] = string(value)
+			}
+		}
+		for i := range uploads {
+			for name, value := range fields {
+				if value == "attach://"+uploads[i].Field {
+					delete(fields, name)
+					uploads[i].Field = name
+					break
+				}
+			}
+		}
+		r, contentType = multipart.Body(fields, uploads)
+	}
+
+	req, err := p.newRequest(ctx, method, r, contentType)
+	if err != nil {
+		return nil, err
+	}
+	return []*http.Request{req}, nil
+}
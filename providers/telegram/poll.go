@@ -0,0 +1,187 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Poll types.
+const (
+	PollRegular = "regular"
+	PollQuiz    = "quiz"
+)
+
+// Poll is a sendPoll payload:
+//
+//	telegram.Poll{
+//		Question:              "Who can take the pager this weekend?",
+//		Options:               telegram.PollOptions("Saturday", "Sunday", "Neither"),
+//		IsAnonymous:           new(bool),
+//		AllowsMultipleAnswers: true,
+//		OpenPeriod:            600,
+//	}
+type Poll struct {
+	BusinessConnectionID string `json:"business_connection_id,omitempty"`
+	ChatID               string `json:"chat_id"`
+	MessageThreadID      int    `json:"message_thread_id,omitempty"`
+	// Question is 1 to 300 characters.
+	Question          string          `json:"question"`
+	QuestionParseMode string          `json:"question_parse_mode,omitempty"`
+	QuestionEntities  []MessageEntity `json:"question_entities,omitempty"`
+	// Options are the 2 to 12 answer options.
+	Options []InputPollOption `json:"options"`
+	// IsAnonymous hides who voted; nil keeps the default of true. Answers to
+	// polls that are not anonymous arrive as UpdatePollAnswer updates.
+	IsAnonymous *bool `json:"is_anonymous,omitempty"`
+	// Type is PollRegular or PollQuiz. Defaults to PollRegular.
+	Type                  string `json:"type,omitempty"`
+	AllowsMultipleAnswers bool   `json:"allows_multiple_answers,omitempty"`
+	// CorrectOptionID is the index of the right option of a quiz. It is sent
+	// for quizzes only, so the first option can be the correct one.
+	CorrectOptionID int `json:"-"`
+	// Explanation is shown after a wrong quiz answer, up to 200 characters.
+	Explanation          string          `json:"explanation,omitempty"`
+	ExplanationParseMode string          `json:"explanation_parse_mode,omitempty"`
+	ExplanationEntities  []MessageEntity `json:"explanation_entities,omitempty"`
+	// OpenPeriod closes the poll after 5 to 600 seconds. It cannot be
+	// combined with CloseDate.
+	OpenPeriod int `json:"open_period,omitempty"`
+	// CloseDate is the Unix time the poll closes, 5 to 600 seconds ahead.
+	CloseDate int64 `json:"close_date,omitempty"`
+	// IsClosed sends the poll already closed, e.g. to preview it.
+	IsClosed            bool             `json:"is_closed,omitempty"`
+	DisableNotification bool             `json:"disable_notification,omitempty"`
+	ProtectContent      bool             `json:"protect_content,omitempty"`
+	AllowPaidBroadcast  bool             `json:"allow_paid_broadcast,omitempty"`
+	MessageEffectID     string           `json:"message_effect_id,omitempty"`
+	ReplyParameters     *ReplyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup         ReplyMarkup      `json:"reply_markup,omitempty"`
+}
+
+// MarshalJSON adds correct_option_id to quizzes.
+func (p Poll) MarshalJSON() ([]byte, error) {
+	type poll Poll
+	var correct *int
+	if p.Type == PollQuiz {
+		correct = &p.CorrectOptionID
+	}
+	return json.Marshal(struct {
+		poll
+		CorrectOptionID *int `json:"correct_option_id,omitempty"`
+	}{poll(p), correct})
+}
+
+// validate checks the options, quiz answer and closing time of p.
+func (p Poll) validate() error {
+	if p.Question == "" {
+		return fmt.Errorf("telegram poll question is missing")
+	}
+	if n := len(p.Options); n < 2 || n > 12 {
+		return fmt.Errorf("telegram poll needs 2 to 12 options, got %d", n)
+	}
+	if p.Type == PollQuiz {
+		if p.AllowsMultipleAnswers {
+			return fmt.Errorf("telegram quiz cannot allow multiple answers")
+		}
+		if p.CorrectOptionID < 0 || p.CorrectOptionID >= len(p.Options) {
+			return fmt.Errorf("telegram quiz correct option %d is out of range", p.CorrectOptionID)
+		}
+	}
+	if p.OpenPeriod != 0 && p.CloseDate != 0 {
+		return fmt.Errorf("telegram poll cannot have both an open period and a close date")
+	}
+	return nil
+}
+
+// InputPollOption is an answer option of a Poll.
+type InputPollOption struct {
+	// Text is 1 to 100 characters.
+	Text          string          `json:"text"`
+	TextParseMode string          `json:"text_parse_mode,omitempty"`
+	TextEntities  []MessageEntity `json:"text_entities,omitempty"`
+}
+
+// PollOptions returns plain text poll options.
+func PollOptions(texts ...string) []InputPollOption {
+	options := make([]InputPollOption, len(texts))
+	for i, text := range texts {
+		options[i] = InputPollOption{Text: text}
+	}
+	return options
+}
+
+// StopPoll is a stopPoll payload. It closes a poll sent by the bot; the
+// final results arrive as an UpdatePoll update.
+type StopPoll struct {
+	BusinessConnectionID string `json:"business_connection_id,omitempty"`
+	ChatID               string `json:"chat_id"`
+	MessageID            int    `json:"message_id"`
+	// ReplyMarkup replaces the inline keyboard of the poll message.
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+// PollStatus is a poll with its current results, as received in a Message
+// or an UpdatePoll update.
+type PollStatus struct {
+	ID                    string             `json:"id"`
+	Question              string             `json:"question"`
+	Options               []PollOptionStatus `json:"options"`
+	TotalVoterCount       int                `json:"total_voter_count"`
+	IsClosed              bool               `json:"is_closed"`
+	IsAnonymous           bool               `json:"is_anonymous"`
+	Type                  string             `json:"type"`
+	AllowsMultipleAnswers bool               `json:"allows_multiple_answers"`
+	// CorrectOptionID is only known for quizzes sent by the bot and closed quizzes.
+	CorrectOptionID *int `json:"correct_option_id,omitempty"`
+	OpenPeriod      int  `json:"open_period,omitempty"`
+	// CloseDate is the Unix time the poll closes.
+	CloseDate int64 `json:"close_date,omitempty"`
+}
+
+// PollOptionStatus is an answer option of a PollStatus.
+type PollOptionStatus struct {
+	Text       string `json:"text"`
+	VoterCount int    `json:"voter_count"`
+}
+
+// PollAnswer is a vote in a poll that is not anonymous. OptionIDs is
+// empty when the user retracted their vote.
+type PollAnswer struct {
+	PollID string `json:"poll_id"`
+	// VoterChat is set for votes on behalf of a chat, User otherwise.
+	VoterChat *Chat `json:"voter_chat,omitempty"`
+	User      *User `json:"user,omitempty"`
+	OptionIDs []int `json:"option_ids"`
+}
+
+// Dice emoji; each rolls a random value shown as an animation.
+const (
+	DiceDie        = "🎲" // 1 to 6
+	DiceDarts      = "🎯" // 1 to 6
+	DiceBowling    = "🎳" // 1 to 6
+	DiceBasketball = "🏀" // 1 to 5
+	DiceFootball   = "⚽" // 1 to 5
+	DiceSlots      = "🎰" // 1 to 64
+)
+
+// Dice is a sendDice payload. The rolled value is returned in
+// Message.Dice by SendMessages.
+type Dice struct {
+	BusinessConnectionID string `json:"business_connection_id,omitempty"`
+	ChatID               string `json:"chat_id"`
+	MessageThreadID      int    `json:"message_thread_id,omitempty"`
+	// Emoji is one of the Dice constants. Defaults to DiceDie.
+	Emoji               string           `json:"emoji,omitempty"`
+	DisableNotification bool             `json:"disable_notification,omitempty"`
+	ProtectContent      bool             `json:"protect_content,omitempty"`
+	AllowPaidBroadcast  bool             `json:"allow_paid_broadcast,omitempty"`
+	MessageEffectID     string           `json:"message_effect_id,omitempty"`
+	ReplyParameters     *ReplyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup         ReplyMarkup      `json:"reply_markup,omitempty"`
+}
+
+// DiceResult is a rolled dice.
+type DiceResult struct {
+	Emoji string `json:"emoji"`
+	Value int    `json:"value"`
+}
@@ -0,0 +1,138 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/thanpawatpiti/notify"
+)
+
+// maxPollBackoff is the longest wait between failed getUpdates calls.
+const maxPollBackoff = 30 * time.Second
+
+// Poller receives updates with getUpdates long polling and passes them to
+// an UpdateHandler, usually a Router. Updates are handled one at a time, in
+// order; an update is confirmed to Telegram with the next getUpdates call
+// after its handler returns, whether or not the handler failed.
+type Poller struct {
+	provider *Provider
+	handler  UpdateHandler
+	timeout  time.Duration
+	allowed  []string
+	clock    notify.Clock
+	onError  func(error)
+	offset   atomic.Int64
+}
+
+// PollerOption configures a Poller.
+type PollerOption func(*Poller)
+
+// WithPollTimeout configures how long each getUpdates call waits for new
+// updates. The HTTP client must allow requests to take this long. Defaults
+// to 30 seconds.
+func WithPollTimeout(d time.Duration) PollerOption {
+	return func(pl *Poller) {
+		pl.timeout = d
+	}
+}
+
+// WithAllowedUpdates limits the update types received, e.g. UpdateMessage
+// and UpdateCallbackQuery.
+func WithAllowedUpdates(types ...string) PollerOption {
+	return func(pl *Poller) {
+		pl.allowed = types
+	}
+}
+
+// WithPollOffset starts polling at the update ID offset, e.g. one saved
+// from Offset before a restart.
+func WithPollOffset(offset int) PollerOption {
+	return func(pl *Poller) {
+		pl.offset.Store(int64(offset))
+	}
+}
+
+// WithPollerClock configures the clock that times retries. Defaults to notify.SystemClock.
+func WithPollerClock(c notify.Clock) PollerOption {
+	return func(pl *Poller) {
+		pl.clock = c
+	}
+}
+
+// WithPollerErrorHandler configures a callback for handler errors and
+// failed getUpdates calls.
+func WithPollerErrorHandler(f func(error)) PollerOption {
+	return func(pl *Poller) {
+		pl.onError = f
+	}
+}
+
+// NewPoller creates a Poller that receives the updates of p's bot.
+func NewPoller(p *Provider, h UpdateHandler, opts ...PollerOption) *Poller {
+	pl := &Poller{
+		provider: p,
+		handler:  h,
+		timeout:  30 * time.Second,
+		clock:    notify.SystemClock,
+		onError:  func(error) {},
+	}
+
+	for _, opt := range opts {
+		opt(pl)
+	}
+
+	return pl
+}
+
+// Offset returns the ID of the next update to receive.
+func (pl *Poller) Offset() int {
+	return int(pl.offset.Load())
+}
+
+// Run polls for updates until ctx is cancelled. Failed calls are retried
+// with exponential backoff, or after the delay Telegram asks for.
+func (pl *Poller) Run(ctx context.Context) error {
+	backoff := time.Second
+	for {
+		updates, err := pl.provider.GetUpdates(ctx, GetUpdatesParams{
+			Offset:         pl.Offset(),
+			Timeout:        int(pl.timeout / time.Second),
+			AllowedUpdates: pl.allowed,
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			pl.onError(fmt.Errorf("telegram getUpdates: %w", err))
+			wait := backoff
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.Parameters != nil && apiErr.Parameters.RetryAfter > 0 {
+				wait = time.Duration(apiErr.Parameters.RetryAfter) * time.Second
+			}
+			backoff = min(2*backoff, maxPollBackoff)
+			timer, stop := notify.NewTimer(pl.clock, wait)
+			select {
+			case <-ctx.Done():
+				stop()
+				return ctx.Err()
+			case <-timer:
+			}
+			continue
+		}
+		backoff = time.Second
+
+		for i := range updates {
+			u := &updates[i]
+			if err := pl.handler.HandleUpdate(ctx, u); err != nil {
+				pl.onError(fmt.Errorf("telegram update %d: %w", u.UpdateID, err))
+			}
+			pl.offset.Store(int64(u.UpdateID) + 1)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+	}
+}
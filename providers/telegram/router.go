@@ -0,0 +1,183 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// UpdateHandler handles incoming updates. Router, Poller and Webhook use it.
+type UpdateHandler interface {
+	HandleUpdate(ctx context.Context, u *Update) error
+}
+
+// UpdateHandlerFunc adapts a function to the UpdateHandler interface.
+type UpdateHandlerFunc func(ctx context.Context, u *Update) error
+
+// HandleUpdate calls f(ctx, u).
+func (f UpdateHandlerFunc) HandleUpdate(ctx context.Context, u *Update) error {
+	return f(ctx, u)
+}
+
+// MessageHandler handles a message or channel post.
+type MessageHandler func(ctx context.Context, m *Message) error
+
+// CallbackHandler handles a callback query.
+type CallbackHandler func(ctx context.Context, q *CallbackQuery) error
+
+// Router is an UpdateHandler that dispatches messages by bot command and
+// callback queries by the prefix of their data:
+//
+//	r := telegram.NewRouter()
+//	r.Command("status", func(ctx context.Context, m *telegram.Message) error {
+//		return m.Reply(ctx, "All systems operational")
+//	})
+//	r.Callback("ack:", func(ctx context.Context, q *telegram.CallbackQuery) error {
+//		return q.Answer(ctx, "Acknowledged "+strings.TrimPrefix(q.Data, "ack:"))
+//	})
+//
+// Callback queries that are still unanswered after their handler returns,
+// or that no handler matched, are answered without text, so the button
+// stops showing progress. Handlers may register further routes.
+type Router struct {
+	username string
+
+	mu         sync.RWMutex
+	commands   map[string]MessageHandler
+	callbacks  map[string]CallbackHandler
+	onMessage  MessageHandler
+	onCallback CallbackHandler
+	onUpdate   UpdateHandlerFunc
+}
+
+// RouterOption configures a Router.
+type RouterOption func(*Router)
+
+// WithBotUsername ignores commands addressed to other bots, such as
+// "/status@other_bot" in groups with several bots.
+func WithBotUsername(username string) RouterOption {
+	return func(r *Router) {
+		r.username = strings.TrimPrefix(username, "@")
+	}
+}
+
+// NewRouter creates an empty Router.
+func NewRouter(opts ...RouterOption) *Router {
+	r := &Router{
+		commands:  make(map[string]MessageHandler),
+		callbacks: make(map[string]CallbackHandler),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Command registers h for messages starting with the command name, given
+// without the leading slash. Use Message.Command to read its arguments.
+func (r *Router) Command(name string, h MessageHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands[strings.TrimPrefix(name, "/")] = h
+}
+
+// Callback registers h for callback queries whose data starts with prefix.
+// The handler of the longest matching prefix is called.
+func (r *Router) Callback(prefix string, h CallbackHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.callbacks[prefix] = h
+}
+
+// OnMessage registers h for messages and channel posts without a
+// registered command.
+func (r *Router) OnMessage(h MessageHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onMessage = h
+}
+
+// OnCallback registers h for callback queries without a matching prefix.
+func (r *Router) OnCallback(h CallbackHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onCallback = h
+}
+
+// OnUpdate registers h for every other update, such as edited messages.
+func (r *Router) OnUpdate(h UpdateHandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onUpdate = h
+}
+
+// HandleUpdate implements UpdateHandler. Handlers are looked up under the
+// lock and called after it is released.
+func (r *Router) HandleUpdate(ctx context.Context, u *Update) error {
+	r.mu.RLock()
+	var (
+		onCallback CallbackHandler
+		onMessage  MessageHandler
+		m          *Message
+		onUpdate   = r.onUpdate
+	)
+	switch {
+	case u.CallbackQuery != nil:
+		onCallback = r.callback(u.CallbackQuery.Data)
+	case u.Message != nil || u.ChannelPost != nil:
+		m = u.Message
+		if m == nil {
+			m = u.ChannelPost
+		}
+		onMessage = r.message(m)
+	}
+	r.mu.RUnlock()
+
+	switch {
+	case onCallback != nil:
+		return r.answer(ctx, u.CallbackQuery, onCallback(ctx, u.CallbackQuery))
+	case onMessage != nil:
+		return onMessage(ctx, m)
+	}
+	var err error
+	if onUpdate != nil {
+		err = onUpdate(ctx, u)
+	}
+	if u.CallbackQuery != nil {
+		return r.answer(ctx, u.CallbackQuery, err)
+	}
+	return err
+}
+
+// message returns the handler for m.
+func (r *Router) message(m *Message) MessageHandler {
+	name, bot, _ := parseCommand(m.Text)
+	if h, ok := r.commands[name]; ok && name != "" && (bot == "" || r.username == "" || strings.EqualFold(bot, r.username)) {
+		return h
+	}
+	return r.onMessage
+}
+
+// callback returns the handler for callback data.
+func (r *Router) callback(data string) CallbackHandler {
+	best, h := -1, r.onCallback
+	for prefix, ph := range r.callbacks {
+		if len(prefix) > best && strings.HasPrefix(data, prefix) {
+			best, h = len(prefix), ph
+		}
+	}
+	return h
+}
+
+// answer answers q if its handler did not, and returns the handler's err.
+func (r *Router) answer(ctx context.Context, q *CallbackQuery, err error) error {
+	if q.answered || q.provider == nil {
+		return err
+	}
+	if answerErr := q.Answer(ctx, ""); err == nil {
+		return answerErr
+	}
+	return err
+}
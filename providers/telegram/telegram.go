@@ -5,18 +5,46 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/thanpawatpiti/notify"
+	"github.com/thanpawatpiti/notify/format"
+	"github.com/thanpawatpiti/notify/internal/multipart"
 )
 
 const telegramAPIBase = "https://api.telegram.org/bot"
 
+// Name identifies this provider to a notify.MentionResolver.
+const Name = "telegram"
+
 // Provider implements the Notifier interface for Telegram.
 type Provider struct {
 	token  string
 	chatID string
 	opts   notify.Options
+	config options
+	thread *int // forum topic replacing WithTopic and its routes; see inChat
+}
+
+// options are the Telegram-specific settings given to New.
+type options struct {
+	parseMode   *string // nil chooses the mode by format
+	topic       int
+	topicRoutes map[string]int
+}
+
+// WithParseMode sets the parse mode used for string and CommonMessage
+// payloads: ParseModeMarkdownV2, ParseModeHTML, or ParseModeNone to send
+// plain text with links written as "label (url)". Content is converted and
+// escaped for the chosen mode whatever its notify.Format. By default HTML
+// content is sent as HTML and everything else as MarkdownV2.
+func WithParseMode(mode string) notify.Option {
+	return notify.ProviderOption(func(o *options) {
+		o.parseMode = &mode
+	})
 }
 
 // New creates a new Telegram provider.
@@ -32,34 +60,107 @@ func New(token, chatID string, opts ...notify.Option) *Provider {
 	for _, opt := range opts {
 		opt(&p.opts)
 	}
+	notify.ApplyProviderOptions(&p.opts, &p.config)
 
 	return p
 }
 
 // Send sends a message via Telegram.
 // payload can be:
-// - string: Simple text message.
-// - notify.CommonMessage: Generic rich message (Text + Image).
+// - string: Simple text message, interpreted as notify.FormatMarkdown.
+// - notify.CommonMessage: Generic rich message (Text + Image + Attachments).
+// - *telegram.Text: Formatted text, sent with message entities.
 // - telegram.Payload: Full API payload.
+// - telegram.Photo, Document, Video, Audio, Animation, Voice: A file by URL, file_id or upload.
+// - telegram.MediaGroup: An album of 2 to 10 photos, videos, documents or audio files.
+// - telegram.Poll, StopPoll: A poll or quiz, and closing it early.
+// - telegram.Location, EditLiveLocation, StopLiveLocation: A point or live location, and its updates.
+// - telegram.Venue, Contact, Dice: A venue, phone contact or animated dice.
+// - telegram.ChatAction: A status such as "typing" while the bot is busy; see KeepChatAction.
+//
+// String and CommonMessage content is converted to MarkdownV2 (or HTML when
+// the message Format is notify.FormatHTML) with all reserved characters escaped.
+// CommonMessage attachments are uploaded after the text with sendPhoto for
+// images and sendDocument for everything else. Mentions resolved to numeric
+// user IDs become inline mention links (text_mention entities); @usernames
+// are written as-is.
 func (p *Provider) Send(ctx context.Context, payload interface{}) error {
+	_, err := p.SendMessages(ctx, payload)
+	return err
+}
+
+// SendMessages sends payload like Send and returns the messages Telegram
+// created, one per album item. Their file IDs (see Message.FileID) can be
+// passed to FileID to send the same files again without uploading them.
+// Chat actions, stopped polls and edits of inline messages return no message.
+func (p *Provider) SendMessages(ctx context.Context, payload interface{}) ([]Message, error) {
+	reqs, err := p.Render(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var sent []Message
+	for _, req := range reqs {
+		var result json.RawMessage
+		if err := p.do(req, &result); err != nil {
+			return sent, err
+		}
+		switch {
+		case bytes.HasPrefix(result, []byte("[")):
+			var msgs []Message
+			if err := json.Unmarshal(result, &msgs); err != nil {
+				return sent, fmt.Errorf("failed to decode response: %w", err)
+			}
+			sent = append(sent, msgs...)
+		case bytes.HasPrefix(result, []byte("{")):
+			var msg Message
+			if err := json.Unmarshal(result, &msg); err != nil {
+				return sent, fmt.Errorf("failed to decode response: %w", err)
+			}
+			if msg.MessageID != 0 {
+				sent = append(sent, msg)
+			}
+		}
+	}
+
+	return sent, nil
+}
+
+// Render builds the Bot API requests Send would make for payload, in order,
+// without sending them. Attachments add one request each after the text.
+func (p *Provider) Render(ctx context.Context, payload interface{}) ([]*http.Request, error) {
 	if p.token == "" || p.chatID == "" {
-		return fmt.Errorf("telegram token or chatID is missing")
+		return nil, fmt.Errorf("telegram token or chatID is missing")
 	}
 
 	var method string = "sendMessage"
 	var reqPayload Payload
+	var attachments []notify.Attachment
 
 	switch v := payload.(type) {
 	case string:
+		text, parseMode, err := p.renderText(format.ParseMarkdown(v), notify.FormatMarkdown)
+		if err != nil {
+			return nil, err
+		}
 		reqPayload = Payload{
 			ChatID:    p.chatID,
-			Text:      v,
-			ParseMode: "Markdown",
+			Text:      text,
+			ParseMode: parseMode,
 		}
 	case notify.CommonMessage:
-		text := v.Content
-		if v.Title != "" {
-			text = fmt.Sprintf("*%s*\n%s", v.Title, v.Content)
+		attachments = v.Attachments
+		if v.Title == "" && v.Content == "" && v.ImageURL == "" && len(attachments) > 0 {
+			return p.attachmentRequests(ctx, p.chatID, p.topic(ctx, payload, 0), attachments)
+		}
+		mentions, err := notify.ResolveMentions(ctx, p.opts.MentionResolver, Name, v.Mentions)
+		if err != nil {
+			return nil, err
+		}
+		doc := format.Parse(v.Content, v.Format).PrependLine(mentionNodes(mentions)...).WithTitle(v.Title)
+		text, parseMode, err := p.renderText(doc, v.Format)
+		if err != nil {
+			return nil, err
 		}
 		if v.ImageURL != "" {
 			method = "sendPhoto"
@@ -67,50 +168,319 @@ func (p *Provider) Send(ctx context.Context, payload interface{}) error {
 				ChatID:    p.chatID,
 				Photo:     v.ImageURL,
 				Caption:   text,
-				ParseMode: "Markdown",
+				ParseMode: parseMode,
 			}
 		} else {
 			reqPayload = Payload{
 				ChatID:    p.chatID,
 				Text:      text,
-				ParseMode: "Markdown",
+				ParseMode: parseMode,
 			}
 		}
+	case *Text:
+		text, entities := v.Entities()
+		reqPayload = Payload{
+			ChatID:   p.chatID,
+			Text:     text,
+			Entities: entities,
+		}
 	case Payload:
 		reqPayload = v
+		reqPayload.upgrade()
 		if reqPayload.ChatID == "" {
 			reqPayload.ChatID = p.chatID
 		}
 		if reqPayload.Photo != "" {
 			method = "sendPhoto"
 		}
+	case Photo:
+		if v.Photo.IsZero() {
+			return nil, fmt.Errorf("telegram photo is missing")
+		}
+		v.ChatID = p.chat(v.ChatID)
+		v.MessageThreadID = p.topic(ctx, payload, v.MessageThreadID)
+		return p.payloadRequests(ctx, "sendPhoto", &v, &v.Photo)
+	case Document:
+		if v.Document.IsZero() {
+			return nil, fmt.Errorf("telegram document is missing")
+		}
+		v.ChatID = p.chat(v.ChatID)
+		v.MessageThreadID = p.topic(ctx, payload, v.MessageThreadID)
+		return p.payloadRequests(ctx, "sendDocument", &v, &v.Document, &v.Thumbnail)
+	case Video:
+		if v.Video.IsZero() {
+			return nil, fmt.Errorf("telegram video is missing")
+		}
+		v.ChatID = p.chat(v.ChatID)
+		v.MessageThreadID = p.topic(ctx, payload, v.MessageThreadID)
+		return p.payloadRequests(ctx, "sendVideo", &v, &v.Video, &v.Thumbnail)
+	case Audio:
+		if v.Audio.IsZero() {
+			return nil, fmt.Errorf("telegram audio is missing")
+		}
+		v.ChatID = p.chat(v.ChatID)
+		v.MessageThreadID = p.topic(ctx, payload, v.MessageThreadID)
+		return p.payloadRequests(ctx, "sendAudio", &v, &v.Audio, &v.Thumbnail)
+	case Animation:
+		if v.Animation.IsZero() {
+			return nil, fmt.Errorf("telegram animation is missing")
+		}
+		v.ChatID = p.chat(v.ChatID)
+		v.MessageThreadID = p.topic(ctx, payload, v.MessageThreadID)
+		return p.payloadRequests(ctx, "sendAnimation", &v, &v.Animation, &v.Thumbnail)
+	case Voice:
+		if v.Voice.IsZero() {
+			return nil, fmt.Errorf("telegram voice is missing")
+		}
+		v.ChatID = p.chat(v.ChatID)
+		v.MessageThreadID = p.topic(ctx, payload, v.MessageThreadID)
+		return p.payloadRequests(ctx, "sendVoice", &v, &v.Voice)
+	case MediaGroup:
+		if err := v.validate(); err != nil {
+			return nil, err
+		}
+		v.ChatID = p.chat(v.ChatID)
+		v.MessageThreadID = p.topic(ctx, payload, v.MessageThreadID)
+		// Copy the items, since Render assigns upload names to them.
+		v.Media = append([]InputMedia(nil), v.Media...)
+		files := make([]*InputFile, 0, 2*len(v.Media))
+		for i := range v.Media {
+			files = append(files, &v.Media[i].Media, &v.Media[i].Thumbnail)
+		}
+		return p.payloadRequests(ctx, "sendMediaGroup", &v, files...)
+	case Poll:
+		if err := v.validate(); err != nil {
+			return nil, err
+		}
+		v.ChatID = p.chat(v.ChatID)
+		v.MessageThreadID = p.topic(ctx, payload, v.MessageThreadID)
+		return p.payloadRequests(ctx, "sendPoll", &v)
+	case StopPoll:
+		if v.MessageID == 0 {
+			return nil, fmt.Errorf("telegram message ID is missing")
+		}
+		v.ChatID = p.chat(v.ChatID)
+		return p.payloadRequests(ctx, "stopPoll", &v)
+	case Location:
+		v.ChatID = p.chat(v.ChatID)
+		v.MessageThreadID = p.topic(ctx, payload, v.MessageThreadID)
+		return p.payloadRequests(ctx, "sendLocation", &v)
+	case EditLiveLocation:
+		chatID, err := p.editChat(v.ChatID, v.MessageID, v.InlineMessageID)
+		if err != nil {
+			return nil, err
+		}
+		v.ChatID = chatID
+		return p.payloadRequests(ctx, "editMessageLiveLocation", &v)
+	case StopLiveLocation:
+		chatID, err := p.editChat(v.ChatID, v.MessageID, v.InlineMessageID)
+		if err != nil {
+			return nil, err
+		}
+		v.ChatID = chatID
+		return p.payloadRequests(ctx, "stopMessageLiveLocation", &v)
+	case Venue:
+		if v.Title == "" || v.Address == "" {
+			return nil, fmt.Errorf("telegram venue title or address is missing")
+		}
+		v.ChatID = p.chat(v.ChatID)
+		v.MessageThreadID = p.topic(ctx, payload, v.MessageThreadID)
+		return p.payloadRequests(ctx, "sendVenue", &v)
+	case Contact:
+		if v.PhoneNumber == "" || v.FirstName == "" {
+			return nil, fmt.Errorf("telegram contact phone number or first name is missing")
+		}
+		v.ChatID = p.chat(v.ChatID)
+		v.MessageThreadID = p.topic(ctx, payload, v.MessageThreadID)
+		return p.payloadRequests(ctx, "sendContact", &v)
+	case Dice:
+		v.ChatID = p.chat(v.ChatID)
+		v.MessageThreadID = p.topic(ctx, payload, v.MessageThreadID)
+		return p.payloadRequests(ctx, "sendDice", &v)
+	case ChatAction:
+		if v.Action == "" {
+			return nil, fmt.Errorf("telegram chat action is missing")
+		}
+		v.ChatID = p.chat(v.ChatID)
+		v.MessageThreadID = p.topic(ctx, payload, v.MessageThreadID)
+		return p.payloadRequests(ctx, "sendChatAction", &v)
 	default:
-		return fmt.Errorf("unsupported payload type: %T", v)
+		return nil, fmt.Errorf("unsupported payload type: %T", v)
 	}
 
-	url := fmt.Sprintf("%s%s/%s", telegramAPIBase, p.token, method)
+	reqPayload.MessageThreadID = p.topic(ctx, payload, reqPayload.MessageThreadID)
 
 	body, err := json.Marshal(reqPayload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := p.newRequest(ctx, method, bytes.NewReader(body), "application/json")
+	if err != nil {
+		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	files, err := p.attachmentRequests(ctx, reqPayload.ChatID, reqPayload.MessageThreadID, attachments)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	return append([]*http.Request{req}, files...), nil
+}
+
+// chat returns chatID, or the provider's chat when it is empty.
+func (p *Provider) chat(chatID string) string {
+	if chatID == "" {
+		return p.chatID
+	}
+	return chatID
+}
 
+// attachmentRequests builds a sendPhoto or sendDocument upload for each attachment.
+func (p *Provider) attachmentRequests(ctx context.Context, chatID string, threadID int, attachments []notify.Attachment) ([]*http.Request, error) {
+	fields := map[string]string{"chat_id": chatID}
+	if threadID != 0 {
+		fields["message_thread_id"] = strconv.Itoa(threadID)
+	}
+	reqs := make([]*http.Request, 0, len(attachments))
+	for _, a := range attachments {
+		method, field := "sendDocument", "document"
+		if a.IsImage() {
+			method, field = "sendPhoto", "photo"
+		}
+		body, contentType := multipart.Body(fields, []multipart.File{{Field: field, Attachment: a}})
+		req, err := p.newRequest(ctx, method, body, contentType)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+// newRequest creates a POST request for the given Bot API method.
+func (p *Provider) newRequest(ctx context.Context, method string, body io.Reader, contentType string) (*http.Request, error) {
+	url := fmt.Sprintf("%s%s/%s", telegramAPIBase, p.token, method)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	return req, nil
+}
+
+// call sends a Bot API request with in as the JSON body and decodes the
+// result into out unless out is nil.
+func (p *Provider) call(ctx context.Context, method string, in, out interface{}) error {
+	if p.token == "" {
+		return fmt.Errorf("telegram token is missing")
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	req, err := p.newRequest(ctx, method, bytes.NewReader(body), "application/json")
+	if err != nil {
+		return err
+	}
+	return p.do(req, out)
+}
+
+// do sends req, checks the response and decodes its result into out
+// unless out is nil.
+func (p *Provider) do(req *http.Request, out interface{}) error {
 	resp, err := p.opts.HTTPClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("telegram api returned status: %d", resp.StatusCode)
+	var body struct {
+		OK     bool            `json:"ok"`
+		Result json.RawMessage `json:"result"`
+		APIError
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode != http.StatusOK || (err == nil && !body.OK) {
+		body.APIError.StatusCode = resp.StatusCode
+		return &body.APIError
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body.Result, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
 	}
-
 	return nil
 }
+
+// APIError is an error response from the Bot API.
+type APIError struct {
+	StatusCode int `json:"-"`
+	// Description explains the error, e.g. "Bad Request: chat not found".
+	Description string `json:"description"`
+	// Parameters tell how the request can be repeated successfully.
+	Parameters *ResponseParameters `json:"parameters"`
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("telegram api returned status: %d", e.StatusCode)
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// ResponseParameters describe why a request failed.
+type ResponseParameters struct {
+	// MigrateToChatID is the new ID of a group upgraded to a supergroup.
+	MigrateToChatID int64 `json:"migrate_to_chat_id,omitempty"`
+	// RetryAfter is the number of seconds to wait when rate limited.
+	RetryAfter int `json:"retry_after,omitempty"`
+}
+
+// mentionNodes renders mentions as a space separated line. Numeric IDs use
+// tg://user links, which Telegram turns into text_mention entities.
+func mentionNodes(mentions []notify.ResolvedMention) []*format.Node {
+	var nodes []*format.Node
+	for i, m := range mentions {
+		if i > 0 {
+			nodes = append(nodes, format.Text(" "))
+		}
+		if _, err := strconv.ParseInt(m.ID, 10, 64); err == nil {
+			nodes = append(nodes, format.Link(m.DisplayName, "tg://user?id="+m.ID))
+		} else {
+			nodes = append(nodes, format.Text("@"+strings.TrimPrefix(m.ID, "@")))
+		}
+	}
+	return nodes
+}
+
+// renderText renders doc for Telegram and returns the text with its parse
+// mode. Without WithParseMode, HTML input is kept as HTML and everything
+// else is rendered as MarkdownV2.
+func (p *Provider) renderText(doc *format.Document, f notify.Format) (string, string, error) {
+	mode := ParseModeMarkdownV2
+	if f == notify.FormatHTML {
+		mode = ParseModeHTML
+	}
+	if p.config.parseMode != nil {
+		mode = *p.config.parseMode
+	}
+
+	switch mode {
+	case ParseModeMarkdownV2:
+		return format.TelegramMarkdownV2.Render(doc), mode, nil
+	case ParseModeHTML:
+		return format.TelegramHTML.Render(doc), mode, nil
+	case ParseModeNone:
+		return format.Plain.Render(doc), mode, nil
+	default:
+		return "", "", fmt.Errorf("telegram parse mode %q cannot be generated; use MarkdownV2, HTML or none", mode)
+	}
+}
@@ -2,31 +2,36 @@ package telegram
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/thanpawatpiti/notify"
+	"github.com/thanpawatpiti/notify/notifytest"
 )
 
 func TestSend(t *testing.T) {
-	client := &http.Client{
-		Transport: &mockTransport{
-			roundTrip: func(req *http.Request) (*http.Response, error) {
-				return &http.Response{
-					StatusCode: http.StatusOK,
-					Body:       http.NoBody,
-				}, nil
-			},
-		},
-	}
-
-	p := New("test-token", "test-chat", notify.WithHTTPClient(client))
+	srv := notifytest.NewTelegramServer(t)
+	p := New("test-token", "test-chat", notify.WithHTTPClient(srv.Client()))
 
 	// Test 1: CommonMessage
 	err := p.Send(context.Background(), notify.CommonMessage{Content: "test"})
 	if err != nil {
 		t.Errorf("CommonMessage: expected no error, got %v", err)
 	}
+	if req := srv.LastRequest(t); req.Path != "/bottest-token/sendMessage" {
+		t.Errorf("unexpected path %s", req.Path)
+	}
 
 	// Test 2: Payload
 	payload := Payload{
@@ -37,12 +42,780 @@ func TestSend(t *testing.T) {
 	if err != nil {
 		t.Errorf("Payload: expected no error, got %v", err)
 	}
+
+	// Test 3: API error
+	srv.Fail(http.StatusBadRequest, "Bad Request: can't parse entities")
+	if err := p.Send(context.Background(), "test"); err == nil {
+		t.Errorf("expected error for 400 response")
+	}
+}
+
+func TestSendAttachments(t *testing.T) {
+	srv := notifytest.NewTelegramServer(t)
+	p := New("test-token", "test-chat", notify.WithHTTPClient(srv.Client()))
+
+	err := p.Send(context.Background(), notify.CommonMessage{
+		Content: "Nightly report",
+		Attachments: []notify.Attachment{
+			{Name: "report.csv", Data: []byte("a,b\n1,2")},
+			{Name: "graph.png", Reader: strings.NewReader("png")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var methods []string
+	for _, req := range srv.Requests() {
+		methods = append(methods, path.Base(req.Path))
+	}
+	if want := []string{"sendMessage", "sendDocument", "sendPhoto"}; strings.Join(methods, ",") != strings.Join(want, ",") {
+		t.Errorf("expected methods %v, got %v", want, methods)
+	}
+
+	form, err := srv.LastRequest(t).Multipart()
+	if err != nil {
+		t.Fatalf("failed to parse multipart body: %v", err)
+	}
+	if form.Value["chat_id"][0] != "test-chat" || form.File["photo"][0].Filename != "graph.png" {
+		t.Errorf("unexpected form %v", form.Value)
+	}
+}
+
+func TestRender(t *testing.T) {
+	p := New("test-token", "test-chat")
+
+	tests := []struct {
+		name    string
+		payload interface{}
+	}{
+		{"string", "*Deploy* finished for api_server (v1.2.0)"},
+		{"common_message", notify.CommonMessage{
+			Title:       "Nightly report",
+			Content:     "Backups **completed** in 3.5 minutes. See [logs](https://example.com/logs?id=1).",
+			Mentions:    []notify.Mention{{Name: "42"}, {Name: "oncall"}},
+			Attachments: []notify.Attachment{{Name: "report.csv", Data: []byte("a,b\n1,2\n")}},
+		}},
+		{"payload", Payload{Text: "<b>raw</b> html", ParseMode: ParseModeHTML}},
+		{"text", NewText().Bold("Deploy failed").Plain(": ").Code("exit status 1").Blockquote("see runbook")},
+		{"reply_options", Payload{
+			Text:                  "Which service?",
+			ReplyToMessageID:      42,
+			DisableWebPagePreview: true,
+			ProtectContent:        true,
+			MessageEffectID:       "5104841245755180586",
+			ReplyMarkup:           ForceReply{InputFieldPlaceholder: "service name"},
+		}},
+		{"quiz", Poll{
+			Question:        "Which port does the metrics endpoint use?",
+			Options:         PollOptions("9090", "8080", "443"),
+			Type:            PollQuiz,
+			CorrectOptionID: 0,
+			Explanation:     "See the runbook",
+			OpenPeriod:      60,
+		}},
+		{"media_group", MediaGroup{Media: []InputMedia{
+			{Type: MediaPhoto, Media: FileURL("https://example.com/before.png"), Caption: "Before"},
+			{Type: MediaPhoto, Media: FileID("AgADBAADbqcxG"), HasSpoiler: true},
+			{Type: MediaVideo, Media: FileUpload(notify.Attachment{Name: "after.mp4", Data: []byte("mp4")})},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqs, err := p.Render(context.Background(), tt.payload)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			notifytest.GoldenRequests(t, tt.name, reqs)
+		})
+	}
+}
+
+func TestSendMedia(t *testing.T) {
+	srv := notifytest.NewTelegramServer(t)
+	p := New("test-token", "test-chat", notify.WithHTTPClient(srv.Client()))
+	ctx := context.Background()
+
+	// URLs and file IDs are sent as JSON.
+	sent, err := p.SendMessages(ctx, Document{Document: FileURL("https://example.com/report.pdf"), Caption: "Report"})
+	if err != nil {
+		t.Fatalf("Document: %v", err)
+	}
+	req := srv.LastRequest(t)
+	var body map[string]interface{}
+	if err := req.JSON(&body); err != nil || req.Path != "/bottest-token/sendDocument" || body["document"] != "https://example.com/report.pdf" {
+		t.Errorf("unexpected request %s %v (%v)", req.Path, body, err)
+	}
+	if len(sent) != 1 || sent[0].FileID() != "file-1" {
+		t.Fatalf("expected file-1, got %+v", sent)
+	}
+
+	// Uploads are sent as multipart form files named after their field.
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "clip.mp4"), []byte("mp4 data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	video := Video{
+		Video:     FilePath(filepath.Join(dir, "clip.mp4")),
+		Thumbnail: FileUpload(notify.Attachment{Name: "thumb.jpg", Data: []byte("jpeg")}),
+		Duration:  12,
+	}
+	// Files given by path are reopened for every send.
+	for i := range 2 {
+		if _, err := p.SendMessages(ctx, video); err != nil {
+			t.Fatalf("Video send %d: %v", i+1, err)
+		}
+		form, err := srv.LastRequest(t).Multipart()
+		if err != nil {
+			t.Fatalf("failed to parse multipart body: %v", err)
+		}
+		if form.File["video"][0].Filename != "clip.mp4" || form.File["thumbnail"][0].Filename != "thumb.jpg" {
+			t.Errorf("unexpected files %v", form.File)
+		}
+		if f, err := form.File["video"][0].Open(); err != nil {
+			t.Errorf("failed to open video part: %v", err)
+		} else if data, _ := io.ReadAll(f); string(data) != "mp4 data" {
+			t.Errorf("send %d uploaded %q", i+1, data)
+		}
+		if _, ok := form.Value["video"]; ok || form.Value["duration"][0] != "12" {
+			t.Errorf("unexpected fields %v", form.Value)
+		}
+	}
+
+	// Album items reference uploads with attach://.
+	sent, err = p.SendMessages(ctx, MediaGroup{Media: []InputMedia{
+		{Type: MediaDocument, Media: FileID(sent[0].FileID())},
+		{Type: MediaDocument, Media: FileUpload(notify.Attachment{Name: "b.csv", Data: []byte("b")})},
+	}})
+	if err != nil {
+		t.Fatalf("MediaGroup: %v", err)
+	}
+	form, err := srv.LastRequest(t).Multipart()
+	if err != nil {
+		t.Fatalf("failed to parse multipart body: %v", err)
+	}
+	var media []map[string]interface{}
+	if err := json.Unmarshal([]byte(form.Value["media"][0]), &media); err != nil {
+		t.Fatalf("failed to decode media: %v", err)
+	}
+	if media[1]["media"] != "attach://file2" || form.File["file2"][0].Filename != "b.csv" {
+		t.Errorf("unexpected media %v", media)
+	}
+	if len(sent) != 2 || sent[1].FileID() == "" || sent[0].MediaGroupID == "" {
+		t.Errorf("expected two album messages, got %+v", sent)
+	}
+
+	// Albums are validated before sending.
+	srv.Reset()
+	photo := InputMedia{Type: MediaPhoto, Media: FileURL("https://example.com/a.png")}
+	document := InputMedia{Type: MediaDocument, Media: FileURL("https://example.com/a.pdf")}
+	for _, g := range []MediaGroup{
+		{Media: []InputMedia{photo}},
+		{Media: make([]InputMedia, 11)},
+		{Media: []InputMedia{photo, document}},
+		{Media: []InputMedia{photo, {Type: MediaPhoto}}},
+	} {
+		if err := p.Send(ctx, g); err == nil {
+			t.Errorf("expected error for %d items", len(g.Media))
+		}
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("expected no requests, got %d", n)
+	}
+
+	// API errors carry the description.
+	srv.Fail(http.StatusBadRequest, "Bad Request: wrong file identifier")
+	var apiErr *APIError
+	if err := p.Send(ctx, Voice{Voice: FileID("bad")}); !errors.As(err, &apiErr) || apiErr.Description != "Bad Request: wrong file identifier" {
+		t.Errorf("expected APIError, got %v", err)
+	}
+}
+
+func TestPolls(t *testing.T) {
+	srv := notifytest.NewTelegramServer(t)
+	p := New("test-token", "test-chat", notify.WithHTTPClient(srv.Client()))
+	ctx := context.Background()
+
+	// Polls that are not anonymous report votes; their ID comes back in the message.
+	sent, err := p.SendMessages(ctx, Poll{
+		Question:              "Who can take the pager this weekend?",
+		Options:               PollOptions("Saturday", "Sunday", "Neither"),
+		IsAnonymous:           new(bool),
+		AllowsMultipleAnswers: true,
+		CloseDate:             1767225600,
+	})
+	if err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	var body map[string]interface{}
+	srv.LastRequest(t).JSON(&body)
+	if body["is_anonymous"] != false || body["close_date"] != float64(1767225600) {
+		t.Errorf("unexpected body %v", body)
+	}
+	if _, ok := body["correct_option_id"]; ok {
+		t.Errorf("expected no correct_option_id for regular polls, got %v", body)
+	}
+	if len(sent) != 1 || sent[0].Poll == nil || sent[0].Poll.ID != "poll-1" || len(sent[0].Poll.Options) != 3 || sent[0].Poll.IsAnonymous {
+		t.Fatalf("unexpected poll message %+v", sent)
+	}
+
+	// Stopping returns the closed poll, not a message.
+	sent, err = p.SendMessages(ctx, StopPoll{MessageID: sent[0].MessageID})
+	if err != nil || len(sent) != 0 {
+		t.Errorf("StopPoll: %v %+v", err, sent)
+	}
+	if req := srv.LastRequest(t); req.Path != "/bottest-token/stopPoll" {
+		t.Errorf("unexpected request %s", req.Path)
+	}
+
+	// Polls are validated before sending.
+	srv.Reset()
+	for _, poll := range []Poll{
+		{Question: "?", Options: PollOptions("yes")},
+		{Question: "?", Options: PollOptions("a", "b"), Type: PollQuiz, CorrectOptionID: 2},
+		{Question: "?", Options: PollOptions("a", "b"), Type: PollQuiz, AllowsMultipleAnswers: true},
+		{Question: "?", Options: PollOptions("a", "b"), OpenPeriod: 60, CloseDate: 1767225600},
+		{Options: PollOptions("a", "b")},
+	} {
+		if err := p.Send(ctx, poll); err == nil {
+			t.Errorf("expected error for %+v", poll)
+		}
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("expected no requests, got %d", n)
+	}
+
+	// Live locations are edited by message ID or inline message ID.
+	sent, err = p.SendMessages(ctx, Location{Latitude: 13.7563, Longitude: 100.5018, LivePeriod: LivePeriodForever})
+	if err != nil || len(sent) != 1 || sent[0].Location == nil || sent[0].Location.Latitude != 13.7563 {
+		t.Fatalf("Location: %v %+v", err, sent)
+	}
+	if err := p.Send(ctx, EditLiveLocation{MessageID: sent[0].MessageID, Latitude: 13.75, Longitude: 100.5, Heading: 90}); err != nil {
+		t.Fatalf("EditLiveLocation: %v", err)
+	}
+	if err := p.Send(ctx, StopLiveLocation{InlineMessageID: "inline-1"}); err != nil {
+		t.Fatalf("StopLiveLocation: %v", err)
+	}
+	reqs := srv.Requests()
+	var edit, stop map[string]interface{}
+	reqs[1].JSON(&edit)
+	reqs[2].JSON(&stop)
+	if reqs[1].Path != "/bottest-token/editMessageLiveLocation" || edit["chat_id"] != "test-chat" || edit["heading"] != float64(90) {
+		t.Errorf("unexpected edit %s %v", reqs[1].Path, edit)
+	}
+	if _, ok := stop["chat_id"]; ok || stop["inline_message_id"] != "inline-1" {
+		t.Errorf("unexpected stop %v", stop)
+	}
+	if err := p.Send(ctx, StopLiveLocation{}); err == nil {
+		t.Error("expected error for a live location without a message")
+	}
+
+	// Venues, contacts and dice are plain JSON payloads.
+	for _, payload := range []interface{}{
+		Venue{Latitude: 13.7, Longitude: 100.5, Title: "War room", Address: "Floor 7"},
+		Contact{PhoneNumber: "+6620000000", FirstName: "On-call"},
+		Dice{Emoji: DiceDarts},
+	} {
+		if err := p.Send(ctx, payload); err != nil {
+			t.Errorf("%T: %v", payload, err)
+		}
+	}
+	if err := p.Send(ctx, Venue{Title: "War room"}); err == nil {
+		t.Error("expected error for a venue without an address")
+	}
+	sent, err = p.SendMessages(ctx, Dice{})
+	if err != nil || len(sent) != 1 || sent[0].Dice == nil || sent[0].Dice.Value < 1 || sent[0].Dice.Value > 6 {
+		t.Errorf("Dice: %v %+v", err, sent)
+	}
+
+	// Chat actions return no message and are repeated until stopped.
+	srv.Reset()
+	clock := notifytest.NewClock(time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC))
+	actionErrs := make(chan error, 1)
+	stopTyping, err := p.KeepChatAction(ctx, ChatAction{Action: ChatActionTyping, MessageThreadID: 7},
+		WithChatActionClock(clock),
+		WithChatActionErrorHandler(func(err error) { actionErrs <- err }))
+	if err != nil {
+		t.Fatalf("KeepChatAction: %v", err)
+	}
+	if !clock.BlockUntil(1, time.Second) {
+		t.Fatal("expected KeepChatAction to wait for the next repeat")
+	}
+	srv.Fail(http.StatusBadRequest, "Bad Request: chat not found")
+	clock.Advance(chatActionInterval)
+	select {
+	case err := <-actionErrs:
+		if !strings.Contains(err.Error(), "chat not found") {
+			t.Errorf("unexpected repeat error %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the failed repeat to be reported")
+	}
+	if !clock.BlockUntil(1, time.Second) {
+		t.Fatal("expected KeepChatAction to keep repeating after an error")
+	}
+	stopTyping()
+	if n := len(srv.Requests()); n != 2 {
+		t.Errorf("expected 2 chat actions, got %d", n)
+	}
+	stopTyping()
+	req := srv.LastRequest(t)
+	body = nil
+	req.JSON(&body)
+	if req.Path != "/bottest-token/sendChatAction" || body["action"] != "typing" || body["message_thread_id"] != float64(7) {
+		t.Errorf("unexpected request %s %v", req.Path, body)
+	}
+	if err := p.Send(ctx, ChatAction{}); err == nil {
+		t.Error("expected error for a chat action without an action")
+	}
+
+	// Votes arrive as poll_answer updates.
+	var u Update
+	if err := json.Unmarshal([]byte(`{"update_id":1,"poll_answer":{"poll_id":"poll-1","user":{"id":42,"is_bot":false,"first_name":"Ann"},"option_ids":[0,1]}}`), &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.PollAnswer == nil || u.PollAnswer.User.ID != 42 || len(u.PollAnswer.OptionIDs) != 2 {
+		t.Errorf("unexpected update %+v", u)
+	}
+}
+
+func TestForumTopics(t *testing.T) {
+	srv := notifytest.NewTelegramServer(t)
+	ctx := context.Background()
+	p := New("test-token", "-100123", notify.WithHTTPClient(srv.Client()),
+		WithTopic(1),
+		WithTopicRoutes(map[string]int{"service=payments": 12, "team=core": 7}))
+
+	topic, err := p.CreateForumTopic(ctx, ForumTopic{Name: "payments", IconColor: TopicColorRed})
+	if err != nil {
+		t.Fatalf("CreateForumTopic: %v", err)
+	}
+	if topic.MessageThreadID == 0 || topic.Name != "payments" || topic.IconColor != TopicColorRed {
+		t.Errorf("unexpected topic %+v", topic)
+	}
+
+	if err := p.EditForumTopic(ctx, topic.MessageThreadID, ForumTopicEdit{Name: "payments-prod"}); err != nil {
+		t.Fatalf("EditForumTopic: %v", err)
+	}
+	var body map[string]interface{}
+	srv.LastRequest(t).JSON(&body)
+	if _, ok := body["icon_custom_emoji_id"]; ok || body["name"] != "payments-prod" || body["message_thread_id"] != float64(topic.MessageThreadID) {
+		t.Errorf("unexpected edit %v", body)
+	}
+	if err := p.CloseForumTopic(ctx, topic.MessageThreadID); err != nil {
+		t.Fatalf("CloseForumTopic: %v", err)
+	}
+	if req := srv.LastRequest(t); req.Path != "/bottest-token/closeForumTopic" {
+		t.Errorf("unexpected path %s", req.Path)
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		payload interface{}
+		want    string
+	}{
+		{"label", ctx, notify.CommonMessage{Content: "down", Labels: map[string]string{"service": "payments"}}, "12"},
+		{"context labels", notify.ContextWithLabels(ctx, map[string]string{"team": "core"}), "down", "7"},
+		{"first sorted label", ctx, notify.CommonMessage{Content: "down", Labels: map[string]string{"team": "core", "service": "payments"}}, "12"},
+		{"default topic", ctx, "down", "1"},
+		{"explicit thread", ctx, Payload{Text: "down", MessageThreadID: 99}, "99"},
+		{"media", ctx, Document{Document: FileID("doc")}, "1"},
+		{"attachments", notify.ContextWithLabels(ctx, map[string]string{"team": "core"}),
+			notify.CommonMessage{Attachments: []notify.Attachment{{Name: "a.txt", Data: []byte("a")}}}, "7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := p.Send(tt.ctx, tt.payload); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			req := srv.LastRequest(t)
+			var got string
+			if form, err := req.Multipart(); err == nil {
+				got = form.Value["message_thread_id"][0]
+			} else {
+				var body map[string]interface{}
+				req.JSON(&body)
+				b, _ := json.Marshal(body["message_thread_id"])
+				got = string(b)
+			}
+			if got != tt.want {
+				t.Errorf("expected thread %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPoller(t *testing.T) {
+	srv := notifytest.NewTelegramServer(t)
+	clock := notifytest.NewClock(time.Now())
+	p := New("test-token", "-100123", notify.WithHTTPClient(srv.Client()))
+
+	srv.Respond(http.StatusOK, `{"ok":true,"result":[
+		{"update_id":10,"message":{"message_id":1,"date":0,"chat":{"id":-100123,"type":"supergroup"},"text":"/status"}},
+		{"update_id":11,"edited_message":{"message_id":1,"date":0,"chat":{"id":-100123,"type":"supergroup"},"text":"/status now"}}]}`)
+	srv.Fail(http.StatusConflict, "Conflict: terminated by other getUpdates request")
+	srv.Respond(http.StatusOK, `{"ok":true,"result":[
+		{"update_id":12,"callback_query":{"id":"cb-1","from":{"id":7,"first_name":"Ann"},"chat_instance":"1","data":"ack:42"}}]}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var got []int
+	var errs []error
+	h := UpdateHandlerFunc(func(ctx context.Context, u *Update) error {
+		got = append(got, u.UpdateID)
+		if u.CallbackQuery != nil {
+			cancel()
+		}
+		return nil
+	})
+	pl := NewPoller(p, h, WithPollTimeout(10*time.Second), WithAllowedUpdates(UpdateMessage, UpdateCallbackQuery),
+		WithPollerClock(clock), WithPollerErrorHandler(func(err error) { errs = append(errs, err) }))
+
+	done := make(chan error)
+	go func() { done <- pl.Run(ctx) }()
+	if !clock.BlockUntil(1, time.Second) {
+		t.Fatalf("poller did not back off after the error")
+	}
+	clock.Advance(time.Second)
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	if !reflect.DeepEqual(got, []int{10, 11, 12}) || pl.Offset() != 13 {
+		t.Errorf("unexpected updates %v, offset %d", got, pl.Offset())
+	}
+	var apiErr *APIError
+	if len(errs) != 1 || !errors.As(errs[0], &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("expected one conflict error, got %v", errs)
+	}
+
+	var params GetUpdatesParams
+	reqs := srv.Requests()
+	reqs[2].JSON(&params)
+	if reqs[2].Path != "/bottest-token/getUpdates" || params.Offset != 12 || params.Timeout != 10 || len(params.AllowedUpdates) != 2 {
+		t.Errorf("unexpected getUpdates request %s %+v", reqs[2].Path, params)
+	}
+}
+
+func TestRouter(t *testing.T) {
+	srv := notifytest.NewTelegramServer(t)
+	ctx := context.Background()
+	p := New("test-token", "-100123", notify.WithHTTPClient(srv.Client()), WithTopicRoutes(map[string]int{"service=db": 9}))
+
+	srv.Respond(http.StatusOK, `{"ok":true,"result":[
+		{"update_id":1,"message":{"message_id":1,"message_thread_id":5,"is_topic_message":true,"date":0,"chat":{"id":-100123,"type":"supergroup"},"text":"/ack@notify_bot 42"}},
+		{"update_id":2,"message":{"message_id":2,"date":0,"chat":{"id":-100123,"type":"supergroup"},"text":"/ack@other_bot 43"}},
+		{"update_id":3,"callback_query":{"id":"cb-1","from":{"id":7,"first_name":"Ann"},"chat_instance":"1","data":"silence:db:1h"}},
+		{"update_id":4,"callback_query":{"id":"cb-2","from":{"id":7,"first_name":"Ann"},"chat_instance":"1","data":"silence:web"}},
+		{"update_id":5,"edited_message":{"message_id":1,"date":0,"chat":{"id":-100123,"type":"supergroup"},"text":"/ack"}}]}`)
+	updates, err := p.GetUpdates(ctx, GetUpdatesParams{})
+	if err != nil || len(updates) != 5 {
+		t.Fatalf("GetUpdates: %v (%d updates)", err, len(updates))
+	}
+
+	var calls []string
+	r := NewRouter(WithBotUsername("@notify_bot"))
+	r.Command("ack", func(ctx context.Context, m *Message) error {
+		_, args := m.Command()
+		calls = append(calls, "ack "+args)
+		return m.Reply(ctx, notify.CommonMessage{Content: "Acknowledged", Labels: map[string]string{"service": "db"}})
+	})
+	r.OnMessage(func(ctx context.Context, m *Message) error {
+		calls = append(calls, "message "+m.Text)
+		return nil
+	})
+	r.Callback("silence:", func(ctx context.Context, q *CallbackQuery) error {
+		calls = append(calls, "silence "+q.Data)
+		return nil
+	})
+	r.Callback("silence:db:", func(ctx context.Context, q *CallbackQuery) error {
+		calls = append(calls, "silence db "+q.Data)
+		return q.AnswerWith(ctx, CallbackAnswer{Text: "Silenced", ShowAlert: true})
+	})
+	r.OnUpdate(func(ctx context.Context, u *Update) error {
+		calls = append(calls, "update "+strconv.Itoa(u.UpdateID))
+		return nil
+	})
+
+	srv.Reset()
+	for i := range updates {
+		if err := r.HandleUpdate(ctx, &updates[i]); err != nil {
+			t.Errorf("update %d: %v", updates[i].UpdateID, err)
+		}
+	}
+
+	want := []string{"ack 42", "message /ack@other_bot 43", "silence db silence:db:1h", "silence silence:web", "update 5"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("expected calls %q, got %q", want, calls)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(reqs))
+	}
+	var reply map[string]interface{}
+	reqs[0].JSON(&reply)
+	if reqs[0].Path != "/bottest-token/sendMessage" || reply["chat_id"] != "-100123" || reply["message_thread_id"] != float64(5) {
+		t.Errorf("unexpected reply %s %v", reqs[0].Path, reply)
+	}
+	var answers []CallbackAnswer
+	for _, req := range reqs[1:] {
+		var a CallbackAnswer
+		req.JSON(&a)
+		answers = append(answers, a)
+	}
+	if answers[0] != (CallbackAnswer{CallbackQueryID: "cb-1", Text: "Silenced", ShowAlert: true}) || answers[1] != (CallbackAnswer{CallbackQueryID: "cb-2"}) {
+		t.Errorf("unexpected answers %+v", answers)
+	}
+
+	var unbound Message
+	if err := unbound.Reply(ctx, "hi"); err == nil {
+		t.Errorf("expected error replying to a message not received through a provider")
+	}
+
+	// Handlers can register routes, and unmatched callbacks are still answered.
+	r = NewRouter()
+	r.Command("enable", func(ctx context.Context, m *Message) error {
+		r.Callback("page:", func(ctx context.Context, q *CallbackQuery) error { return nil })
+		return nil
+	})
+	srv.Reset()
+	done := make(chan error, 1)
+	go func() { done <- r.HandleUpdate(ctx, &updates[0]) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("registering handler: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler registering a route deadlocked")
+	}
+	q := *updates[3].CallbackQuery
+	q.answered = false
+	if err := r.HandleUpdate(ctx, &Update{UpdateID: 4, CallbackQuery: &q}); err != nil {
+		t.Errorf("unmatched callback: %v", err)
+	}
+	var a CallbackAnswer
+	if err := srv.LastRequest(t).JSON(&a); err != nil || a != (CallbackAnswer{CallbackQueryID: "cb-2"}) {
+		t.Errorf("expected unmatched callback to be answered, got %+v (%v)", a, err)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	srv := notifytest.NewTelegramServer(t)
+	ctx := context.Background()
+	p := New("test-token", "-100123", notify.WithHTTPClient(srv.Client()))
+
+	r := NewRouter()
+	r.Command("status", func(ctx context.Context, m *Message) error {
+		return m.ReplyInline(ctx, "All good")
+	})
+	r.Callback("ack:", func(ctx context.Context, q *CallbackQuery) error {
+		return q.AnswerInline(ctx, CallbackAnswer{Text: "Acknowledged"})
+	})
+	r.OnMessage(func(ctx context.Context, m *Message) error {
+		if err := m.ReplyInline(ctx, "first"); err != nil {
+			return err
+		}
+		return m.ReplyInline(ctx, "second")
+	})
+	var errs []error
+	w := NewWebhook("s3cret", r, WithWebhookProvider(p), WithWebhookErrorHandler(func(err error) { errs = append(errs, err) }))
+
+	post := func(secret, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/telegram", strings.NewReader(body))
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+		rec := httptest.NewRecorder()
+		w.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("s3cret", `{"update_id":1,"message":{"message_id":1,"message_thread_id":5,"is_topic_message":true,"date":0,"chat":{"id":-100123,"type":"supergroup"},"text":"/status"}}`)
+	var reply map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Body)
+	}
+	if reply["method"] != "sendMessage" || reply["chat_id"] != "-100123" || reply["message_thread_id"] != float64(5) || reply["text"] != "All good" {
+		t.Errorf("unexpected inline reply %v", reply)
+	}
+
+	rec = post("s3cret", `{"update_id":2,"callback_query":{"id":"cb-1","from":{"id":7,"first_name":"Ann"},"chat_instance":"1","data":"ack:42"}}`)
+	var answer map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &answer)
+	if answer["method"] != "answerCallbackQuery" || answer["callback_query_id"] != "cb-1" || answer["text"] != "Acknowledged" {
+		t.Errorf("unexpected inline answer %v", answer)
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("expected no API requests for inline replies, got %d", n)
+	}
+
+	rec = post("s3cret", `{"update_id":3,"message":{"message_id":2,"date":0,"chat":{"id":-100123,"type":"supergroup"},"text":"hello"}}`)
+	json.Unmarshal(rec.Body.Bytes(), &reply)
+	if rec.Code != http.StatusOK || reply["text"] != "first" || len(errs) != 1 || !errors.Is(errs[0], ErrInlineReplyUnavailable) {
+		t.Errorf("expected the first inline reply and one error, got %q %v", rec.Body, errs)
+	}
+
+	errs = nil
+	if rec := post("wrong", `{"update_id":4}`); rec.Code != http.StatusUnauthorized || len(errs) != 1 || !errors.Is(errs[0], ErrInvalidSecretToken) {
+		t.Errorf("expected 401 for a wrong secret token, got %d %v", rec.Code, errs)
+	}
+	if rec := post("s3cret", `{`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid body, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/telegram", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", rec.Code)
+	}
+
+	if err := (&Message{}).ReplyInline(ctx, "hi"); err == nil {
+		t.Errorf("expected error for an unbound message")
+	}
+	srv.Respond(http.StatusOK, `{"ok":true,"result":[{"update_id":9,"message":{"message_id":1,"date":0,"chat":{"id":1,"type":"private"},"text":"hi"}}]}`)
+	if updates, _ := p.GetUpdates(ctx, GetUpdatesParams{}); len(updates) != 1 || !errors.Is(updates[0].Message.ReplyInline(ctx, "hi"), ErrInlineReplyUnavailable) {
+		t.Errorf("expected ErrInlineReplyUnavailable outside a webhook")
+	}
+
+	if err := p.SetWebhook(ctx, WebhookConfig{URL: "https://bot.example.com/telegram", SecretToken: "s3cret"}); err != nil {
+		t.Fatalf("SetWebhook: %v", err)
+	}
+	var cfg WebhookConfig
+	srv.LastRequest(t).JSON(&cfg)
+	if cfg.SecretToken != "s3cret" {
+		t.Errorf("unexpected setWebhook request %+v", cfg)
+	}
+	info, err := p.GetWebhookInfo(ctx)
+	if err != nil || info.URL != "https://bot.example.com/telegram" {
+		t.Errorf("unexpected webhook info %+v (%v)", info, err)
+	}
+	if err := p.DeleteWebhook(ctx, true); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	}
+	if info, _ := p.GetWebhookInfo(ctx); info.URL != "" {
+		t.Errorf("expected webhook to be removed, got %q", info.URL)
+	}
 }
 
-type mockTransport struct {
-	roundTrip func(*http.Request) (*http.Response, error)
+func TestReplyMarkup(t *testing.T) {
+	tests := []struct {
+		markup ReplyMarkup
+		want   string
+	}{
+		{ReplyKeyboardRemove{}, `{"remove_keyboard":true}`},
+		{ReplyKeyboardRemove{Selective: true}, `{"remove_keyboard":true,"selective":true}`},
+		{ForceReply{}, `{"force_reply":true}`},
+		{&ReplyKeyboardMarkup{Keyboard: [][]KeyboardButton{{{Text: "Ack"}}}, IsPersistent: true},
+			`{"keyboard":[[{"text":"Ack"}]],"is_persistent":true}`},
+	}
+	for _, tt := range tests {
+		b, err := json.Marshal(tt.markup)
+		if err != nil || string(b) != tt.want {
+			t.Errorf("expected %s, got %s (%v)", tt.want, b, err)
+		}
+	}
+
+	// Explicit reply parameters win over the deprecated field.
+	p := New("test-token", "test-chat")
+	reqs, err := p.Render(context.Background(), Payload{
+		Text:             "hi",
+		ReplyToMessageID: 1,
+		ReplyParameters:  &ReplyParameters{MessageID: 2, AllowSendingWithoutReply: true},
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	var body map[string]interface{}
+	json.NewDecoder(reqs[0].Body).Decode(&body)
+	params, _ := body["reply_parameters"].(map[string]interface{})
+	if _, ok := body["reply_to_message_id"]; ok || params["message_id"] != float64(2) {
+		t.Errorf("unexpected body %v", body)
+	}
+}
+
+func TestText(t *testing.T) {
+	txt := NewText().
+		Plain("🚨 ").
+		Bold("api_server*").
+		Plain(" failed (v1.2.0) ").
+		Italic("again").
+		Underline("!").
+		Plain("\n").
+		Pre("if a < b {\n\tpanic(`x`)\n}", "go").
+		Link("logs [1]", "https://example.com/logs?q=(a)").
+		Mention("Alice", 42).
+		Spoiler("token").
+		Blockquote("one\ntwo")
+
+	text, entities := txt.Entities()
+	wantText := "🚨 api_server* failed (v1.2.0) again!\nif a < b {\n\tpanic(`x`)\n}logs [1]Alicetoken\none\ntwo"
+	if text != wantText {
+		t.Errorf("unexpected text %q", text)
+	}
+	wantEntities := []MessageEntity{
+		{Type: "bold", Offset: 3, Length: 11}, // the emoji is two UTF-16 code units
+		{Type: "italic", Offset: 31, Length: 5},
+		{Type: "underline", Offset: 36, Length: 1},
+		{Type: "pre", Offset: 38, Length: 24, Language: "go"},
+		{Type: "text_link", Offset: 62, Length: 8, URL: "https://example.com/logs?q=(a)"},
+		{Type: "text_mention", Offset: 70, Length: 5, User: &User{ID: 42}},
+		{Type: "spoiler", Offset: 75, Length: 5},
+		{Type: "blockquote", Offset: 81, Length: 7},
+	}
+	if !reflect.DeepEqual(entities, wantEntities) {
+		t.Errorf("unexpected entities\ngot  %+v\nwant %+v", entities, wantEntities)
+	}
+
+	wantMarkdown := "🚨 *api\\_server\\** failed \\(v1\\.2\\.0\\) _again_\r__\\!__\n" +
+		"```go\nif a < b {\n\tpanic(\\`x\\`)\n}\n```" +
+		"[logs \\[1\\]](https://example.com/logs?q=(a\\))" +
+		"[Alice](tg://user?id=42)||token||\n>one\n>two"
+	if got := txt.MarkdownV2(); got != wantMarkdown {
+		t.Errorf("unexpected MarkdownV2\ngot  %q\nwant %q", got, wantMarkdown)
+	}
+
+	wantHTML := "🚨 <b>api_server*</b> failed (v1.2.0) <i>again</i><u>!</u>\n" +
+		`<pre><code class="language-go">if a &lt; b {` + "\n\tpanic(`x`)\n}</code></pre>" +
+		`<a href="https://example.com/logs?q=(a)">logs [1]</a>` +
+		`<a href="tg://user?id=42">Alice</a><tg-spoiler>token</tg-spoiler>` + "\n<blockquote>one\ntwo</blockquote>"
+	if got := txt.HTML(); got != wantHTML {
+		t.Errorf("unexpected HTML\ngot  %q\nwant %q", got, wantHTML)
+	}
+
+	// Touching entities with the same delimiter are kept apart.
+	adjacent := NewText().Italic("a").Italic("b").Underline("c").Italic("d").Bold("e").Bold("f").Code("g").Plain("_").Italic("h")
+	if got, want := adjacent.MarkdownV2(), "_a_\r_b_\r__c__\r_d_*e*\r*f*`g`\\__h_"; got != want {
+		t.Errorf("unexpected adjacent MarkdownV2\ngot  %q\nwant %q", got, want)
+	}
 }
 
-func (m *mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	return m.roundTrip(req)
+func TestWithParseMode(t *testing.T) {
+	tests := []struct {
+		mode     string
+		wantText string
+	}{
+		{ParseModeHTML, "<b>Deploy</b> finished for api_server &lt;v1&gt;"},
+		{ParseModeNone, "Deploy finished for api_server <v1>"},
+		{ParseModeMarkdownV2, "*Deploy* finished for api\\_server <v1\\>"},
+	}
+	for _, tt := range tests {
+		p := New("test-token", "test-chat", WithParseMode(tt.mode))
+		reqs, err := p.Render(context.Background(), "**Deploy** finished for api_server <v1>")
+		if err != nil {
+			t.Fatalf("%q: Render: %v", tt.mode, err)
+		}
+		var body Payload
+		if err := json.NewDecoder(reqs[0].Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Text != tt.wantText || body.ParseMode != tt.mode {
+			t.Errorf("%q: got text %q with parse mode %q", tt.mode, body.Text, body.ParseMode)
+		}
+	}
+
+	p := New("test-token", "test-chat", WithParseMode(ParseModeMarkdown))
+	if _, err := p.Render(context.Background(), "hi"); err == nil {
+		t.Errorf("expected legacy Markdown to be rejected")
+	}
 }
@@ -0,0 +1,246 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/thanpawatpiti/notify/format"
+)
+
+var markdownV2URLEscaper = strings.NewReplacer(`\`, `\\`, `)`, `\)`)
+
+// EscapeMarkdownV2 escapes every character reserved by the MarkdownV2 parse mode.
+func EscapeMarkdownV2(s string) string {
+	return format.EscapeTelegramMarkdownV2(s)
+}
+
+// EscapeMarkdownV2Code escapes s for use inside MarkdownV2 `code` and ```pre``` blocks.
+func EscapeMarkdownV2Code(s string) string {
+	return format.EscapeTelegramCode(s)
+}
+
+// EscapeMarkdownV2URL escapes s for use as the URL of a MarkdownV2 [link](url).
+func EscapeMarkdownV2URL(s string) string {
+	return markdownV2URLEscaper.Replace(s)
+}
+
+// EscapeHTML escapes the characters reserved by the HTML parse mode.
+func EscapeHTML(s string) string {
+	return format.EscapeTelegramHTML(s)
+}
+
+// Text builds formatted text from typed segments, so user-supplied strings
+// never need escaping. Send a *Text directly to deliver it with message
+// entities, or render it with MarkdownV2 or HTML for use in a Payload:
+//
+//	t := telegram.NewText().Bold("Deploy failed").Plain(": ").Code(err.Error())
+//	p.Send(ctx, t)
+type Text struct {
+	segments []segment
+}
+
+// segment is a run of text with at most one entity type.
+type segment struct {
+	kind     string // entity type; "" for plain text
+	text     string
+	url      string
+	language string
+	userID   int64
+}
+
+// NewText returns an empty Text.
+func NewText() *Text {
+	return &Text{}
+}
+
+func (t *Text) add(s segment) *Text {
+	if s.text == "" {
+		return t
+	}
+	// Quotes span whole lines, so keep them apart from neighbouring text.
+	if n := len(t.segments); n > 0 {
+		prev := t.segments[n-1]
+		if (s.kind == "blockquote" && !strings.HasSuffix(prev.text, "\n")) ||
+			(prev.kind == "blockquote" && !strings.HasPrefix(s.text, "\n")) {
+			t.segments = append(t.segments, segment{text: "\n"})
+		}
+	}
+	t.segments = append(t.segments, s)
+	return t
+}
+
+// Plain appends unformatted text.
+func (t *Text) Plain(s string) *Text {
+	return t.add(segment{text: s})
+}
+
+// Plainf appends unformatted text formatted with fmt.Sprintf.
+func (t *Text) Plainf(layout string, args ...interface{}) *Text {
+	return t.Plain(fmt.Sprintf(layout, args...))
+}
+
+// Bold appends bold text.
+func (t *Text) Bold(s string) *Text {
+	return t.add(segment{kind: "bold", text: s})
+}
+
+// Italic appends italic text.
+func (t *Text) Italic(s string) *Text {
+	return t.add(segment{kind: "italic", text: s})
+}
+
+// Underline appends underlined text.
+func (t *Text) Underline(s string) *Text {
+	return t.add(segment{kind: "underline", text: s})
+}
+
+// Strikethrough appends struck-through text.
+func (t *Text) Strikethrough(s string) *Text {
+	return t.add(segment{kind: "strikethrough", text: s})
+}
+
+// Spoiler appends text hidden until tapped.
+func (t *Text) Spoiler(s string) *Text {
+	return t.add(segment{kind: "spoiler", text: s})
+}
+
+// Code appends inline monospace text.
+func (t *Text) Code(s string) *Text {
+	return t.add(segment{kind: "code", text: s})
+}
+
+// Pre appends a code block highlighted as language, which may be empty.
+func (t *Text) Pre(code, language string) *Text {
+	return t.add(segment{kind: "pre", text: code, language: language})
+}
+
+// Link appends label linking to url.
+func (t *Text) Link(label, url string) *Text {
+	return t.add(segment{kind: "text_link", text: label, url: url})
+}
+
+// Mention appends label mentioning the user with userID, for users without
+// a username.
+func (t *Text) Mention(label string, userID int64) *Text {
+	return t.add(segment{kind: "text_mention", text: label, userID: userID})
+}
+
+// Blockquote appends a quotation on lines of its own.
+func (t *Text) Blockquote(s string) *Text {
+	return t.add(segment{kind: "blockquote", text: s})
+}
+
+// Entities returns the unformatted text and the entities describing its
+// formatting, for Payload.Text and Payload.Entities.
+func (t *Text) Entities() (string, []MessageEntity) {
+	var sb strings.Builder
+	var entities []MessageEntity
+	offset := 0
+	for _, s := range t.segments {
+		length := utf16Len(s.text)
+		if s.kind != "" {
+			e := MessageEntity{Type: s.kind, Offset: offset, Length: length, URL: s.url, Language: s.language}
+			if s.kind == "text_mention" {
+				e.User = &User{ID: s.userID}
+			}
+			entities = append(entities, e)
+		}
+		sb.WriteString(s.text)
+		offset += length
+	}
+	return sb.String(), entities
+}
+
+// markdownV2Delimiters are the characters that open and close MarkdownV2
+// entities and that would merge when two entities touch.
+const markdownV2Delimiters = "*_~|`"
+
+// MarkdownV2 returns the text as MarkdownV2 markup.
+func (t *Text) MarkdownV2() string {
+	var sb strings.Builder
+	prev := ""
+	for _, s := range t.segments {
+		text := EscapeMarkdownV2(s.text)
+		switch s.kind {
+		case "bold":
+			text = "*" + text + "*"
+		case "italic":
+			text = "_" + text + "_"
+		case "underline":
+			text = "__" + text + "__"
+		case "strikethrough":
+			text = "~" + text + "~"
+		case "spoiler":
+			text = "||" + text + "||"
+		case "code":
+			text = "`" + EscapeMarkdownV2Code(s.text) + "`"
+		case "pre":
+			text = "```" + s.language + "\n" + EscapeMarkdownV2Code(s.text) + "\n```"
+		case "text_link":
+			text = "[" + text + "](" + EscapeMarkdownV2URL(s.url) + ")"
+		case "text_mention":
+			text = "[" + text + "](tg://user?id=" + strconv.FormatInt(s.userID, 10) + ")"
+		case "blockquote":
+			text = ">" + strings.ReplaceAll(text, "\n", "\n>")
+		}
+		if s.kind != "" && prev != "" && prev[len(prev)-1] == text[0] && strings.IndexByte(markdownV2Delimiters, text[0]) >= 0 {
+			// Separates touching delimiters such as "_" and "__" with the
+			// ignored character the Bot API documents.
+			sb.WriteString("\r")
+		}
+		sb.WriteString(text)
+		prev = ""
+		if s.kind != "" {
+			prev = text
+		}
+	}
+	return sb.String()
+}
+
+// HTML returns the text as HTML markup.
+func (t *Text) HTML() string {
+	var sb strings.Builder
+	for _, s := range t.segments {
+		text := EscapeHTML(s.text)
+		switch s.kind {
+		case "bold":
+			text = "<b>" + text + "</b>"
+		case "italic":
+			text = "<i>" + text + "</i>"
+		case "underline":
+			text = "<u>" + text + "</u>"
+		case "strikethrough":
+			text = "<s>" + text + "</s>"
+		case "spoiler":
+			text = "<tg-spoiler>" + text + "</tg-spoiler>"
+		case "code":
+			text = "<code>" + text + "</code>"
+		case "pre":
+			if s.language != "" {
+				text = `<pre><code class="language-` + EscapeHTML(s.language) + `">` + text + "</code></pre>"
+			} else {
+				text = "<pre>" + text + "</pre>"
+			}
+		case "text_link":
+			text = `<a href="` + html.EscapeString(s.url) + `">` + text + "</a>"
+		case "text_mention":
+			text = `<a href="tg://user?id=` + strconv.FormatInt(s.userID, 10) + `">` + text + "</a>"
+		case "blockquote":
+			text = "<blockquote>" + text + "</blockquote>"
+		}
+		sb.WriteString(text)
+	}
+	return sb.String()
+}
+
+// utf16Len returns the length of s in UTF-16 code units, the unit of entity offsets.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
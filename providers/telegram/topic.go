@@ -0,0 +1,150 @@
+package telegram
+
+import (
+	"context"
+	"sort"
+
+	"github.com/thanpawatpiti/notify"
+)
+
+// Colors available for forum topic icons.
+const (
+	TopicColorBlue   = 0x6FB9F0
+	TopicColorYellow = 0xFFD67E
+	TopicColorViolet = 0xCB86DB
+	TopicColorGreen  = 0x8EEE98
+	TopicColorRose   = 0xFF93B2
+	TopicColorRed    = 0xFB6F5F
+)
+
+// ForumTopic is a topic of a supergroup with forum topics enabled.
+type ForumTopic struct {
+	MessageThreadID int    `json:"message_thread_id,omitempty"`
+	Name            string `json:"name"`
+	// IconColor is one of the TopicColor constants. It cannot be changed
+	// after the topic has been created.
+	IconColor         int    `json:"icon_color,omitempty"`
+	IconCustomEmojiID string `json:"icon_custom_emoji_id,omitempty"`
+}
+
+// ForumTopicEdit changes the name or icon of a forum topic.
+type ForumTopicEdit struct {
+	// Name is the new name; empty keeps the current one.
+	Name string `json:"name,omitempty"`
+	// IconCustomEmojiID is the new icon; nil keeps the current icon and an
+	// empty string removes it.
+	IconCustomEmojiID *string `json:"icon_custom_emoji_id,omitempty"`
+}
+
+// WithTopic sends messages into the forum topic threadID of the chat
+// unless a route of WithTopicRoutes matches.
+func WithTopic(threadID int) notify.Option {
+	return notify.ProviderOption(func(o *options) {
+		o.topic = threadID
+	})
+}
+
+// WithTopicRoutes sends messages into forum topics by label. routes maps
+// labels written as "key=value", e.g. "service=payments", to topic IDs.
+// Labels come from CommonMessage.Labels or notify.ContextWithLabels; when
+// several match, the label with the first key in sorted order wins.
+// A MessageThreadID set on the payload always takes precedence.
+func WithTopicRoutes(routes map[string]int) notify.Option {
+	return notify.ProviderOption(func(o *options) {
+		o.topicRoutes = routes
+	})
+}
+
+// topic returns threadID, or when it is zero the topic of the message
+// being replied to, the topic routed from the labels of payload or the one
+// set with WithTopic.
+func (p *Provider) topic(ctx context.Context, payload interface{}, threadID int) int {
+	if threadID != 0 {
+		return threadID
+	}
+	if p.thread != nil {
+		return *p.thread
+	}
+	if routes := p.config.topicRoutes; routes != nil {
+		labels := notify.LabelsOf(ctx, payload)
+		keys := make([]string, 0, len(labels))
+		for k := range labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if id, ok := routes[k+"="+labels[k]]; ok {
+				return id
+			}
+		}
+	}
+	return p.config.topic
+}
+
+// CreateForumTopic creates a topic in the provider's chat and returns it
+// with its MessageThreadID. The bot needs the can_manage_topics right.
+func (p *Provider) CreateForumTopic(ctx context.Context, topic ForumTopic) (*ForumTopic, error) {
+	in := struct {
+		ChatID string `json:"chat_id"`
+		ForumTopic
+	}{p.chatID, topic}
+	in.MessageThreadID = 0
+	var created ForumTopic
+	if err := p.call(ctx, "createForumTopic", in, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// EditForumTopic changes the name or icon of the topic threadID.
+func (p *Provider) EditForumTopic(ctx context.Context, threadID int, edit ForumTopicEdit) error {
+	in := struct {
+		ChatID          string `json:"chat_id"`
+		MessageThreadID int    `json:"message_thread_id"`
+		ForumTopicEdit
+	}{p.chatID, threadID, edit}
+	return p.call(ctx, "editForumTopic", in, nil)
+}
+
+// CloseForumTopic closes the topic threadID; only admins can post until it is reopened.
+func (p *Provider) CloseForumTopic(ctx context.Context, threadID int) error {
+	return p.topicCall(ctx, "closeForumTopic", threadID)
+}
+
+// ReopenForumTopic reopens the closed topic threadID.
+func (p *Provider) ReopenForumTopic(ctx context.Context, threadID int) error {
+	return p.topicCall(ctx, "reopenForumTopic", threadID)
+}
+
+// DeleteForumTopic deletes the topic threadID with all its messages.
+func (p *Provider) DeleteForumTopic(ctx context.Context, threadID int) error {
+	return p.topicCall(ctx, "deleteForumTopic", threadID)
+}
+
+// EditGeneralForumTopic renames the General topic.
+func (p *Provider) EditGeneralForumTopic(ctx context.Context, name string) error {
+	in := struct {
+		ChatID string `json:"chat_id"`
+		Name   string `json:"name"`
+	}{p.chatID, name}
+	return p.call(ctx, "editGeneralForumTopic", in, nil)
+}
+
+// CloseGeneralForumTopic closes the General topic.
+func (p *Provider) CloseGeneralForumTopic(ctx context.Context) error {
+	return p.topicCall(ctx, "closeGeneralForumTopic", 0)
+}
+
+// ReopenGeneralForumTopic reopens the General topic.
+func (p *Provider) ReopenGeneralForumTopic(ctx context.Context) error {
+	return p.topicCall(ctx, "reopenGeneralForumTopic", 0)
+}
+
+// topicCall calls a topic method that takes only the chat and thread ID.
+func (p *Provider) topicCall(ctx context.Context, method string, threadID int) error {
+	in := struct {
+		ChatID          string `json:"chat_id"`
+		MessageThreadID int    `json:"message_thread_id,omitempty"`
+	}{p.chatID, threadID}
+	return p.call(ctx, method, in, nil)
+}
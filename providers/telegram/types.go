@@ -1,16 +1,117 @@
 package telegram
 
+import "encoding/json"
+
+// Parse modes supported by the Bot API.
+const (
+	ParseModeMarkdownV2 = "MarkdownV2"
+	ParseModeHTML       = "HTML"
+	ParseModeMarkdown   = "Markdown" // Legacy mode, kept for backward compatibility.
+	// ParseModeNone sends text without markup; see WithParseMode.
+	ParseModeNone = ""
+)
+
 // Payload represents a Telegram message payload.
 type Payload struct {
-	ChatID                string      `json:"chat_id"`
-	Text                  string      `json:"text,omitempty"`
-	ParseMode             string      `json:"parse_mode,omitempty"` // "MarkdownV2", "HTML", "Markdown"
-	DisableWebPagePreview bool        `json:"disable_web_page_preview,omitempty"`
-	DisableNotification   bool        `json:"disable_notification,omitempty"`
-	ReplyToMessageID      int         `json:"reply_to_message_id,omitempty"`
-	ReplyMarkup           interface{} `json:"reply_markup,omitempty"` // InlineKeyboardMarkup or ReplyKeyboardMarkup
-	Photo                 string      `json:"photo,omitempty"`        // URL for sendPhoto
-	Caption               string      `json:"caption,omitempty"`      // For sendPhoto
+	// BusinessConnectionID sends on behalf of a business account connected to the bot.
+	BusinessConnectionID string `json:"business_connection_id,omitempty"`
+	ChatID               string `json:"chat_id"`
+	MessageThreadID      int    `json:"message_thread_id,omitempty"` // Forum topic; see WithTopicRoutes
+	Text                 string `json:"text,omitempty"`
+	ParseMode            string `json:"parse_mode,omitempty"` // "MarkdownV2", "HTML", "Markdown"
+	// LinkPreviewOptions control the preview of the first link in Text.
+	LinkPreviewOptions *LinkPreviewOptions `json:"link_preview_options,omitempty"`
+	// Deprecated: Use LinkPreviewOptions with IsDisabled. It is sent as such.
+	DisableWebPagePreview bool `json:"disable_web_page_preview,omitempty"`
+	DisableNotification   bool `json:"disable_notification,omitempty"`
+	// ProtectContent prevents forwarding and saving the message.
+	ProtectContent bool `json:"protect_content,omitempty"`
+	// AllowPaidBroadcast lifts the broadcast rate limit to 1000 messages per
+	// second for a fee in Telegram Stars.
+	AllowPaidBroadcast bool `json:"allow_paid_broadcast,omitempty"`
+	// MessageEffectID adds a message effect; private chats only.
+	MessageEffectID string           `json:"message_effect_id,omitempty"`
+	ReplyParameters *ReplyParameters `json:"reply_parameters,omitempty"`
+	// Deprecated: Use ReplyParameters. It is sent as such.
+	ReplyToMessageID int         `json:"reply_to_message_id,omitempty"`
+	ReplyMarkup      ReplyMarkup `json:"reply_markup,omitempty"`
+	Photo            string      `json:"photo,omitempty"`   // URL for sendPhoto
+	Caption          string      `json:"caption,omitempty"` // For sendPhoto
+	// Entities format Text instead of ParseMode; see Text.Entities.
+	Entities []MessageEntity `json:"entities,omitempty"`
+	// CaptionEntities format Caption instead of ParseMode.
+	CaptionEntities []MessageEntity `json:"caption_entities,omitempty"`
+}
+
+// upgrade moves the deprecated fields of p to their replacements.
+func (p *Payload) upgrade() {
+	if p.ReplyToMessageID != 0 {
+		if p.ReplyParameters == nil {
+			p.ReplyParameters = &ReplyParameters{MessageID: p.ReplyToMessageID}
+		}
+		p.ReplyToMessageID = 0
+	}
+	if p.DisableWebPagePreview {
+		if p.LinkPreviewOptions == nil {
+			p.LinkPreviewOptions = &LinkPreviewOptions{IsDisabled: true}
+		}
+		p.DisableWebPagePreview = false
+	}
+}
+
+// ReplyParameters describe the message being replied to.
+type ReplyParameters struct {
+	MessageID int `json:"message_id"`
+	// ChatID is the chat of the message if it differs from the payload's chat.
+	ChatID string `json:"chat_id,omitempty"`
+	// AllowSendingWithoutReply sends the message even if the message to be
+	// replied to is not found.
+	AllowSendingWithoutReply bool `json:"allow_sending_without_reply,omitempty"`
+	// Quote is the exact part of the message to quote, with its formatting.
+	Quote          string          `json:"quote,omitempty"`
+	QuoteParseMode string          `json:"quote_parse_mode,omitempty"`
+	QuoteEntities  []MessageEntity `json:"quote_entities,omitempty"`
+	// QuotePosition is the UTF-16 offset of Quote in the original message.
+	QuotePosition int `json:"quote_position,omitempty"`
+}
+
+// LinkPreviewOptions describe how link previews are shown.
+type LinkPreviewOptions struct {
+	IsDisabled bool `json:"is_disabled,omitempty"`
+	// URL is previewed instead of the first link in the text.
+	URL              string `json:"url,omitempty"`
+	PreferSmallMedia bool   `json:"prefer_small_media,omitempty"`
+	PreferLargeMedia bool   `json:"prefer_large_media,omitempty"`
+	ShowAboveText    bool   `json:"show_above_text,omitempty"`
+}
+
+// MessageEntity marks a formatted range of a message text. Offset and
+// Length are measured in UTF-16 code units.
+type MessageEntity struct {
+	// Type is e.g. "bold", "italic", "underline", "strikethrough", "spoiler",
+	// "code", "pre", "text_link", "text_mention" or "blockquote".
+	Type     string `json:"type"`
+	Offset   int    `json:"offset"`
+	Length   int    `json:"length"`
+	URL      string `json:"url,omitempty"`      // text_link
+	User     *User  `json:"user,omitempty"`     // text_mention
+	Language string `json:"language,omitempty"` // pre
+}
+
+// User represents a Telegram user or bot.
+type User struct {
+	ID           int64  `json:"id"`
+	IsBot        bool   `json:"is_bot,omitempty"`
+	FirstName    string `json:"first_name,omitempty"`
+	LastName     string `json:"last_name,omitempty"`
+	Username     string `json:"username,omitempty"`
+	LanguageCode string `json:"language_code,omitempty"`
+}
+
+// ReplyMarkup is an interface option attached to a message:
+// InlineKeyboardMarkup, ReplyKeyboardMarkup, ReplyKeyboardRemove or ForceReply.
+type ReplyMarkup interface {
+	replyMarkup()
 }
 
 // InlineKeyboardMarkup represents an inline keyboard.
@@ -27,12 +128,132 @@ type InlineKeyboardButton struct {
 
 // ReplyKeyboardMarkup represents a custom keyboard.
 type ReplyKeyboardMarkup struct {
-	Keyboard        [][]KeyboardButton `json:"keyboard"`
-	ResizeKeyboard  bool               `json:"resize_keyboard,omitempty"`
-	OneTimeKeyboard bool               `json:"one_time_keyboard,omitempty"`
+	Keyboard [][]KeyboardButton `json:"keyboard"`
+	// IsPersistent keeps the keyboard shown when the regular keyboard is hidden.
+	IsPersistent    bool `json:"is_persistent,omitempty"`
+	ResizeKeyboard  bool `json:"resize_keyboard,omitempty"`
+	OneTimeKeyboard bool `json:"one_time_keyboard,omitempty"`
+	// InputFieldPlaceholder is shown in the input field while the keyboard is active.
+	InputFieldPlaceholder string `json:"input_field_placeholder,omitempty"`
+	// Selective shows the keyboard only to mentioned users and the sender
+	// of the message replied to.
+	Selective bool `json:"selective,omitempty"`
 }
 
 // KeyboardButton represents a button in a custom keyboard.
 type KeyboardButton struct {
 	Text string `json:"text"`
 }
+
+// ReplyKeyboardRemove removes the custom keyboard.
+type ReplyKeyboardRemove struct {
+	// Selective removes the keyboard only for mentioned users and the
+	// sender of the message replied to.
+	Selective bool
+}
+
+// MarshalJSON adds the remove_keyboard field required by the Bot API.
+func (r ReplyKeyboardRemove) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		RemoveKeyboard bool `json:"remove_keyboard"`
+		Selective      bool `json:"selective,omitempty"`
+	}{true, r.Selective})
+}
+
+// ForceReply shows the reply interface to the user, as if they had
+// selected the bot's message and tapped Reply.
+type ForceReply struct {
+	// InputFieldPlaceholder is shown in the input field while replying.
+	InputFieldPlaceholder string
+	// Selective forces a reply only from mentioned users and the sender of
+	// the message replied to.
+	Selective bool
+}
+
+// MarshalJSON adds the force_reply field required by the Bot API.
+func (f ForceReply) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ForceReply            bool   `json:"force_reply"`
+		InputFieldPlaceholder string `json:"input_field_placeholder,omitempty"`
+		Selective             bool   `json:"selective,omitempty"`
+	}{true, f.InputFieldPlaceholder, f.Selective})
+}
+
+func (InlineKeyboardMarkup) replyMarkup() {}
+func (ReplyKeyboardMarkup) replyMarkup()  {}
+func (ReplyKeyboardRemove) replyMarkup()  {}
+func (ForceReply) replyMarkup()           {}
+
+// Message is a message sent by the bot or received in an Update.
+type Message struct {
+	MessageID int `json:"message_id"`
+	// MessageThreadID is the forum topic of the message.
+	MessageThreadID int  `json:"message_thread_id,omitempty"`
+	IsTopicMessage  bool `json:"is_topic_message,omitempty"`
+	// From is the sender; it is empty for messages sent to channels.
+	From *User `json:"from,omitempty"`
+	// SenderChat is set for messages sent on behalf of a chat.
+	SenderChat      *Chat           `json:"sender_chat,omitempty"`
+	Date            int64           `json:"date"`
+	Chat            Chat            `json:"chat"`
+	ReplyToMessage  *Message        `json:"reply_to_message,omitempty"`
+	Text            string          `json:"text,omitempty"`
+	Entities        []MessageEntity `json:"entities,omitempty"`
+	Caption         string          `json:"caption,omitempty"`
+	CaptionEntities []MessageEntity `json:"caption_entities,omitempty"`
+	// MediaGroupID is shared by the messages of an album.
+	MediaGroupID string `json:"media_group_id,omitempty"`
+	// Photo holds the available sizes of a photo, smallest first.
+	Photo     []File `json:"photo,omitempty"`
+	Document  *File  `json:"document,omitempty"`
+	Video     *File  `json:"video,omitempty"`
+	Audio     *File  `json:"audio,omitempty"`
+	Animation *File  `json:"animation,omitempty"`
+	Voice     *File  `json:"voice,omitempty"`
+	// Poll is set for polls; its ID matches later PollAnswer updates.
+	Poll     *PollStatus  `json:"poll,omitempty"`
+	Location *Coordinates `json:"location,omitempty"`
+	Dice     *DiceResult  `json:"dice,omitempty"`
+
+	provider *Provider // set for received messages; see Reply
+}
+
+// FileID returns the file_id of the message's media, the largest size for
+// photos, or "" for messages without a file.
+func (m Message) FileID() string {
+	for _, f := range []*File{m.Document, m.Video, m.Audio, m.Animation, m.Voice} {
+		if f != nil {
+			return f.FileID
+		}
+	}
+	if len(m.Photo) > 0 {
+		return m.Photo[len(m.Photo)-1].FileID
+	}
+	return ""
+}
+
+// Chat is a private chat, group, supergroup or channel.
+type Chat struct {
+	ID       int64  `json:"id"`
+	Type     string `json:"type"` // "private", "group", "supergroup" or "channel"
+	Title    string `json:"title,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// File describes a photo size, document, video, audio, animation or voice
+// message. Only the fields matching the kind of file are set.
+type File struct {
+	// FileID can be used to send the file again.
+	FileID string `json:"file_id"`
+	// FileUniqueID is the same for every bot and over time, but cannot be
+	// used to send the file.
+	FileUniqueID string `json:"file_unique_id"`
+	FileSize     int64  `json:"file_size,omitempty"`
+	FileName     string `json:"file_name,omitempty"`
+	MIMEType     string `json:"mime_type,omitempty"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	Duration     int    `json:"duration,omitempty"` // seconds
+	Performer    string `json:"performer,omitempty"`
+	Title        string `json:"title,omitempty"`
+}
@@ -0,0 +1,181 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Update types, for GetUpdatesParams.AllowedUpdates and WithAllowedUpdates.
+const (
+	UpdateMessage           = "message"
+	UpdateEditedMessage     = "edited_message"
+	UpdateChannelPost       = "channel_post"
+	UpdateEditedChannelPost = "edited_channel_post"
+	UpdateCallbackQuery     = "callback_query"
+	UpdatePoll              = "poll"
+	UpdatePollAnswer        = "poll_answer"
+)
+
+// errUnbound is returned by the reply helpers of updates that were not
+// received through the provider.
+var errUnbound = errors.New("telegram update was not received through a provider")
+
+// Update is an incoming update. At most one of its optional fields is set.
+type Update struct {
+	// UpdateID increases with each update; see Poller.Offset.
+	UpdateID          int            `json:"update_id"`
+	Message           *Message       `json:"message,omitempty"`
+	EditedMessage     *Message       `json:"edited_message,omitempty"`
+	ChannelPost       *Message       `json:"channel_post,omitempty"`
+	EditedChannelPost *Message       `json:"edited_channel_post,omitempty"`
+	CallbackQuery     *CallbackQuery `json:"callback_query,omitempty"`
+	// Poll is a new state of a poll sent by the bot, e.g. once it is closed.
+	Poll *PollStatus `json:"poll,omitempty"`
+	// PollAnswer is a vote in a poll sent by the bot that is not anonymous.
+	PollAnswer *PollAnswer `json:"poll_answer,omitempty"`
+}
+
+// bind lets the messages and callback query of u reply through p.
+func (u *Update) bind(p *Provider) {
+	for _, m := range []*Message{u.Message, u.EditedMessage, u.ChannelPost, u.EditedChannelPost} {
+		if m != nil {
+			m.provider = p
+		}
+	}
+	if q := u.CallbackQuery; q != nil {
+		q.provider = p
+		if q.Message != nil {
+			q.Message.provider = p
+		}
+	}
+}
+
+// CallbackQuery is a press of an inline keyboard button with CallbackData.
+type CallbackQuery struct {
+	ID   string `json:"id"`
+	From User   `json:"from"`
+	// Message is the message with the button. It is nil for inline messages
+	// and messages too old to be returned.
+	Message         *Message `json:"message,omitempty"`
+	InlineMessageID string   `json:"inline_message_id,omitempty"`
+	// ChatInstance identifies the chat the message was sent to.
+	ChatInstance string `json:"chat_instance"`
+	// Data is the CallbackData of the button.
+	Data string `json:"data,omitempty"`
+
+	provider *Provider
+	answered bool
+}
+
+// CallbackAnswer is the answer to a callback query, shown to the user who
+// pressed the button.
+type CallbackAnswer struct {
+	CallbackQueryID string `json:"callback_query_id"`
+	// Text is shown as a notification at the top of the chat, up to 200 characters.
+	Text string `json:"text,omitempty"`
+	// ShowAlert shows Text in an alert instead of a notification.
+	ShowAlert bool `json:"show_alert,omitempty"`
+	// URL is opened by the client, for games and t.me links to the bot.
+	URL string `json:"url,omitempty"`
+	// CacheTime is how long in seconds clients may cache the answer.
+	CacheTime int `json:"cache_time,omitempty"`
+}
+
+// AnswerCallbackQuery answers a callback query. Clients show a progress
+// indicator on the button until the query is answered.
+func (p *Provider) AnswerCallbackQuery(ctx context.Context, answer CallbackAnswer) error {
+	return p.call(ctx, "answerCallbackQuery", answer, nil)
+}
+
+// Answer answers q with a notification showing text, which may be empty.
+func (q *CallbackQuery) Answer(ctx context.Context, text string) error {
+	return q.AnswerWith(ctx, CallbackAnswer{Text: text})
+}
+
+// AnswerWith answers q with answer; its CallbackQueryID is filled in.
+func (q *CallbackQuery) AnswerWith(ctx context.Context, answer CallbackAnswer) error {
+	if q.provider == nil {
+		return errUnbound
+	}
+	answer.CallbackQueryID = q.ID
+	if err := q.provider.AnswerCallbackQuery(ctx, answer); err != nil {
+		return err
+	}
+	q.answered = true
+	return nil
+}
+
+// Reply sends payload, of any type accepted by Provider.Send, to the chat
+// and forum topic of m.
+func (m *Message) Reply(ctx context.Context, payload interface{}) error {
+	if m.provider == nil {
+		return errUnbound
+	}
+	return m.provider.inChat(m.Chat.ID, m.topicID()).Send(ctx, payload)
+}
+
+// topicID returns the forum topic of m, or 0 outside forum topics.
+func (m *Message) topicID() int {
+	if m.IsTopicMessage {
+		return m.MessageThreadID
+	}
+	return 0
+}
+
+// Command returns the command of a message starting with a bot command,
+// e.g. "ack" and "42" for "/ack@alerts_bot 42". name is empty for other messages.
+func (m *Message) Command() (name, args string) {
+	name, _, args = parseCommand(m.Text)
+	return name, args
+}
+
+// parseCommand splits "/name@bot args" into its parts.
+func parseCommand(text string) (name, bot, args string) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", ""
+	}
+	cmd, args, _ := strings.Cut(text[1:], " ")
+	if i := strings.IndexByte(cmd, '\n'); i >= 0 {
+		cmd, args = cmd[:i], strings.TrimSpace(text[1+i:])
+	}
+	name, bot, _ = strings.Cut(cmd, "@")
+	return name, bot, strings.TrimSpace(args)
+}
+
+// GetUpdatesParams are the parameters of GetUpdates.
+type GetUpdatesParams struct {
+	// Offset is the ID of the first update to return. Updates before it
+	// are confirmed and will not be returned again.
+	Offset int `json:"offset,omitempty"`
+	// Limit is the maximum number of updates, 1 to 100. Defaults to 100.
+	Limit int `json:"limit,omitempty"`
+	// Timeout is how long in seconds to wait for an update to arrive.
+	Timeout int `json:"timeout,omitempty"`
+	// AllowedUpdates are the update types to receive, e.g. UpdateMessage.
+	// Empty keeps the previous setting.
+	AllowedUpdates []string `json:"allowed_updates,omitempty"`
+}
+
+// GetUpdates receives incoming updates by long polling. It fails while a
+// webhook is set. Use a Poller to consume updates continuously.
+func (p *Provider) GetUpdates(ctx context.Context, params GetUpdatesParams) ([]Update, error) {
+	var updates []Update
+	if err := p.call(ctx, "getUpdates", params, &updates); err != nil {
+		return nil, err
+	}
+	for i := range updates {
+		updates[i].bind(p)
+	}
+	return updates, nil
+}
+
+// inChat returns a copy of p that sends to chatID and the forum topic
+// threadID, ignoring topic routes.
+func (p *Provider) inChat(chatID int64, threadID int) *Provider {
+	c := *p
+	c.chatID = strconv.FormatInt(chatID, 10)
+	c.thread = &threadID
+	return &c
+}
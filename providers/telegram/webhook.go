@@ -0,0 +1,286 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+)
+
+// maxWebhookBody is the largest webhook request body the handler reads.
+const maxWebhookBody = 1 << 20
+
+// ErrInvalidSecretToken is returned by ParseRequest when the
+// X-Telegram-Bot-Api-Secret-Token header does not match the secret token.
+var ErrInvalidSecretToken = errors.New("telegram webhook secret token is invalid")
+
+// ErrInlineReplyUnavailable is returned by ReplyInline and AnswerInline
+// outside a webhook request, or when the request already has an inline reply.
+var ErrInlineReplyUnavailable = errors.New("telegram inline reply is unavailable")
+
+// Webhook is an http.Handler that receives updates pushed by Telegram,
+// checks their secret token and passes them to an UpdateHandler, usually a
+// Router. Handler errors are passed to the error handler; Telegram always
+// receives 200 OK for authentic requests so that updates are not redelivered.
+type Webhook struct {
+	secretToken string
+	handler     UpdateHandler
+	provider    *Provider
+	onError     func(error)
+}
+
+// WebhookOption configures a Webhook.
+type WebhookOption func(*Webhook)
+
+// WithWebhookProvider configures the provider used by Message.Reply,
+// CallbackQuery.Answer and the inline replies.
+func WithWebhookProvider(p *Provider) WebhookOption {
+	return func(w *Webhook) {
+		w.provider = p
+	}
+}
+
+// WithWebhookErrorHandler configures a callback for handler errors and
+// rejected requests.
+func WithWebhookErrorHandler(f func(error)) WebhookOption {
+	return func(w *Webhook) {
+		w.onError = f
+	}
+}
+
+// NewWebhook creates a webhook handler for updates sent with secretToken,
+// the SecretToken of the WebhookConfig passed to SetWebhook. An empty
+// secretToken disables the check, so anyone who knows the URL can send updates.
+func NewWebhook(secretToken string, h UpdateHandler, opts ...WebhookOption) *Webhook {
+	w := &Webhook{
+		secretToken: secretToken,
+		handler:     h,
+		onError:     func(error) {},
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// ServeHTTP implements http.Handler. An inline reply set by the handler is
+// written as the response body.
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	u, err := w.ParseRequest(rw, r)
+	switch {
+	case errors.Is(err, ErrInvalidSecretToken):
+		w.onError(err)
+		http.Error(rw, "invalid secret token", http.StatusUnauthorized)
+		return
+	case err != nil:
+		w.onError(err)
+		http.Error(rw, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	reply := &inlineReply{}
+	if err := w.handler.HandleUpdate(context.WithValue(r.Context(), inlineReplyKey{}, reply), u); err != nil {
+		w.onError(fmt.Errorf("telegram update %d: %w", u.UpdateID, err))
+	}
+
+	body := reply.seal()
+	if body == nil {
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(body)
+}
+
+// ParseRequest checks the secret token and decodes a webhook request
+// without dispatching it, for applications that handle updates themselves.
+// rw may be nil; it is only used to limit the body size.
+func (w *Webhook) ParseRequest(rw http.ResponseWriter, r *http.Request) (*Update, error) {
+	if w.secretToken != "" &&
+		subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Telegram-Bot-Api-Secret-Token")), []byte(w.secretToken)) != 1 {
+		return nil, ErrInvalidSecretToken
+	}
+
+	var u Update
+	if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxWebhookBody)).Decode(&u); err != nil {
+		return nil, fmt.Errorf("failed to decode telegram webhook: %w", err)
+	}
+	if w.provider != nil {
+		u.bind(w.provider)
+	}
+	return &u, nil
+}
+
+// inlineReplyKey is the context key of the inline reply of a webhook request.
+type inlineReplyKey struct{}
+
+// inlineReply holds the Bot API call answered in a webhook response.
+type inlineReply struct {
+	mu     sync.Mutex
+	body   []byte
+	sealed bool
+}
+
+// set stores body unless a reply was already set or the response was written.
+func (r *inlineReply) set(body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sealed || r.body != nil {
+		return ErrInlineReplyUnavailable
+	}
+	r.body = body
+	return nil
+}
+
+// seal returns the reply and rejects later ones.
+func (r *inlineReply) seal() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sealed = true
+	return r.body
+}
+
+// respondInline sets req, a rendered JSON Bot API request, as the inline
+// reply of the webhook request in ctx.
+func respondInline(ctx context.Context, req *http.Request) error {
+	reply, ok := ctx.Value(inlineReplyKey{}).(*inlineReply)
+	if !ok {
+		return ErrInlineReplyUnavailable
+	}
+	if req.Header.Get("Content-Type") != "application/json" {
+		return fmt.Errorf("telegram inline replies cannot upload files")
+	}
+	var params map[string]json.RawMessage
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	method, _ := json.Marshal(path.Base(req.URL.Path))
+	params["method"] = method
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return reply.set(body)
+}
+
+// ReplyInline sends payload to the chat and forum topic of m in the
+// response to the webhook request in ctx, saving a separate request.
+// Telegram does not report whether an inline reply succeeded, and only
+// payloads without uploads that render to a single request can be sent
+// inline. Each webhook request has one inline reply; it returns
+// ErrInlineReplyUnavailable when that is taken or outside a Webhook.
+func (m *Message) ReplyInline(ctx context.Context, payload interface{}) error {
+	if m.provider == nil {
+		return errUnbound
+	}
+	reqs, err := m.provider.inChat(m.Chat.ID, m.topicID()).Render(ctx, payload)
+	if err != nil {
+		return err
+	}
+	if len(reqs) != 1 {
+		return fmt.Errorf("telegram inline reply needs a payload sent with one request, got %d", len(reqs))
+	}
+	return respondInline(ctx, reqs[0])
+}
+
+// AnswerInline answers q in the response to the webhook request in ctx;
+// see Message.ReplyInline.
+func (q *CallbackQuery) AnswerInline(ctx context.Context, answer CallbackAnswer) error {
+	if q.provider == nil {
+		return errUnbound
+	}
+	answer.CallbackQueryID = q.ID
+	body, err := json.Marshal(answer)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	req, err := q.provider.newRequest(ctx, "answerCallbackQuery", bytes.NewReader(body), "application/json")
+	if err != nil {
+		return err
+	}
+	if err := respondInline(ctx, req); err != nil {
+		return err
+	}
+	q.answered = true
+	return nil
+}
+
+// WebhookConfig are the parameters of SetWebhook.
+type WebhookConfig struct {
+	// URL is the HTTPS URL updates are sent to, on port 443, 80, 88 or 8443.
+	URL string `json:"url"`
+	// Certificate is the public key of a self-signed certificate, as an upload.
+	Certificate InputFile `json:"certificate,omitzero"`
+	// IPAddress is used instead of resolving URL through DNS.
+	IPAddress string `json:"ip_address,omitempty"`
+	// MaxConnections is the number of simultaneous connections, 1 to 100. Defaults to 40.
+	MaxConnections int `json:"max_connections,omitempty"`
+	// AllowedUpdates are the update types to receive, e.g. UpdateMessage.
+	AllowedUpdates []string `json:"allowed_updates,omitempty"`
+	// DropPendingUpdates discards updates that arrived before the webhook was set.
+	DropPendingUpdates bool `json:"drop_pending_updates,omitempty"`
+	// SecretToken is sent in the X-Telegram-Bot-Api-Secret-Token header of
+	// every update: 1 to 256 characters of A-Z, a-z, 0-9, _ and -.
+	SecretToken string `json:"secret_token,omitempty"`
+}
+
+// WebhookInfo is the current webhook status.
+type WebhookInfo struct {
+	// URL is empty when updates are received with getUpdates.
+	URL                  string `json:"url"`
+	HasCustomCertificate bool   `json:"has_custom_certificate"`
+	PendingUpdateCount   int    `json:"pending_update_count"`
+	IPAddress            string `json:"ip_address,omitempty"`
+	// LastErrorDate is the Unix time of the last failed delivery.
+	LastErrorDate    int64  `json:"last_error_date,omitempty"`
+	LastErrorMessage string `json:"last_error_message,omitempty"`
+	// LastSynchronizationErrorDate is the Unix time of the last error
+	// synchronizing updates with Telegram's datacenters.
+	LastSynchronizationErrorDate int64    `json:"last_synchronization_error_date,omitempty"`
+	MaxConnections               int      `json:"max_connections,omitempty"`
+	AllowedUpdates               []string `json:"allowed_updates,omitempty"`
+}
+
+// SetWebhook makes Telegram send updates to cfg.URL. GetUpdates stops
+// working while a webhook is set.
+func (p *Provider) SetWebhook(ctx context.Context, cfg WebhookConfig) error {
+	if p.token == "" {
+		return fmt.Errorf("telegram token is missing")
+	}
+	reqs, err := p.payloadRequests(ctx, "setWebhook", &cfg, &cfg.Certificate)
+	if err != nil {
+		return err
+	}
+	return p.do(reqs[0], nil)
+}
+
+// DeleteWebhook removes the webhook so updates can be received with
+// GetUpdates. dropPendingUpdates discards updates not yet delivered.
+func (p *Provider) DeleteWebhook(ctx context.Context, dropPendingUpdates bool) error {
+	in := struct {
+		DropPendingUpdates bool `json:"drop_pending_updates,omitempty"`
+	}{dropPendingUpdates}
+	return p.call(ctx, "deleteWebhook", in, nil)
+}
+
+// GetWebhookInfo returns the current webhook status.
+func (p *Provider) GetWebhookInfo(ctx context.Context) (*WebhookInfo, error) {
+	var info WebhookInfo
+	if err := p.call(ctx, "getWebhookInfo", struct{}{}, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
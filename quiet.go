@@ -0,0 +1,318 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QuietPeriod is a recurring time range during which non-critical messages
+// are not delivered. Start and End are offsets from local midnight; when End
+// is before Start the period runs past midnight into the next day, and when
+// they are equal it covers the whole day.
+type QuietPeriod struct {
+	// Weekdays are the days on which the period starts. Empty means every day.
+	Weekdays []time.Weekday
+	Start    time.Duration
+	End      time.Duration
+}
+
+// QuietPolicy decides what happens to messages sent during quiet hours.
+type QuietPolicy int
+
+const (
+	// QuietHold queues messages and delivers them unchanged when the window opens.
+	QuietHold QuietPolicy = iota
+	// QuietDrop discards messages.
+	QuietDrop
+	// QuietDigest queues messages and delivers them as a single summary when
+	// the window opens. Provider-specific payloads, and messages with
+	// attachments, mentions or labels, are delivered unchanged.
+	QuietDigest
+)
+
+// QuietHours is a Notifier decorator that holds, drops or digests
+// non-critical messages during quiet periods of a destination's time zone.
+// Held messages are released by Run (or Flush) once the window opens.
+type QuietHours struct {
+	next    Notifier
+	loc     *time.Location
+	periods []QuietPeriod
+	policy  QuietPolicy
+	bypass  Severity
+	clock   Clock
+	onError func(error)
+	wake    chan struct{}
+
+	mu   sync.Mutex
+	held []heldMessage
+}
+
+// heldMessage is a payload held during quiet hours with the values of the
+// context it was sent with.
+type heldMessage struct {
+	ctx     context.Context
+	payload interface{}
+}
+
+// QuietHoursOption configures QuietHours.
+type QuietHoursOption func(*QuietHours)
+
+// WithQuietPolicy configures how messages are handled during quiet hours. Defaults to QuietHold.
+func WithQuietPolicy(p QuietPolicy) QuietHoursOption {
+	return func(q *QuietHours) {
+		q.policy = p
+	}
+}
+
+// WithBypassSeverity configures the minimum severity delivered during quiet
+// hours. Defaults to SeverityCritical.
+func WithBypassSeverity(s Severity) QuietHoursOption {
+	return func(q *QuietHours) {
+		q.bypass = s
+	}
+}
+
+// WithQuietClock configures the clock used to evaluate quiet periods. Defaults to SystemClock.
+func WithQuietClock(c Clock) QuietHoursOption {
+	return func(q *QuietHours) {
+		q.clock = c
+	}
+}
+
+// WithReleaseErrorHandler configures a callback for held messages that fail
+// to send when released by Run.
+func WithReleaseErrorHandler(f func(error)) QuietHoursOption {
+	return func(q *QuietHours) {
+		q.onError = f
+	}
+}
+
+// NewQuietHours wraps next so that messages are not delivered during the
+// given periods, evaluated in loc (time.Local when nil).
+func NewQuietHours(next Notifier, loc *time.Location, periods []QuietPeriod, opts ...QuietHoursOption) *QuietHours {
+	if loc == nil {
+		loc = time.Local
+	}
+	q := &QuietHours{
+		next:    next,
+		loc:     loc,
+		periods: periods,
+		policy:  QuietHold,
+		bypass:  SeverityCritical,
+		clock:   SystemClock,
+		onError: func(error) {},
+		wake:    make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	return q
+}
+
+// Send delivers payload immediately outside quiet hours or when its severity
+// (see SeverityOf) reaches the bypass level. Otherwise it applies the quiet
+// policy and returns nil. Held messages keep the values of ctx, such as
+// labels, but not its cancellation.
+func (q *QuietHours) Send(ctx context.Context, payload interface{}) error {
+	if SeverityOf(ctx, payload) >= q.bypass || !q.Quiet(q.clock.Now()) {
+		return q.next.Send(ctx, payload)
+	}
+	if q.policy == QuietDrop {
+		return nil
+	}
+
+	q.mu.Lock()
+	q.held = append(q.held, heldMessage{ctx: context.WithoutCancel(ctx), payload: payload})
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Quiet reports whether t falls within a quiet period.
+func (q *QuietHours) Quiet(t time.Time) bool {
+	t = t.In(q.loc)
+	tod := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, q.loc))
+	yesterday := t.AddDate(0, 0, -1).Weekday()
+
+	for _, p := range q.periods {
+		switch {
+		case p.Start == p.End:
+			if p.onDay(t.Weekday()) {
+				return true
+			}
+		case p.Start < p.End:
+			if p.onDay(t.Weekday()) && tod >= p.Start && tod < p.End {
+				return true
+			}
+		default:
+			if (p.onDay(t.Weekday()) && tod >= p.Start) || (p.onDay(yesterday) && tod < p.End) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Held returns the number of messages waiting for the window to open.
+func (q *QuietHours) Held() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.held)
+}
+
+// Flush delivers all held messages now, regardless of quiet hours.
+func (q *QuietHours) Flush(ctx context.Context) error {
+	q.mu.Lock()
+	held := q.held
+	q.held = nil
+	q.mu.Unlock()
+
+	if len(held) == 0 {
+		return nil
+	}
+
+	if q.policy == QuietDigest {
+		held = digest(ctx, held)
+	}
+
+	var errs []error
+	for _, m := range held {
+		sendCtx, cancel := releaseContext(ctx, m.ctx)
+		if err := q.next.Send(sendCtx, m.payload); err != nil {
+			errs = append(errs, err)
+		}
+		cancel()
+	}
+	return errors.Join(errs...)
+}
+
+// digest replaces the held messages that can be summarised with a digest
+// per Format, sent with ctx ahead of the rest. Each digest has the highest
+// severity of the messages it summarises.
+func digest(ctx context.Context, held []heldMessage) []heldMessage {
+	var (
+		formats []Format
+		digests = make(map[Format]*CommonMessage)
+		counts  = make(map[Format]int)
+		rest    []heldMessage
+	)
+	for _, m := range held {
+		line, format, ok := digestLine(m.payload)
+		if !ok || len(LabelsOf(m.ctx, m.payload)) > 0 {
+			rest = append(rest, m)
+			continue
+		}
+		d, ok := digests[format]
+		if !ok {
+			d = &CommonMessage{Format: format}
+			digests[format] = d
+			formats = append(formats, format)
+		}
+		if d.Content != "" {
+			d.Content += "\n"
+		}
+		d.Content += "• " + line
+		d.Severity = max(d.Severity, SeverityOf(m.ctx, m.payload))
+		counts[format]++
+	}
+
+	out := make([]heldMessage, 0, len(formats)+len(rest))
+	for _, f := range formats {
+		d := digests[f]
+		d.Title = fmt.Sprintf("%d notifications held during quiet hours", counts[f])
+		out = append(out, heldMessage{ctx: ctx, payload: *d})
+	}
+	return append(out, rest...)
+}
+
+// releaseContext returns a context with the values of held that is
+// cancelled with ctx.
+func releaseContext(ctx, held context.Context) (context.Context, context.CancelFunc) {
+	sendCtx, cancel := context.WithCancel(held)
+	stop := context.AfterFunc(ctx, cancel)
+	return sendCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// Run releases held messages whenever the window opens, until ctx is cancelled.
+func (q *QuietHours) Run(ctx context.Context) error {
+	for {
+		now := q.clock.Now()
+		var timer <-chan time.Time
+		stop := func() {}
+		if q.Quiet(now) {
+			if open := q.nextOpen(now); !open.IsZero() {
+				timer, stop = NewTimer(q.clock, open.Sub(now))
+			}
+		} else if err := q.Flush(ctx); err != nil {
+			q.onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			stop()
+			return ctx.Err()
+		case <-q.wake:
+		case <-timer:
+		}
+		stop()
+	}
+}
+
+// nextOpen returns the first minute after now outside every quiet period,
+// or the zero time if quiet hours never end within a week.
+func (q *QuietHours) nextOpen(now time.Time) time.Time {
+	t := now.Truncate(time.Minute)
+	for limit := now.AddDate(0, 0, 8); t.Before(limit); t = t.Add(time.Minute) {
+		if t.After(now) && !q.Quiet(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (p QuietPeriod) onDay(d time.Weekday) bool {
+	if len(p.Weekdays) == 0 {
+		return true
+	}
+	for _, w := range p.Weekdays {
+		if w == d {
+			return true
+		}
+	}
+	return false
+}
+
+// digestLine summarises payload as a single line of a digest in the
+// returned format.
+func digestLine(payload interface{}) (string, Format, bool) {
+	switch v := payload.(type) {
+	case string:
+		return v, FormatMarkdown, true
+	case CommonMessage:
+		if len(v.Attachments) > 0 || v.ImageURL != "" || len(v.Mentions) > 0 {
+			return "", "", false
+		}
+		format := v.Format
+		if format == "" {
+			format = FormatMarkdown
+		}
+		if v.Title != "" && v.Content != "" {
+			return v.Title + ": " + v.Content, format, true
+		}
+		return v.Title + v.Content, format, true
+	default:
+		return "", "", false
+	}
+}
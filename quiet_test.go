@@ -0,0 +1,117 @@
+package notify_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/thanpawatpiti/notify"
+	"github.com/thanpawatpiti/notify/notifytest"
+)
+
+func TestQuietHours(t *testing.T) {
+	bangkok := time.FixedZone("ICT", 7*60*60)
+	clock := notifytest.NewClock(time.Date(2025, 1, 6, 3, 0, 0, 0, bangkok)) // Monday 03:00
+	rec := notifytest.NewRecorder()
+
+	q := notify.NewQuietHours(rec, bangkok, []notify.QuietPeriod{
+		{Start: 22 * time.Hour, End: 7 * time.Hour},
+	}, notify.WithQuietClock(clock))
+
+	ctx := context.Background()
+	q.Send(ctx, "disk at 80%")
+	q.Send(ctx, notify.CommonMessage{Content: "database down", Severity: notify.SeverityCritical})
+	q.Send(notify.ContextWithSeverity(ctx, notify.SeverityCritical), "api down")
+
+	if rec.Len() != 2 {
+		t.Fatalf("expected 2 critical messages to bypass quiet hours, got %v", rec.Payloads())
+	}
+	if q.Held() != 1 {
+		t.Fatalf("expected 1 held message, got %d", q.Held())
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go q.Run(runCtx)
+
+	if !clock.BlockUntil(1, time.Second) {
+		t.Fatal("timed out waiting for a pending timer")
+	}
+	clock.Advance(4 * time.Hour) // 07:00, window opens
+	if !rec.Wait(3, time.Second) || rec.Last() != "disk at 80%" {
+		t.Errorf("expected held message to be released, got %v", rec.Payloads())
+	}
+}
+
+func TestQuietHoursPolicies(t *testing.T) {
+	utc := time.UTC
+	clock := notifytest.NewClock(time.Date(2025, 1, 4, 12, 0, 0, 0, utc)) // Saturday
+	weekend := []notify.QuietPeriod{{Weekdays: []time.Weekday{time.Saturday, time.Sunday}}}
+	ctx := context.Background()
+
+	rec := notifytest.NewRecorder()
+	q := notify.NewQuietHours(rec, utc, weekend, notify.WithQuietClock(clock), notify.WithQuietPolicy(notify.QuietDrop))
+	q.Send(ctx, "dropped")
+	if q.Held() != 0 {
+		t.Errorf("expected message to be dropped")
+	}
+	rec.AssertNothingSent(t)
+
+	rec = notifytest.NewRecorder()
+	q = notify.NewQuietHours(rec, utc, weekend, notify.WithQuietClock(clock), notify.WithQuietPolicy(notify.QuietDigest))
+	q.Send(ctx, "first")
+	q.Send(ctx, notify.CommonMessage{Title: "Backup", Content: "completed"})
+	if err := q.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if rec.Len() != 1 {
+		t.Fatalf("expected a single digest, got %v", rec.Payloads())
+	}
+	digest := rec.Last().(notify.CommonMessage)
+	if digest.Content != "• first\n• Backup: completed" || digest.Format != notify.FormatMarkdown {
+		t.Errorf("unexpected digest %q in format %q", digest.Content, digest.Format)
+	}
+
+	// Held messages keep their context values; labelled ones skip the digest.
+	var sent []sentMessage
+	q = notify.NewQuietHours(notifierFunc(func(ctx context.Context, payload interface{}) error {
+		sent = append(sent, sentMessage{payload, notify.LabelsOf(ctx, payload), notify.SeverityOf(ctx, payload)})
+		return nil
+	}), utc, weekend, notify.WithQuietClock(clock), notify.WithQuietPolicy(notify.QuietDigest))
+	q.Send(notify.ContextWithLabels(ctx, map[string]string{"service": "db"}), "replica lag")
+	q.Send(notify.ContextWithSeverity(ctx, notify.SeverityWarning), "disk at 80%")
+	q.Send(ctx, notify.CommonMessage{Content: "<b>done</b>", Format: notify.FormatHTML})
+	flushCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := q.Flush(flushCtx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if len(sent) != 3 {
+		t.Fatalf("expected two digests and one message, got %+v", sent)
+	}
+	if d := sent[0].payload.(notify.CommonMessage); d.Content != "• disk at 80%" || sent[0].severity != notify.SeverityWarning {
+		t.Errorf("unexpected markdown digest %+v", sent[0])
+	}
+	if d := sent[1].payload.(notify.CommonMessage); d.Content != "• <b>done</b>" || d.Format != notify.FormatHTML {
+		t.Errorf("unexpected HTML digest %+v", sent[1])
+	}
+	if sent[2].payload != "replica lag" || sent[2].labels["service"] != "db" {
+		t.Errorf("expected labelled message to keep its labels, got %+v", sent[2])
+	}
+
+	if q.Quiet(time.Date(2025, 1, 6, 12, 0, 0, 0, utc)) {
+		t.Errorf("expected Monday to be outside quiet hours")
+	}
+}
+
+type notifierFunc func(ctx context.Context, payload interface{}) error
+
+func (f notifierFunc) Send(ctx context.Context, payload interface{}) error {
+	return f(ctx, payload)
+}
+
+type sentMessage struct {
+	payload  interface{}
+	labels   map[string]string
+	severity notify.Severity
+}
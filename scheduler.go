@@ -0,0 +1,361 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/thanpawatpiti/notify/internal/randid"
+)
+
+// ErrJobNotFound is returned when a scheduled job does not exist.
+var ErrJobNotFound = errors.New("job not found")
+
+// Job is a notification scheduled for later delivery.
+type Job struct {
+	// ID uniquely identifies the job. It is used for cancellation.
+	ID string
+	// Notifier delivers the payload. It is nil for jobs scheduled by name.
+	Notifier Notifier
+	// NotifierName is the name the notifier was registered under with
+	// WithNotifier, for jobs scheduled with ScheduleNamed or
+	// ScheduleRecurringNamed. It is resolved when the job is delivered.
+	NotifierName string
+	// Payload is passed to Notifier.Send.
+	Payload interface{}
+	// SendAt is the next delivery time.
+	SendAt time.Time
+	// Spec is the cron expression of a recurring job. It is empty for one-off jobs.
+	Spec string
+}
+
+// JobStore persists scheduled jobs.
+// Implementations must be safe for concurrent use.
+//
+// Jobs scheduled with a Notifier value hold a live object and can only be
+// kept in memory. Jobs scheduled by name hold just the notifier name and
+// the payload, so a durable store can encode them, for example with
+// encoding/gob after registering the payload types with gob.Register, and
+// a restarted Scheduler with the same WithNotifier names delivers them.
+type JobStore interface {
+	// Save inserts or replaces the job with the same ID.
+	Save(ctx context.Context, job Job) error
+	// Get returns the job with the given ID, or ErrJobNotFound.
+	Get(ctx context.Context, id string) (Job, error)
+	// Delete removes the job with the given ID, or returns ErrJobNotFound.
+	Delete(ctx context.Context, id string) error
+	// List returns all pending jobs.
+	List(ctx context.Context) ([]Job, error)
+}
+
+// MemoryJobStore is an in-memory JobStore. Jobs are lost when the process exits.
+type MemoryJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+// NewMemoryJobStore creates an empty MemoryJobStore.
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{jobs: make(map[string]Job)}
+}
+
+// Save implements JobStore.
+func (s *MemoryJobStore) Save(ctx context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+// Get implements JobStore.
+func (s *MemoryJobStore) Get(ctx context.Context, id string) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// Delete implements JobStore.
+func (s *MemoryJobStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[id]; !ok {
+		return ErrJobNotFound
+	}
+	delete(s.jobs, id)
+	return nil
+}
+
+// List implements JobStore. Jobs are ordered by SendAt.
+func (s *MemoryJobStore) List(ctx context.Context) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].SendAt.Before(jobs[j].SendAt) })
+	return jobs, nil
+}
+
+// Scheduler delivers notifications at a later time or on a cron schedule.
+// Jobs are only delivered while Run is active.
+type Scheduler struct {
+	store     JobStore
+	notifiers map[string]Notifier
+	clock     Clock
+	onError   func(Job, error)
+	wake      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// SchedulerOption configures a Scheduler.
+type SchedulerOption func(*Scheduler)
+
+// WithJobStore configures the store used to persist jobs. Defaults to a MemoryJobStore.
+func WithJobStore(store JobStore) SchedulerOption {
+	return func(s *Scheduler) {
+		s.store = store
+	}
+}
+
+// WithNotifier registers n under name for jobs scheduled with
+// ScheduleNamed and ScheduleRecurringNamed.
+func WithNotifier(name string, n Notifier) SchedulerOption {
+	return func(s *Scheduler) {
+		s.notifiers[name] = n
+	}
+}
+
+// WithClock configures the clock used to decide when jobs are due. Defaults to SystemClock.
+func WithClock(c Clock) SchedulerOption {
+	return func(s *Scheduler) {
+		s.clock = c
+	}
+}
+
+// WithErrorHandler configures a callback for jobs that fail to send, and
+// for due jobs that cannot be delivered because their notifier is not
+// registered, their cron spec is invalid or the store cannot be updated.
+func WithErrorHandler(f func(Job, error)) SchedulerOption {
+	return func(s *Scheduler) {
+		s.onError = f
+	}
+}
+
+// NewScheduler creates a new Scheduler.
+func NewScheduler(opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		store:     NewMemoryJobStore(),
+		notifiers: make(map[string]Notifier),
+		clock:     SystemClock,
+		onError:   func(Job, error) {},
+		wake:      make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Schedule queues payload to be sent through n at sendAt and returns the job ID.
+// A sendAt in the past is delivered on the next run of the scheduler loop.
+func (s *Scheduler) Schedule(ctx context.Context, n Notifier, payload interface{}, sendAt time.Time) (string, error) {
+	return s.add(ctx, Job{Notifier: n, Payload: payload, SendAt: sendAt})
+}
+
+// ScheduleNamed is like Schedule, but sends through the notifier
+// registered as name with WithNotifier; see JobStore.
+func (s *Scheduler) ScheduleNamed(ctx context.Context, name string, payload interface{}, sendAt time.Time) (string, error) {
+	return s.add(ctx, Job{NotifierName: name, Payload: payload, SendAt: sendAt})
+}
+
+// ScheduleRecurring queues payload to be sent through n whenever the cron
+// expression spec fires (see ParseCron) and returns the job ID.
+func (s *Scheduler) ScheduleRecurring(ctx context.Context, n Notifier, payload interface{}, spec string) (string, error) {
+	return s.addRecurring(ctx, Job{Notifier: n, Payload: payload, Spec: spec})
+}
+
+// ScheduleRecurringNamed is like ScheduleRecurring, but sends through the
+// notifier registered as name with WithNotifier; see JobStore.
+func (s *Scheduler) ScheduleRecurringNamed(ctx context.Context, name string, payload interface{}, spec string) (string, error) {
+	return s.addRecurring(ctx, Job{NotifierName: name, Payload: payload, Spec: spec})
+}
+
+func (s *Scheduler) addRecurring(ctx context.Context, job Job) (string, error) {
+	cron, err := ParseCron(job.Spec)
+	if err != nil {
+		return "", err
+	}
+	job.SendAt = cron.Next(s.clock.Now())
+	if job.SendAt.IsZero() {
+		return "", fmt.Errorf("cron spec %q never fires", job.Spec)
+	}
+	return s.add(ctx, job)
+}
+
+// Cancel removes a pending job. It returns ErrJobNotFound if the job has
+// already been delivered or never existed.
+func (s *Scheduler) Cancel(ctx context.Context, id string) error {
+	if err := s.store.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+// Jobs returns the pending jobs.
+func (s *Scheduler) Jobs(ctx context.Context) ([]Job, error) {
+	return s.store.List(ctx)
+}
+
+// jobRetryDelay is how long Run waits before retrying a due job that could
+// not be delivered, e.g. because the store failed.
+const jobRetryDelay = 30 * time.Second
+
+// Run delivers due jobs until ctx is cancelled. It waits for in-flight
+// deliveries before returning ctx.Err(). Due jobs that cannot be delivered
+// are reported to the error handler and retried after 30 seconds.
+func (s *Scheduler) Run(ctx context.Context) error {
+	defer s.wg.Wait()
+
+	retryAt := make(map[string]time.Time)
+	for {
+		jobs, err := s.store.List(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list jobs: %w", err)
+		}
+
+		now := s.clock.Now()
+		var next time.Time
+		fired := false
+		retries := make(map[string]time.Time)
+		for _, job := range jobs {
+			due := job.SendAt
+			if at, ok := retryAt[job.ID]; ok && at.After(due) {
+				due = at
+			}
+			if !due.After(now) {
+				if s.fire(ctx, job, now) {
+					fired = true
+					continue
+				}
+				due = now.Add(jobRetryDelay)
+			}
+			if due != job.SendAt {
+				retries[job.ID] = due
+			}
+			if next.IsZero() || due.Before(next) {
+				next = due
+			}
+		}
+		retryAt = retries
+		if fired {
+			// Recurring jobs were rescheduled; list again to find the next due time.
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var timer <-chan time.Time
+		stop := func() {}
+		if !next.IsZero() {
+			timer, stop = NewTimer(s.clock, next.Sub(now))
+		}
+
+		select {
+		case <-ctx.Done():
+			stop()
+			return ctx.Err()
+		case <-s.wake:
+		case <-timer:
+		}
+		stop()
+	}
+}
+
+// fire reschedules or removes job and delivers it in the background. It
+// reports whether job is done with; otherwise it is left in the store.
+func (s *Scheduler) fire(ctx context.Context, job Job, now time.Time) bool {
+	n, err := s.notifier(job)
+	if err != nil {
+		s.onError(job, err)
+		return false
+	}
+
+	if job.Spec != "" {
+		next := job
+		if cron, err := ParseCron(job.Spec); err != nil {
+			s.onError(job, fmt.Errorf("invalid cron spec, delivering once: %w", err))
+		} else {
+			next.SendAt = cron.Next(now)
+		}
+		if next.SendAt.After(now) {
+			if err := s.store.Save(ctx, next); err != nil {
+				s.onError(job, fmt.Errorf("failed to reschedule job: %w", err))
+				return false
+			}
+		} else if err := s.store.Delete(ctx, job.ID); err != nil && !errors.Is(err, ErrJobNotFound) {
+			s.onError(job, fmt.Errorf("failed to remove job: %w", err))
+			return false
+		}
+	} else if err := s.store.Delete(ctx, job.ID); errors.Is(err, ErrJobNotFound) {
+		// Cancelled concurrently; do not deliver.
+		return true
+	} else if err != nil {
+		s.onError(job, fmt.Errorf("failed to remove job: %w", err))
+		return false
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := n.Send(ctx, job.Payload); err != nil {
+			s.onError(job, err)
+		}
+	}()
+	return true
+}
+
+// notifier returns the notifier that delivers job.
+func (s *Scheduler) notifier(job Job) (Notifier, error) {
+	if job.Notifier != nil {
+		return job.Notifier, nil
+	}
+	if n, ok := s.notifiers[job.NotifierName]; ok && n != nil {
+		return n, nil
+	}
+	if job.NotifierName == "" {
+		return nil, fmt.Errorf("notifier is nil")
+	}
+	return nil, fmt.Errorf("notifier %q is not registered", job.NotifierName)
+}
+
+func (s *Scheduler) add(ctx context.Context, job Job) (string, error) {
+	if _, err := s.notifier(job); err != nil {
+		return "", err
+	}
+	job.ID = randid.New()
+	if err := s.store.Save(ctx, job); err != nil {
+		return "", fmt.Errorf("failed to save job: %w", err)
+	}
+	s.notify()
+	return job.ID, nil
+}
+
+// notify wakes the Run loop so it picks up added or removed jobs.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
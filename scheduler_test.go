@@ -0,0 +1,259 @@
+package notify_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thanpawatpiti/notify"
+	"github.com/thanpawatpiti/notify/notifytest"
+)
+
+func TestSchedulerDeliversOnTime(t *testing.T) {
+	clock := notifytest.NewClock(time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC))
+	n := notifytest.NewRecorder()
+
+	s := notify.NewScheduler(notify.WithClock(clock))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	id, err := s.Schedule(ctx, n, "maintenance in 15 minutes", clock.Now().Add(45*time.Minute))
+	if err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	cancelled, err := s.Schedule(ctx, n, "cancelled", clock.Now().Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	if err := s.Cancel(ctx, cancelled); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	if !clock.BlockUntil(1, time.Second) {
+		t.Fatal("timed out waiting for a pending timer")
+	}
+	clock.Advance(44 * time.Minute)
+	if n.Wait(1, 20*time.Millisecond) {
+		t.Fatalf("sent too early: %v", n.Last())
+	}
+
+	clock.Advance(time.Minute)
+	if !n.Wait(1, time.Second) {
+		t.Fatal("job was not delivered")
+	}
+	n.AssertSent(t, "maintenance in 15 minutes")
+	n.AssertCount(t, 1)
+
+	if err := s.Cancel(ctx, id); !errors.Is(err, notify.ErrJobNotFound) {
+		t.Errorf("expected ErrJobNotFound for delivered job, got %v", err)
+	}
+}
+
+func TestSchedulerRecurring(t *testing.T) {
+	clock := notifytest.NewClock(time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC))
+	n := notifytest.NewRecorder()
+
+	s := notify.NewScheduler(notify.WithClock(clock))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	if _, err := s.ScheduleRecurring(ctx, n, "daily summary", "0 9 * * *"); err != nil {
+		t.Fatalf("ScheduleRecurring: %v", err)
+	}
+
+	for day := 0; day < 2; day++ {
+		if !clock.BlockUntil(1, time.Second) {
+			t.Fatal("timed out waiting for a pending timer")
+		}
+		if day == 0 {
+			clock.Advance(time.Hour)
+		} else {
+			clock.Advance(24 * time.Hour)
+		}
+		if !n.Wait(day+1, time.Second) {
+			t.Fatalf("day %d: summary was not delivered", day)
+		}
+	}
+
+	jobs, _ := s.Jobs(ctx)
+	if len(jobs) != 1 || !jobs[0].SendAt.Equal(time.Date(2025, 1, 3, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected pending jobs %+v", jobs)
+	}
+}
+
+// gobStore is a JobStore that keeps jobs only in encoded form, like a
+// durable store would.
+type gobStore struct {
+	mu   sync.Mutex
+	jobs map[string][]byte
+}
+
+func (s *gobStore) Save(ctx context.Context, job notify.Job) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(job); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = buf.Bytes()
+	return nil
+}
+
+func (s *gobStore) Get(ctx context.Context, id string) (notify.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.jobs[id]
+	if !ok {
+		return notify.Job{}, notify.ErrJobNotFound
+	}
+	var job notify.Job
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&job)
+	return job, err
+}
+
+func (s *gobStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[id]; !ok {
+		return notify.ErrJobNotFound
+	}
+	delete(s.jobs, id)
+	return nil
+}
+
+func (s *gobStore) List(ctx context.Context) ([]notify.Job, error) {
+	s.mu.Lock()
+	ids := make([]string, 0, len(s.jobs))
+	for id := range s.jobs {
+		ids = append(ids, id)
+	}
+	s.mu.Unlock()
+	jobs := make([]notify.Job, 0, len(ids))
+	for _, id := range ids {
+		job, err := s.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func TestSchedulerNamedJobs(t *testing.T) {
+	gob.Register(notify.CommonMessage{})
+	clock := notifytest.NewClock(time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC))
+	store := &gobStore{jobs: make(map[string][]byte)}
+	ctx := context.Background()
+
+	// Jobs scheduled by name survive encoding and a new Scheduler.
+	s := notify.NewScheduler(notify.WithJobStore(store), notify.WithNotifier("ops", notifytest.NewRecorder()))
+	if _, err := s.ScheduleNamed(ctx, "ops", notify.CommonMessage{Title: "Maintenance"}, clock.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("ScheduleNamed: %v", err)
+	}
+	if _, err := s.ScheduleRecurringNamed(ctx, "ops", "daily summary", "0 10 * * *"); err != nil {
+		t.Fatalf("ScheduleRecurringNamed: %v", err)
+	}
+	if _, err := s.ScheduleNamed(ctx, "unknown", "x", clock.Now()); err == nil {
+		t.Error("expected error for an unregistered notifier")
+	}
+
+	n := notifytest.NewRecorder()
+	restarted := notify.NewScheduler(notify.WithJobStore(store), notify.WithClock(clock), notify.WithNotifier("ops", n))
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go restarted.Run(runCtx)
+
+	if !clock.BlockUntil(1, time.Second) {
+		t.Fatal("scheduler is not waiting")
+	}
+	clock.Advance(time.Hour)
+	if !n.Wait(1, time.Second) {
+		t.Fatal("job was not delivered")
+	}
+	n.AssertSent(t, notify.CommonMessage{Title: "Maintenance"})
+}
+
+// flakyStore is a MemoryJobStore whose Delete fails while failing is set.
+type flakyStore struct {
+	*notify.MemoryJobStore
+	failing atomic.Bool
+}
+
+func (s *flakyStore) Delete(ctx context.Context, id string) error {
+	if s.failing.Load() {
+		return errors.New("store unavailable")
+	}
+	return s.MemoryJobStore.Delete(ctx, id)
+}
+
+func TestSchedulerStoreErrors(t *testing.T) {
+	clock := notifytest.NewClock(time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC))
+	store := &flakyStore{MemoryJobStore: notify.NewMemoryJobStore()}
+	store.failing.Store(true)
+	errs := make(chan error, 10)
+	n := notifytest.NewRecorder()
+	s := notify.NewScheduler(notify.WithJobStore(store), notify.WithClock(clock), notify.WithNotifier("ops", n),
+		notify.WithErrorHandler(func(job notify.Job, err error) { errs <- err }))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := s.ScheduleNamed(ctx, "ops", "backup done", clock.Now()); err != nil {
+		t.Fatalf("ScheduleNamed: %v", err)
+	}
+	go s.Run(ctx)
+
+	// A failed Delete is reported once and retried later instead of spinning.
+	if err := <-errs; !strings.Contains(err.Error(), "store unavailable") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if !clock.BlockUntil(1, time.Second) {
+		t.Fatal("scheduler did not back off after a store error")
+	}
+	if len(errs) != 0 {
+		t.Errorf("expected one error, got %d more", len(errs))
+	}
+	store.failing.Store(false)
+	clock.Advance(30 * time.Second)
+	if !n.Wait(1, time.Second) {
+		t.Fatal("job was not delivered after the store recovered")
+	}
+
+	// Jobs whose notifier is not registered are kept.
+	id, err := s.ScheduleNamed(ctx, "ops", "x", clock.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ScheduleNamed: %v", err)
+	}
+	if !clock.BlockUntil(1, time.Second) {
+		t.Fatal("scheduler is not waiting")
+	}
+	job, _ := store.Get(ctx, id)
+	job.NotifierName = "retired"
+	store.Save(ctx, job)
+	clock.Advance(time.Hour)
+	if err := <-errs; !strings.Contains(err.Error(), `"retired" is not registered`) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := store.Get(ctx, id); err != nil {
+		t.Errorf("expected job to be kept, got %v", err)
+	}
+	store.Delete(ctx, id)
+
+	// An invalid cron spec is reported, and the job delivered a last time.
+	store.Save(ctx, notify.Job{ID: "bad", NotifierName: "ops", Payload: "weekly", SendAt: clock.Now(), Spec: "bogus"})
+	s.ScheduleNamed(ctx, "ops", "wake", clock.Now().Add(time.Hour))
+	if err := <-errs; !strings.Contains(err.Error(), "invalid cron spec") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if !n.Wait(2, time.Second) {
+		t.Fatal("job with an invalid spec was not delivered")
+	}
+	n.AssertSent(t, "weekly")
+}